@@ -62,6 +62,43 @@ func ConnectDB() {
 		&models.Block{},          // user blocking/muting
 		&models.Recommendation{}, // health recommendations
 		&models.Notification{},   // user notifications
+		&models.Appointment{},
+		&models.CalendarFeedToken{}, // ICS subscription tokens
+		&models.Medication{},        // contraception & medications
+		&models.DoseLog{},
+		&models.ImportJob{},      // cycle history imports
+		&models.DailyLog{},       // day-level mood & symptom logs
+		&models.DRSPEntry{},      // PMS/PMDD daily ratings
+		&models.DueDateHistory{}, // pregnancy due date changes
+		&models.Baby{},           // infants from deliveries
+		&models.GrowthMeasurement{},
+		&models.BabyCareLog{},
+		&models.Vaccination{},
+		&models.PregnancyWeekContent{}, // week-by-week pregnancy guide
+		&models.PregnancyContentDelivery{},
+		&models.KickSession{}, // kick counter & contraction timer
+		&models.KickEvent{},
+		&models.ContractionSession{},
+		&models.Contraction{},
+		&models.VitalThreshold{}, // prenatal vitals rules & care team
+		&models.ClinicalAlert{},
+		&models.CareTeamLink{},
+		&models.VitalReading{},
+		&models.GlucoseTarget{},
+		&models.EPDSResponse{},
+		&models.FeedingSession{},
+		&models.FeedingPreference{},
+		&models.LochiaLog{},
+		&models.MonitoringTemplate{},
+		&models.MonitoringRecord{},
+		&models.AppointmentEvent{},
+		&models.DoctorAvailability{},
+		&models.DoctorTimeOff{},
+		&models.AppointmentType{},
+		&models.ReminderJob{},
+		&models.ReminderPreference{},
+		&models.VisitRoom{},
+		&models.VisitEvent{},
 	)
 	if err != nil {
 		log.Fatalf("❌ AutoMigration failed: %v", err)
@@ -63,10 +63,28 @@ func parseRange(c *gin.Context) (*time.Time, *time.Time, bool) {
 	return fromPtr, toPtr, true
 }
 
+// analyticsSelfOrAbort returns the :user_id param if it is the signed-in
+// user; analytics are not shown to anyone else through the user routes
+func analyticsSelfOrAbort(c *gin.Context) uuid.UUID {
+	userID := utils.ParseUUIDParamOrAbort(c, "user_id")
+	if userID == uuid.Nil {
+		return uuid.Nil
+	}
+	callerID := utils.GetUserIDFromContextOrAbort(c)
+	if callerID == uuid.Nil {
+		return uuid.Nil
+	}
+	if callerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own analytics"})
+		return uuid.Nil
+	}
+	return userID
+}
+
 // --------- User self-analytics ---------
 // GET /analytics/user/:user_id/pregnancy-postpartum
 func GetPregnancyPostpartumAnalytics(c *gin.Context) {
-	userID := utils.ParseUUIDParamOrAbort(c, "user_id")
+	userID := analyticsSelfOrAbort(c)
 	if userID == uuid.Nil {
 		return
 	}
@@ -76,7 +94,7 @@ func GetPregnancyPostpartumAnalytics(c *gin.Context) {
 		return
 	}
 
-	result, err := services.GetCombinedAnalytics(userID, fromPtr, toPtr)
+	result, err := services.GetCombinedAnalytics(userID, userID, fromPtr, toPtr)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load analytics"})
 		return
@@ -93,17 +111,22 @@ func GetPatientAnalyticsForDoctor(c *gin.Context) {
 		return
 	}
 
-	patientID := utils.ParseUUIDParamOrAbort(c, "patient_id")
+	// Vitals, glucose and recovery trends are only shared with the patient's care team
+	patientID := patientWithConsentOrAbort(c)
 	if patientID == uuid.Nil {
 		return
 	}
+	viewerID := utils.GetUserIDFromContextOrAbort(c)
+	if viewerID == uuid.Nil {
+		return
+	}
 
 	fromPtr, toPtr, ok := parseRange(c)
 	if !ok {
 		return
 	}
 
-	result, err := services.GetCombinedAnalytics(patientID, fromPtr, toPtr)
+	result, err := services.GetCombinedAnalytics(patientID, viewerID, fromPtr, toPtr)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load analytics"})
 		return
@@ -114,7 +137,7 @@ func GetPatientAnalyticsForDoctor(c *gin.Context) {
 // --------- CSV export (user self) ---------
 // GET /analytics/user/:user_id/pregnancy-postpartum.csv
 func ExportPregnancyPostpartumCSV(c *gin.Context) {
-	userID := utils.ParseUUIDParamOrAbort(c, "user_id")
+	userID := analyticsSelfOrAbort(c)
 	if userID == uuid.Nil {
 		return
 	}
@@ -124,7 +147,7 @@ func ExportPregnancyPostpartumCSV(c *gin.Context) {
 		return
 	}
 
-	result, err := services.GetCombinedAnalytics(userID, fromPtr, toPtr)
+	result, err := services.GetCombinedAnalytics(userID, userID, fromPtr, toPtr)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load analytics"})
 		return
@@ -137,15 +160,15 @@ func ExportPregnancyPostpartumCSV(c *gin.Context) {
 
 	// Section 1: Weight trend
 	_ = w.Write([]string{"Weight Trend"})
-	_ = w.Write([]string{"Time", "Weight"})
+	_ = w.Write([]string{"Time", "Weight", "Source"})
 	for _, p := range result.WeightTrend {
-		_ = w.Write([]string{p.Time.UTC().Format(time.RFC3339), strconvFloat(p.Value)})
+		_ = w.Write([]string{p.Time.UTC().Format(time.RFC3339), strconvFloat(p.Value), p.Source})
 	}
 	_ = w.Write([]string{}) // blank line
 
 	// Section 2: Blood pressure
 	_ = w.Write([]string{"Blood Pressure"})
-	_ = w.Write([]string{"Time", "Systolic", "Diastolic", "Raw"})
+	_ = w.Write([]string{"Time", "Systolic", "Diastolic", "Raw", "Source"})
 	for _, bp := range result.BloodPressure {
 		var s, d string
 		if bp.Systolic != nil {
@@ -154,7 +177,7 @@ func ExportPregnancyPostpartumCSV(c *gin.Context) {
 		if bp.Diastolic != nil {
 			d = strconvInt(*bp.Diastolic)
 		}
-		_ = w.Write([]string{bp.Time.UTC().Format(time.RFC3339), s, d, bp.Raw})
+		_ = w.Write([]string{bp.Time.UTC().Format(time.RFC3339), s, d, bp.Raw, bp.Source})
 	}
 	_ = w.Write([]string{})
 
@@ -175,17 +198,22 @@ func ExportPatientAnalyticsCSVForDoctor(c *gin.Context) {
 		return
 	}
 
-	patientID := utils.ParseUUIDParamOrAbort(c, "patient_id")
+	// Vitals, glucose and recovery trends are only shared with the patient's care team
+	patientID := patientWithConsentOrAbort(c)
 	if patientID == uuid.Nil {
 		return
 	}
+	viewerID := utils.GetUserIDFromContextOrAbort(c)
+	if viewerID == uuid.Nil {
+		return
+	}
 
 	fromPtr, toPtr, ok := parseRange(c)
 	if !ok {
 		return
 	}
 
-	result, err := services.GetCombinedAnalytics(patientID, fromPtr, toPtr)
+	result, err := services.GetCombinedAnalytics(patientID, viewerID, fromPtr, toPtr)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load analytics"})
 		return
@@ -197,14 +225,14 @@ func ExportPatientAnalyticsCSVForDoctor(c *gin.Context) {
 	defer w.Flush()
 
 	_ = w.Write([]string{"Weight Trend"})
-	_ = w.Write([]string{"Time", "Weight"})
+	_ = w.Write([]string{"Time", "Weight", "Source"})
 	for _, p := range result.WeightTrend {
-		_ = w.Write([]string{p.Time.UTC().Format(time.RFC3339), strconvFloat(p.Value)})
+		_ = w.Write([]string{p.Time.UTC().Format(time.RFC3339), strconvFloat(p.Value), p.Source})
 	}
 	_ = w.Write([]string{})
 
 	_ = w.Write([]string{"Blood Pressure"})
-	_ = w.Write([]string{"Time", "Systolic", "Diastolic", "Raw"})
+	_ = w.Write([]string{"Time", "Systolic", "Diastolic", "Raw", "Source"})
 	for _, bp := range result.BloodPressure {
 		var s, d string
 		if bp.Systolic != nil {
@@ -213,7 +241,7 @@ func ExportPatientAnalyticsCSVForDoctor(c *gin.Context) {
 		if bp.Diastolic != nil {
 			d = strconvInt(*bp.Diastolic)
 		}
-		_ = w.Write([]string{bp.Time.UTC().Format(time.RFC3339), s, d, bp.Raw})
+		_ = w.Write([]string{bp.Time.UTC().Format(time.RFC3339), s, d, bp.Raw, bp.Source})
 	}
 	_ = w.Write([]string{})
 
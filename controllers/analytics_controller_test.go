@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestAnalyticsSelfOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	caller, other := uuid.New(), uuid.New()
+
+	for _, handler := range []gin.HandlerFunc{GetPregnancyPostpartumAnalytics, ExportPregnancyPostpartumCSV} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Params = gin.Params{{Key: "user_id", Value: other.String()}}
+		c.Set("user_id", caller.String())
+
+		handler(c)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("another user's analytics: got status %d, want %d", w.Code, http.StatusForbidden)
+		}
+	}
+}
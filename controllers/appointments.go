@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,69 +9,285 @@ import (
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
 )
 
+// respondAppointmentError maps appointment lifecycle errors to responses
+func respondAppointmentError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrAppointmentInPast), errors.Is(err, services.ErrCancellationReasonBlank),
+		errors.Is(err, services.ErrNotADoctor), errors.Is(err, services.ErrSlotOutsideAvailability),
+		errors.Is(err, services.ErrCheckupKindRequired), errors.Is(err, services.ErrInvalidAppointmentMode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotAppointmentParty), errors.Is(err, services.ErrNoCareTeamConsent):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentNotFound), errors.Is(err, services.ErrAppointmentTypeNotFound),
+		errors.Is(err, services.ErrPatientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentTransition), errors.Is(err, services.ErrAppointmentNotStarted),
+		errors.Is(err, services.ErrSlotTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// CreateAppointment lets a patient request an appointment with a doctor, or a
+// doctor book one for a patient whose care team they are on (confirmed immediately)
 func CreateAppointment(c *gin.Context) {
+	actorID := utils.GetUserIDFromContextOrAbort(c)
+	if actorID == uuid.Nil {
+		return
+	}
+
 	var input struct {
-		UserID      string `json:"user_id" binding:"required"`
-		DoctorID    string `json:"doctor_id" binding:"required"`
+		UserID      string `json:"user_id"` // the patient; required when a doctor books
+		DoctorID    string `json:"doctor_id"`
 		Title       string `json:"title"`
 		Description string `json:"description"`
 		Location    string `json:"location"`
-		ScheduledAt string `json:"scheduled_at"` // "2025-07-01T15:04:00"
+		Mode        string `json:"mode"`                            // in_person (default) or virtual
+		ScheduledAt string `json:"scheduled_at" binding:"required"` // RFC3339
 		IsFollowUp  bool   `json:"is_follow_up"`
-	}
 
+		AppointmentTypeID *uuid.UUID `json:"appointment_type_id"` // sets the length
+	}
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
 		return
 	}
 
-	userUUID, err := uuid.Parse(input.UserID)
-	doctorUUID, err2 := uuid.Parse(input.DoctorID)
-	if err != nil || err2 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user or doctor ID"})
+	scheduledAt, err := time.Parse(time.RFC3339, input.ScheduledAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datetime format. Use ISO 8601"})
 		return
 	}
 
-	scheduledAt, err := time.Parse(time.RFC3339, input.ScheduledAt)
+	role, err := getRoleFromContextOrDB(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datetime format. Use ISO 8601"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to determine role"})
 		return
 	}
 
-	appt := models.Appointment{
-		ID:          uuid.New(),
-		UserID:      userUUID,
-		DoctorID:    doctorUUID,
+	req := services.AppointmentRequest{
 		Title:       input.Title,
 		Description: input.Description,
 		Location:    input.Location,
+		Mode:        input.Mode,
 		ScheduledAt: scheduledAt,
 		IsFollowUp:  input.IsFollowUp,
+
+		AppointmentTypeID: input.AppointmentTypeID,
+	}
+	if role == models.RoleDoctor && input.UserID != "" {
+		patientID, err := uuid.Parse(input.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		req.PatientID, req.DoctorID = patientID, actorID
+	} else {
+		doctorID, err := uuid.Parse(input.DoctorID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+			return
+		}
+		req.PatientID, req.DoctorID = actorID, doctorID
 	}
 
-	if err := config.DB.Create(&appt).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create appointment"})
+	appt, err := services.CreateAppointment(actorID, req, time.Now())
+	if err != nil {
+		respondAppointmentError(c, err, "Could not create appointment")
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Appointment scheduled", "appointment": appt})
 }
 
-func GetAppointmentsForUser(c *gin.Context) {
-	userID := c.Param("id")
-	userUUID, err := uuid.Parse(userID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
+// appointmentsQuery lists appointments on one side, filtered by optional status and upcoming=true
+func appointmentsQuery(c *gin.Context, column string, id uuid.UUID) {
+	query := config.DB.Where(column+" = ?", id)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("upcoming") == "true" {
+		query = query.Where("scheduled_at >= ?", time.Now())
 	}
 
 	var appts []models.Appointment
-	if err := config.DB.Where("user_id = ?", userUUID).Order("scheduled_at asc").Find(&appts).Error; err != nil {
+	if err := query.Order("scheduled_at asc").Find(&appts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointments"})
 		return
 	}
 
 	c.JSON(http.StatusOK, appts)
 }
+
+// GetMyAppointments lists the caller's appointments as a patient
+func GetMyAppointments(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	appointmentsQuery(c, "user_id", userID)
+}
+
+// GetDoctorAppointments lists the calling doctor's appointments
+func GetDoctorAppointments(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+	appointmentsQuery(c, "doctor_id", doctorID)
+}
+
+// GetAppointmentsForUser lists a patient's appointments; only the patient may view them
+func GetAppointmentsForUser(c *gin.Context) {
+	userUUID := utils.ParseUUIDParamOrAbort(c, "id")
+	if userUUID == uuid.Nil {
+		return
+	}
+	callerID := utils.GetUserIDFromContextOrAbort(c)
+	if callerID == uuid.Nil {
+		return
+	}
+	if callerID != userUUID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own appointments"})
+		return
+	}
+	appointmentsQuery(c, "user_id", userUUID)
+}
+
+// GetAppointment returns an appointment and its status history to either party
+func GetAppointment(c *gin.Context) {
+	appointmentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if appointmentID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	appt, err := services.GetAppointmentForParty(appointmentID, userID)
+	if err != nil {
+		respondAppointmentError(c, err, "Failed to retrieve appointment")
+		return
+	}
+	history, err := services.GetAppointmentHistory(appt.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointment history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": appt, "history": history})
+}
+
+// changeAppointment runs a lifecycle action for the caller
+func changeAppointment(c *gin.Context, change services.AppointmentChange) {
+	appointmentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if appointmentID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	appt, err := services.ChangeAppointment(appointmentID, userID, change, time.Now())
+	if err != nil {
+		respondAppointmentError(c, err, "Failed to update appointment")
+		return
+	}
+
+	c.JSON(http.StatusOK, appt)
+}
+
+// bindAppointmentNote reads an optional {"reason": "..."} body
+func bindAppointmentNote(c *gin.Context) (string, bool) {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return "", false
+		}
+	}
+	return input.Reason, true
+}
+
+// ConfirmAppointment accepts a requested appointment: the doctor confirms a
+// patient's request, the patient confirms a follow-up the doctor proposed
+func ConfirmAppointment(c *gin.Context) {
+	note, ok := bindAppointmentNote(c)
+	if !ok {
+		return
+	}
+	changeAppointment(c, services.AppointmentChange{Action: models.AppointmentActionConfirmed, Reason: note})
+}
+
+// CancelAppointment lets either party cancel with a reason
+func CancelAppointment(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrCancellationReasonBlank.Error()})
+		return
+	}
+	changeAppointment(c, services.AppointmentChange{Action: models.AppointmentActionCancelled, Reason: input.Reason})
+}
+
+// RescheduleAppointment moves an appointment to a new time
+func RescheduleAppointment(c *gin.Context) {
+	var input struct {
+		ScheduledAt string `json:"scheduled_at" binding:"required"` // RFC3339
+		Reason      string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, input.ScheduledAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datetime format. Use ISO 8601"})
+		return
+	}
+	changeAppointment(c, services.AppointmentChange{
+		Action:      models.AppointmentActionRescheduled,
+		Reason:      input.Reason,
+		ScheduledAt: &scheduledAt,
+	})
+}
+
+// CompleteAppointment lets the doctor mark a past appointment as attended and
+// records the visit as a checkup. Optional body: {"reason": "...", "checkup": {...}};
+// a next_checkup_at in the checkup proposes a follow-up.
+func CompleteAppointment(c *gin.Context) {
+	var input struct {
+		Reason  string                   `json:"reason"`
+		Checkup *services.CheckupDetails `json:"checkup"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return
+		}
+	}
+	changeAppointment(c, services.AppointmentChange{
+		Action:  models.AppointmentActionCompleted,
+		Reason:  input.Reason,
+		Checkup: input.Checkup,
+	})
+}
+
+// MarkAppointmentNoShow lets the doctor record that the patient did not attend
+func MarkAppointmentNoShow(c *gin.Context) {
+	note, ok := bindAppointmentNote(c)
+	if !ok {
+		return
+	}
+	changeAppointment(c, services.AppointmentChange{Action: models.AppointmentActionNoShow, Reason: note})
+}
@@ -0,0 +1,508 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+var validCareLogKinds = map[string]bool{
+	models.CareLogFeeding: true,
+	models.CareLogDiaper:  true,
+	models.CareLogSleep:   true,
+}
+
+var validFeedingTypes = map[string]bool{"": true, "breast": true, "bottle": true, "solids": true}
+
+var validDiaperTypes = map[string]bool{"": true, "wet": true, "dirty": true, "mixed": true}
+
+// findUserBaby loads a baby owned by the user or writes a 404
+func findUserBaby(c *gin.Context, userID uuid.UUID) (*models.Baby, bool) {
+	babyID := utils.ParseUUIDParamOrAbort(c, "id")
+	if babyID == uuid.Nil {
+		return nil, false
+	}
+	var baby models.Baby
+	if err := config.DB.Where("id = ? AND user_id = ?", babyID, userID).First(&baby).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Baby not found or unauthorized"})
+		return nil, false
+	}
+	return &baby, true
+}
+
+// CreateBabiesFromPregnancy adds one or more babies (twins, triplets) to a delivered pregnancy
+func CreateBabiesFromPregnancy(c *gin.Context) {
+	pregnancyID := utils.ParseUUIDParamOrAbort(c, "pregnancy_id")
+	if pregnancyID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Babies []services.NewBaby `json:"babies" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	babies, err := services.CreateBabiesFromPregnancy(userID, pregnancyID, input.Babies)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPregnancyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+		case errors.Is(err, services.ErrPregnancyNotDelivered), errors.Is(err, services.ErrBabiesAlreadyAdded):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidBabySex), errors.Is(err, services.ErrInvalidBabyCount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add babies"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, babies)
+}
+
+// GetBabies lists the authenticated user's babies
+func GetBabies(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var babies []models.Baby
+	if err := config.DB.Where("user_id = ?", userID).Order("birth_date desc, birth_order asc").Find(&babies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve babies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, babies)
+}
+
+// GetBaby returns a single baby
+func GetBaby(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, baby)
+}
+
+// UpdateBaby updates a baby's name, sex, birth measurements or notes
+func UpdateBaby(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name                     *string  `json:"name"`
+		Sex                      *string  `json:"sex"`
+		BirthWeightKg            *float64 `json:"birth_weight_kg"`
+		BirthLengthCm            *float64 `json:"birth_length_cm"`
+		BirthHeadCircumferenceCm *float64 `json:"birth_head_circumference_cm"`
+		Notes                    *string  `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid update data", "details": err.Error()})
+		return
+	}
+
+	if input.Name != nil {
+		baby.Name = *input.Name
+	}
+	if input.Sex != nil {
+		if *input.Sex != models.BabySexFemale && *input.Sex != models.BabySexMale {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidBabySex.Error()})
+			return
+		}
+		baby.Sex = *input.Sex
+	}
+	if input.BirthWeightKg != nil {
+		baby.BirthWeightKg = *input.BirthWeightKg
+	}
+	if input.BirthLengthCm != nil {
+		baby.BirthLengthCm = *input.BirthLengthCm
+	}
+	if input.BirthHeadCircumferenceCm != nil {
+		baby.BirthHeadCircumferenceCm = *input.BirthHeadCircumferenceCm
+	}
+	if input.Notes != nil {
+		baby.Notes = *input.Notes
+	}
+	baby.UpdatedAt = time.Now()
+
+	if err := config.DB.Save(baby).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update baby"})
+		return
+	}
+
+	c.JSON(http.StatusOK, baby)
+}
+
+// AddGrowthMeasurement records a weight, length or head circumference measurement
+func AddGrowthMeasurement(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		MeasuredAt          time.Time `json:"measured_at" binding:"required"`
+		WeightKg            *float64  `json:"weight_kg"`
+		LengthCm            *float64  `json:"length_cm"`
+		HeadCircumferenceCm *float64  `json:"head_circumference_cm"`
+		Notes               string    `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if input.WeightKg == nil && input.LengthCm == nil && input.HeadCircumferenceCm == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one of weight_kg, length_cm or head_circumference_cm is required"})
+		return
+	}
+	if input.MeasuredAt.Before(baby.BirthDate.Add(-24*time.Hour)) || input.MeasuredAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "measured_at must be between birth and now"})
+		return
+	}
+
+	m := models.GrowthMeasurement{
+		ID:                  uuid.New(),
+		BabyID:              baby.ID,
+		UserID:              userID,
+		MeasuredAt:          input.MeasuredAt,
+		WeightKg:            input.WeightKg,
+		LengthCm:            input.LengthCm,
+		HeadCircumferenceCm: input.HeadCircumferenceCm,
+		Notes:               input.Notes,
+		CreatedAt:           time.Now(),
+	}
+	if err := config.DB.Create(&m).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save measurement"})
+		return
+	}
+
+	services.ApplyGrowthPercentiles(*baby, &m)
+	c.JSON(http.StatusCreated, m)
+}
+
+// GetGrowthChart returns a baby's measurements with WHO percentiles and the
+// reference curves to plot them against
+func GetGrowthChart(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	var measurements []models.GrowthMeasurement
+	if err := config.DB.Where("baby_id = ?", baby.ID).Order("measured_at asc").Find(&measurements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve measurements"})
+		return
+	}
+	for i := range measurements {
+		services.ApplyGrowthPercentiles(*baby, &measurements[i])
+	}
+
+	weightCurve, lengthCurve := services.GrowthReferenceCurves(baby.Sex)
+	c.JSON(http.StatusOK, gin.H{
+		"baby":         baby,
+		"measurements": measurements,
+		"reference": gin.H{
+			"source":           "WHO Child Growth Standards",
+			"weight_for_age":   weightCurve,
+			"length_for_age":   lengthCurve,
+			"age_range_months": []int{0, 12},
+		},
+	})
+}
+
+// AddCareLog records a feeding, diaper change or sleep
+func AddCareLog(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Kind        string     `json:"kind" binding:"required"` // "feeding", "diaper", "sleep"
+		StartedAt   time.Time  `json:"started_at" binding:"required"`
+		EndedAt     *time.Time `json:"ended_at"`
+		FeedingType string     `json:"feeding_type"`
+		AmountMl    float64    `json:"amount_ml"`
+		DiaperType  string     `json:"diaper_type"`
+		Notes       string     `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if !validCareLogKinds[input.Kind] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be feeding, diaper or sleep"})
+		return
+	}
+	if !validFeedingTypes[input.FeedingType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "feeding_type must be breast, bottle or solids"})
+		return
+	}
+	if !validDiaperTypes[input.DiaperType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "diaper_type must be wet, dirty or mixed"})
+		return
+	}
+	if input.EndedAt != nil && input.EndedAt.Before(input.StartedAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ended_at must be after started_at"})
+		return
+	}
+
+	entry := models.BabyCareLog{
+		ID:          uuid.New(),
+		BabyID:      baby.ID,
+		UserID:      userID,
+		Kind:        input.Kind,
+		StartedAt:   input.StartedAt,
+		EndedAt:     input.EndedAt,
+		FeedingType: input.FeedingType,
+		AmountMl:    input.AmountMl,
+		DiaperType:  input.DiaperType,
+		Notes:       input.Notes,
+		CreatedAt:   time.Now(),
+	}
+	if err := config.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save care log"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetCareLogs lists care logs for a baby, optionally filtered by kind and date range (YYYY-MM-DD)
+func GetCareLogs(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Where("baby_id = ?", baby.ID)
+	if kind := c.Query("kind"); kind != "" {
+		if !validCareLogKinds[kind] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be feeding, diaper or sleep"})
+			return
+		}
+		query = query.Where("kind = ?", kind)
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("started_at >= ?", t)
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("started_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var logs []models.BabyCareLog
+	if err := query.Order("started_at desc").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve care logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+// DeleteCareLog removes a care log for a baby
+func DeleteCareLog(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+	logID := utils.ParseUUIDParamOrAbort(c, "log_id")
+	if logID == uuid.Nil {
+		return
+	}
+
+	result := config.DB.Where("id = ? AND baby_id = ?", logID, baby.ID).Delete(&models.BabyCareLog{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete care log"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Care log not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Care log deleted successfully"})
+}
+
+// GetVaccinations lists a baby's vaccination schedule in due order
+func GetVaccinations(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	var vaccinations []models.Vaccination
+	if err := config.DB.Where("baby_id = ?", baby.ID).Order("due_date asc, vaccine asc").Find(&vaccinations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vaccinations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vaccinations)
+}
+
+// AddVaccination adds a vaccine dose that is not on the default schedule
+func AddVaccination(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Vaccine        string     `json:"vaccine" binding:"required"`
+		Dose           int        `json:"dose"`
+		DueDate        time.Time  `json:"due_date" binding:"required"`
+		AdministeredAt *time.Time `json:"administered_at"`
+		Notes          string     `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if input.Dose <= 0 {
+		input.Dose = 1
+	}
+	if err := services.ValidateVaccinationDueDate(baby, input.DueDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	v := models.Vaccination{
+		ID:             uuid.New(),
+		BabyID:         baby.ID,
+		UserID:         userID,
+		Vaccine:        input.Vaccine,
+		Dose:           input.Dose,
+		DueDate:        input.DueDate,
+		AdministeredAt: input.AdministeredAt,
+		Notes:          input.Notes,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := config.DB.Create(&v).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add vaccination"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, v)
+}
+
+// UpdateVaccination marks a vaccine as given or moves its due date
+func UpdateVaccination(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	baby, ok := findUserBaby(c, userID)
+	if !ok {
+		return
+	}
+	vaccinationID := utils.ParseUUIDParamOrAbort(c, "vaccination_id")
+	if vaccinationID == uuid.Nil {
+		return
+	}
+
+	var v models.Vaccination
+	if err := config.DB.Where("id = ? AND baby_id = ?", vaccinationID, baby.ID).First(&v).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vaccination not found"})
+		return
+	}
+
+	var input struct {
+		DueDate        *time.Time `json:"due_date"`
+		AdministeredAt *time.Time `json:"administered_at"`
+		Notes          *string    `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid update data", "details": err.Error()})
+		return
+	}
+
+	if input.DueDate != nil && !input.DueDate.Equal(v.DueDate) {
+		if err := services.ValidateVaccinationDueDate(baby, *input.DueDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		v.DueDate = *input.DueDate
+		v.ReminderSentAt = nil // remind again for the new date
+		v.OverdueReminderSentAt = nil
+	}
+	if input.AdministeredAt != nil {
+		v.AdministeredAt = input.AdministeredAt
+	}
+	if input.Notes != nil {
+		v.Notes = *input.Notes
+	}
+	v.UpdatedAt = time.Now()
+
+	if err := config.DB.Save(&v).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vaccination"})
+		return
+	}
+
+	c.JSON(http.StatusOK, v)
+}
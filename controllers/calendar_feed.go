@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// feedURL builds the public subscription URL for a feed token.
+// PUBLIC_BASE_URL takes precedence so the URL is correct behind proxies.
+func feedURL(c *gin.Context, token string) string {
+	base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/api/calendar/feed/" + token + ".ics"
+}
+
+// GetCalendarFeed returns the user's secret ICS subscription URL, creating it on first use
+func GetCalendarFeed(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	feed, err := services.GetOrCreateFeedToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":        feedURL(c, feed.Token),
+		"created_at": feed.CreatedAt,
+		"rotated_at": feed.RotatedAt,
+	})
+}
+
+// RotateCalendarFeed issues a new feed token, invalidating the previous URL
+func RotateCalendarFeed(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	feed, err := services.RotateFeedToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Calendar feed URL rotated. Re-subscribe with the new URL.",
+		"url":        feedURL(c, feed.Token),
+		"rotated_at": feed.RotatedAt,
+	})
+}
+
+// ServeCalendarFeed renders the ICS document for a feed token. The token is the only credential.
+// GET /calendar/feed/:token (the ".ics" suffix is optional)
+func ServeCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	userID, err := services.FindUserByFeedToken(token)
+	if err != nil {
+		if errors.Is(err, services.ErrFeedTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar feed"})
+		return
+	}
+
+	ics, err := services.BuildICSFeed(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build calendar feed"})
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename=cycle.ics")
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(ics))
+}
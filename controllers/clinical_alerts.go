@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// GetClinicalAlerts lists the authenticated user's clinical alerts, newest first
+func GetClinicalAlerts(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("unacknowledged") == "true" {
+		query = query.Where("acknowledged_at IS NULL")
+	}
+
+	var alerts []models.ClinicalAlert
+	if err := query.Order("observed_at desc").Limit(200).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// AcknowledgeClinicalAlert marks one of the user's alerts as seen
+func AcknowledgeClinicalAlert(c *gin.Context) {
+	alertID := utils.ParseUUIDParamOrAbort(c, "id")
+	if alertID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var alert models.ClinicalAlert
+	if err := config.DB.Where("id = ? AND user_id = ?", alertID, userID).First(&alert).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found or unauthorized"})
+		return
+	}
+
+	if alert.AcknowledgedAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&alert).Update("acknowledged_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge alert"})
+			return
+		}
+		alert.AcknowledgedAt = &now
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetPatientClinicalAlerts lists alerts for patients who share alerts with the doctor (doctor only)
+func GetPatientClinicalAlerts(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	patients := config.DB.Model(&models.CareTeamLink{}).Select("user_id").
+		Where("doctor_id = ? AND share_alerts = ? AND revoked_at IS NULL", doctorID, true)
+
+	var alerts []models.ClinicalAlert
+	if err := config.DB.Where("user_id IN (?)", patients).
+		Order("acknowledged_at IS NOT NULL, observed_at desc").Limit(200).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// GrantCareTeamAccess adds a verified doctor to the user's care team
+func GrantCareTeamAccess(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		DoctorID    uuid.UUID `json:"doctor_id" binding:"required"`
+		ShareAlerts *bool     `json:"share_alerts"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	shareAlerts := true
+	if input.ShareAlerts != nil {
+		shareAlerts = *input.ShareAlerts
+	}
+
+	link, err := services.GrantCareTeamConsent(userID, input.DoctorID, shareAlerts)
+	if err != nil {
+		if errors.Is(err, services.ErrNotADoctor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update care team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// GetCareTeam lists the doctors the user has granted access to
+func GetCareTeam(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var links []models.CareTeamLink
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("consented_at desc").Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve care team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeCareTeamAccess withdraws a doctor's access to the user's data and alerts
+func RevokeCareTeamAccess(c *gin.Context) {
+	doctorID := utils.ParseUUIDParamOrAbort(c, "doctor_id")
+	if doctorID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	now := time.Now()
+	result := config.DB.Model(&models.CareTeamLink{}).
+		Where("user_id = ? AND doctor_id = ? AND revoked_at IS NULL", userID, doctorID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Doctor is not on your care team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked"})
+}
+
+// GetCareTeamPatients lists the patients who have granted the doctor access (doctor only)
+func GetCareTeamPatients(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	var links []models.CareTeamLink
+	if err := config.DB.Where("doctor_id = ? AND revoked_at IS NULL", doctorID).Order("consented_at desc").Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve patients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// GetVitalThresholds lists the clinical rule thresholds in effect (admin only)
+func GetVitalThresholds(c *gin.Context) {
+	thresholds, err := services.GetVitalThresholds()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve thresholds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, thresholds)
+}
+
+// UpdateVitalThreshold overrides a clinical rule threshold (admin only)
+func UpdateVitalThreshold(c *gin.Context) {
+	adminID := utils.GetUserIDFromContextOrAbort(c)
+	if adminID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Value *float64 `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if *input.Value <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value must be positive"})
+		return
+	}
+
+	threshold, err := services.SetVitalThreshold(c.Param("key"), *input.Value, adminID)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownThreshold) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update threshold"})
+		return
+	}
+
+	c.JSON(http.StatusOK, threshold)
+}
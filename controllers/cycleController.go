@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
 	"github.com/shem958/cycle-backend/utils"
 )
 
@@ -45,6 +48,12 @@ func AddCycle(c *gin.Context) {
 		return
 	}
 
+	// A new period after a pregnancy resumes cycle predictions
+	if err := services.ResumeCyclesIfPeriodReturned(userID, input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cycle saved but tracking mode could not be updated"})
+		return
+	}
+
 	c.JSON(http.StatusCreated, input)
 }
 
@@ -109,3 +118,57 @@ func DeleteCycle(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Cycle deleted successfully"})
 }
+
+// GetLatePeriodStatus reports whether the user's period is late and a pregnancy test is suggested
+func GetLatePeriodStatus(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	status, err := services.CheckLatePeriod(userID, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrNoCycleData) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No cycles recorded yet"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check period status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ConfirmPregnancy records a positive pregnancy test and switches the user to pregnancy mode
+func ConfirmPregnancy(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Notes string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	pregnancy, err := services.ConfirmPregnancyFromCycles(userID, input.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoCycleData):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A cycle must be recorded to date the pregnancy"})
+		case errors.Is(err, services.ErrActivePregnancyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "An active pregnancy already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pregnancy"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Pregnancy recorded. Cycle predictions are paused.",
+		"pregnancy": pregnancy,
+	})
+}
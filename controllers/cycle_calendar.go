@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// GetCycleCalendar returns one entry per day with cycle phase, fertility and logged data.
+// GET /cycles/calendar?from=YYYY-MM-DD&to=YYYY-MM-DD (defaults to the current month)
+func GetCycleCalendar(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		to = t
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+	if to.Sub(from).Hours()/24 >= services.MaxCalendarDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Range may cover at most " + strconv.Itoa(services.MaxCalendarDays) + " days"})
+		return
+	}
+
+	calendar, err := services.BuildCycleCalendar(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build cycle calendar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
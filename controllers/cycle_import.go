@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+const maxImportFileSize = 5 << 20 // 5 MB
+
+// ImportCycles imports cycle history from a CSV or JSON export.
+// Multipart form fields: file (required), format ("csv" | "json"), layout ("cycles" | "daily"),
+// mapping (JSON column mapping, e.g. {"start_date": "Period Start", "date_format": "01/02/2006"}).
+func ImportCycles(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file must be 5 MB or smaller"})
+		return
+	}
+
+	var mapping services.ImportColumnMapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object", "details": err.Error()})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+		return
+	}
+
+	job, async, err := services.StartCycleImport(services.ImportRequest{
+		UserID:   userID,
+		Format:   c.PostForm("format"),
+		Layout:   c.PostForm("layout"),
+		FileName: fileHeader.Filename,
+		Mapping:  mapping,
+		Data:     data,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnsupportedImportFormat):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json and layout must be cycles or daily"})
+		case job != nil:
+			// The job was recorded but failed while processing
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "job": job})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if async {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":    "Import started. Check the status endpoint for progress.",
+			"job":        job,
+			"status_url": "/api/cycles/import/" + job.ID.String(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Import completed",
+		"job":     job,
+		"errors":  services.GetImportErrors(*job),
+	})
+}
+
+// GetImportJob returns the status and per-row errors of an import job
+func GetImportJob(c *gin.Context) {
+	jobID := utils.ParseUUIDParamOrAbort(c, "id")
+	if jobID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var job models.ImportJob
+	if err := config.DB.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job":    job,
+		"errors": services.GetImportErrors(job),
+	})
+}
+
+// GetImportJobs lists the authenticated user's import jobs, newest first
+func GetImportJobs(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var jobs []models.ImportJob
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve import jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+	"gorm.io/gorm/clause"
+)
+
+var validFlows = map[string]bool{"": true, "none": true, "spotting": true, "light": true, "medium": true, "heavy": true}
+
+// UpsertDailyLog creates or replaces the authenticated user's log for a day
+func UpsertDailyLog(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Date     string `json:"date" binding:"required"` // YYYY-MM-DD
+		Mood     string `json:"mood"`
+		Symptoms string `json:"symptoms"` // comma-separated
+		Flow     string `json:"flow"`
+		Notes    string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (use YYYY-MM-DD)"})
+		return
+	}
+	if !validFlows[input.Flow] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "flow must be none, spotting, light, medium or heavy"})
+		return
+	}
+
+	entry := models.DailyLog{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Date:     date,
+		Mood:     input.Mood,
+		Symptoms: input.Symptoms,
+		Flow:     input.Flow,
+		Notes:    input.Notes,
+	}
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mood", "symptoms", "flow", "notes", "updated_at"}),
+	}).Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save daily log"})
+		return
+	}
+
+	// Reload so an updated entry reports its original ID
+	if err := config.DB.Where("user_id = ? AND date = ?", userID, date).First(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load daily log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetDailyLogs lists daily logs, optionally between from and to (YYYY-MM-DD)
+func GetDailyLogs(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("date >= ?", t)
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("date <= ?", t)
+	}
+
+	var logs []models.DailyLog
+	if err := query.Order("date desc").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve daily logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+// DeleteDailyLog removes a daily log owned by the authenticated user
+func DeleteDailyLog(c *gin.Context) {
+	logID := utils.ParseUUIDParamOrAbort(c, "id")
+	if logID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", logID, userID).Delete(&models.DailyLog{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete daily log"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Daily log not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Daily log deleted successfully"})
+}
+
+// GetCorrelationInsights reports how moods and symptoms relate to cycle phases
+func GetCorrelationInsights(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	insights, err := services.BuildCorrelationInsights(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate correlations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, insights)
+}
@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// respondScheduleError maps schedule errors to responses
+func respondScheduleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTimeZone), errors.Is(err, services.ErrInvalidAvailability),
+		errors.Is(err, services.ErrInvalidTimeOff), errors.Is(err, services.ErrInvalidAppointmentType),
+		errors.Is(err, services.ErrSlotRangeTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetMyAvailability returns the calling doctor's weekly schedule
+func GetMyAvailability(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	windows, err := services.GetDoctorAvailability(config.DB, doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve availability"})
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+// SetMyAvailability replaces the calling doctor's weekly schedule
+func SetMyAvailability(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		TimeZone string                        `json:"time_zone" binding:"required"`
+		Windows  []services.AvailabilityWindow `json:"windows"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	windows, err := services.SetDoctorAvailability(doctorID, input.TimeZone, input.Windows)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to save availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+// GetMyTimeOff lists the calling doctor's current and upcoming time off
+func GetMyTimeOff(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	timeOff := []models.DoctorTimeOff{}
+	if err := config.DB.Where("doctor_id = ? AND ends_at > ?", doctorID, time.Now()).
+		Order("starts_at asc").Find(&timeOff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve time off"})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeOff)
+}
+
+// AddMyTimeOff blocks out time and lists the appointments that now clash with it
+func AddMyTimeOff(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		StartsAt time.Time `json:"starts_at" binding:"required"` // RFC3339
+		EndsAt   time.Time `json:"ends_at" binding:"required"`
+		Reason   string    `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	timeOff, affected, err := services.AddDoctorTimeOff(doctorID, input.StartsAt, input.EndsAt, input.Reason)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to save time off")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"time_off": timeOff, "conflicting_appointments": affected})
+}
+
+// DeleteMyTimeOff removes a block of time off
+func DeleteMyTimeOff(c *gin.Context) {
+	timeOffID := utils.ParseUUIDParamOrAbort(c, "id")
+	if timeOffID == uuid.Nil {
+		return
+	}
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	result := config.DB.Where("id = ? AND doctor_id = ?", timeOffID, doctorID).Delete(&models.DoctorTimeOff{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete time off"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Time off not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Time off deleted successfully"})
+}
+
+// appointmentTypeInput is the body for creating or updating an appointment type
+type appointmentTypeInput struct {
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required"`
+	Active          *bool  `json:"active"`
+}
+
+// GetMyAppointmentTypes lists all of the calling doctor's appointment types
+func GetMyAppointmentTypes(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	types := []models.AppointmentType{}
+	if err := config.DB.Where("doctor_id = ?", doctorID).Order("name asc").Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointment types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+// CreateAppointmentType adds a bookable kind of visit for the calling doctor
+func CreateAppointmentType(c *gin.Context) {
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	var input appointmentTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	apptType := models.AppointmentType{
+		ID:              uuid.New(),
+		DoctorID:        doctorID,
+		Name:            input.Name,
+		Description:     input.Description,
+		DurationMinutes: input.DurationMinutes,
+		Active:          input.Active == nil || *input.Active,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := services.ValidateAppointmentType(&apptType); err != nil {
+		respondScheduleError(c, err, "Invalid appointment type")
+		return
+	}
+	if err := config.DB.Create(&apptType).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment type"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, apptType)
+}
+
+// UpdateAppointmentType edits one of the calling doctor's appointment types.
+// Existing bookings keep their original length.
+func UpdateAppointmentType(c *gin.Context) {
+	typeID := utils.ParseUUIDParamOrAbort(c, "id")
+	if typeID == uuid.Nil {
+		return
+	}
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	var input appointmentTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	var apptType models.AppointmentType
+	if err := config.DB.Where("id = ? AND doctor_id = ?", typeID, doctorID).First(&apptType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment type not found or unauthorized"})
+		return
+	}
+	apptType.Name = input.Name
+	apptType.Description = input.Description
+	apptType.DurationMinutes = input.DurationMinutes
+	if input.Active != nil {
+		apptType.Active = *input.Active
+	}
+	apptType.UpdatedAt = time.Now()
+	if err := services.ValidateAppointmentType(&apptType); err != nil {
+		respondScheduleError(c, err, "Invalid appointment type")
+		return
+	}
+	if err := config.DB.Save(&apptType).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apptType)
+}
+
+// GetDoctorAppointmentTypes lists the bookable appointment types a doctor offers
+func GetDoctorAppointmentTypes(c *gin.Context) {
+	doctorID := utils.ParseUUIDParamOrAbort(c, "id")
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	types := []models.AppointmentType{}
+	if err := config.DB.Where("doctor_id = ? AND active = ?", doctorID, true).Order("name asc").Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointment types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+// GetDoctorSlots lists a doctor's free slots. Query: type_id (optional),
+// from and to as YYYY-MM-DD in tz (default UTC), which is also used to display the slots.
+func GetDoctorSlots(c *gin.Context) {
+	doctorID := utils.ParseUUIDParamOrAbort(c, "id")
+	if doctorID == uuid.Nil {
+		return
+	}
+
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidTimeZone.Error()})
+			return
+		}
+		loc = l
+	}
+
+	now := time.Now()
+	today := now.In(loc)
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	if v := c.Query("from"); v != "" {
+		d, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date (use YYYY-MM-DD)"})
+			return
+		}
+		from = d
+	}
+	to := from.AddDate(0, 0, 7)
+	if v := c.Query("to"); v != "" {
+		d, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date (use YYYY-MM-DD)"})
+			return
+		}
+		to = d.AddDate(0, 0, 1) // inclusive of the whole day
+	}
+
+	duration := services.DefaultAppointmentMinutes
+	if v := c.Query("type_id"); v != "" {
+		typeID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type_id"})
+			return
+		}
+		apptType, err := services.GetActiveAppointmentType(doctorID, typeID)
+		if err != nil {
+			respondScheduleError(c, err, "Failed to retrieve appointment type")
+			return
+		}
+		duration = apptType.DurationMinutes
+	}
+
+	slots, err := services.AvailableSlots(doctorID, time.Duration(duration)*time.Minute, from, to, loc, now)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to compute slots")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"time_zone": loc.String(), "duration_minutes": duration, "slots": slots})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// GetEPDSQuestionnaire returns the Edinburgh Postnatal Depression Scale questions
+func GetEPDSQuestionnaire(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"prompt": services.EPDSPrompt,
+		"items":  services.EPDSItems,
+	})
+}
+
+// SubmitEPDS scores a completed questionnaire and escalates high-risk answers
+func SubmitEPDS(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Answers []int64 `json:"answers" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	response, err := services.SubmitEPDS(userID, input.Answers, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEPDSAnswers) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if response == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save questionnaire"})
+			return
+		}
+		// The screening is saved; a failed escalation must not hide the crisis resources
+		log.Printf("❌ Failed to escalate EPDS response %s: %v", response.ID, err)
+	}
+
+	result := gin.H{"response": response}
+	if response.Escalated {
+		result["crisis_resources"] = services.EPDSCrisisResources
+		if response.SelfHarmScore > 0 {
+			result["message"] = "Thank you for telling us. Thoughts of harming yourself can happen after having a baby, and help is available right now. If you are in immediate danger, call 911."
+		} else {
+			result["message"] = "Your answers suggest you may be experiencing postpartum depression. You are not alone, and it is treatable. Please talk to your care provider soon."
+		}
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetEPDSHistory lists the user's EPDS scores over time, oldest first
+func GetEPDSHistory(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var responses []models.EPDSResponse
+	if err := config.DB.Where("user_id = ?", userID).Order("completed_at asc").Find(&responses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+		return
+	}
+
+	history := gin.H{"responses": responses}
+	if n := len(responses); n > 0 {
+		history["latest_score"] = responses[n-1].Score
+		if n > 1 {
+			history["change_since_previous"] = responses[n-1].Score - responses[n-2].Score
+		}
+	}
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// respondFeedingError maps feeding tracker errors to responses
+func respondFeedingError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFeeding):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrFeedingNotFound), errors.Is(err, services.ErrFeedingBabyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrFeedingInProgress), errors.Is(err, services.ErrFeedingEnded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// feedingDay reads an optional date=YYYY-MM-DD query, defaulting to today
+func feedingDay(c *gin.Context) (time.Time, bool) {
+	v := c.Query("date")
+	if v == "" {
+		return time.Now(), true
+	}
+	day, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (use YYYY-MM-DD)"})
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// LogFeeding records a finished feed or pump, or starts a timer when no end is given
+func LogFeeding(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		BabyID          *uuid.UUID `json:"baby_id"`
+		Kind            string     `json:"kind" binding:"required"`
+		Side            string     `json:"side"`
+		StartedAt       *time.Time `json:"started_at"`
+		EndedAt         *time.Time `json:"ended_at"`
+		DurationSeconds int        `json:"duration_seconds" binding:"min=0"`
+		BottleContent   string     `json:"bottle_content"`
+		VolumeMl        float64    `json:"volume_ml"`
+		Storage         string     `json:"storage"`
+		Notes           string     `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	session := models.FeedingSession{
+		UserID:          userID,
+		BabyID:          input.BabyID,
+		Kind:            input.Kind,
+		Side:            input.Side,
+		StartedAt:       now,
+		EndedAt:         input.EndedAt,
+		DurationSeconds: input.DurationSeconds,
+		BottleContent:   input.BottleContent,
+		VolumeMl:        input.VolumeMl,
+		Storage:         input.Storage,
+		Notes:           input.Notes,
+	}
+	if input.StartedAt != nil {
+		session.StartedAt = *input.StartedAt
+	}
+	if session.StartedAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "started_at cannot be in the future"})
+		return
+	}
+
+	if err := services.LogFeedingSession(&session, now); err != nil {
+		respondFeedingError(c, err, "Failed to save feeding session")
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// StopFeeding ends a running feed or pump timer
+func StopFeeding(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		VolumeMl float64 `json:"volume_ml"`
+		Storage  string  `json:"storage"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return
+		}
+	}
+
+	session, err := services.StopFeedingSession(userID, sessionID, input.VolumeMl, input.Storage, time.Now())
+	if err != nil {
+		respondFeedingError(c, err, "Failed to stop feeding session")
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// UpdateMilkStorage moves pumped milk, e.g. from the fridge to the freezer or marks it used
+func UpdateMilkStorage(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Storage string `json:"storage" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if !services.ValidMilkStorage(input.Storage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "storage must be room, fridge, freezer, used or discarded"})
+		return
+	}
+
+	var session models.FeedingSession
+	if err := config.DB.Where("id = ? AND user_id = ? AND kind = ?", sessionID, userID, models.FeedingPump).
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pumping session not found or unauthorized"})
+		return
+	}
+
+	now := time.Now()
+	services.MoveMilkStorage(&session, input.Storage, now)
+	session.UpdatedAt = now
+	if err := config.DB.Save(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update storage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GetFeedings lists the user's sessions started on a day (default today)
+func GetFeedings(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	day, ok := feedingDay(c)
+	if !ok {
+		return
+	}
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	var sessions []models.FeedingSession
+	if err := config.DB.Where("user_id = ? AND started_at >= ? AND started_at < ?", userID, start, start.AddDate(0, 0, 1)).
+		Order("started_at desc").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feeding sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// DeleteFeeding removes one of the user's sessions
+func DeleteFeeding(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.FeedingSession{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete feeding session"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feeding session not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feeding session deleted successfully"})
+}
+
+// GetFeedingSummary returns the totals for a day of feeding and pumping
+func GetFeedingSummary(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	day, ok := feedingDay(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.GetFeedingDaySummary(userID, day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build feeding summary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetNextFeedingSide suggests which breast to offer first
+func GetNextFeedingSide(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	suggestion, err := services.SuggestNextSide(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest a side"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// GetMilkStash lists pumped milk still in storage, soonest to expire first
+func GetMilkStash(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var sessions []models.FeedingSession
+	if err := config.DB.Where("user_id = ? AND kind = ? AND storage_expires_at > ?", userID, models.FeedingPump, time.Now()).
+		Order("storage_expires_at asc").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve milk stash"})
+		return
+	}
+
+	totals := map[string]float64{}
+	for _, s := range sessions {
+		totals[s.Storage] += s.VolumeMl
+	}
+	c.JSON(http.StatusOK, gin.H{"items": sessions, "total_ml_by_storage": totals})
+}
+
+// GetFeedingPreferences returns the user's feeding reminder settings
+func GetFeedingPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	pref, err := services.GetFeedingPreference(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
+
+// UpdateFeedingPreferences turns feeding-interval reminders on or off and sets the interval
+func UpdateFeedingPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		RemindersEnabled *bool `json:"reminders_enabled" binding:"required"`
+		IntervalMinutes  int   `json:"interval_minutes" binding:"omitempty,min=30,max=720"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	pref, err := services.GetFeedingPreference(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+	pref.RemindersEnabled = *input.RemindersEnabled
+	if input.IntervalMinutes > 0 {
+		pref.IntervalMinutes = input.IntervalMinutes
+	}
+	pref.UpdatedAt = time.Now()
+
+	if err := config.DB.Save(&pref).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// LogLochia records the day's post-birth bleeding and flags warning signs
+func LogLochia(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Date       string `json:"date"` // YYYY-MM-DD, defaults to today
+		Stage      string `json:"stage" binding:"required"`
+		Flow       string `json:"flow"`
+		LargeClots bool   `json:"large_clots"`
+		FoulOdor   bool   `json:"foul_odor"`
+		Notes      string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if input.Date != "" {
+		parsed, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (use YYYY-MM-DD)"})
+			return
+		}
+		date = parsed
+	}
+
+	entry, warnings, err := services.SaveLochiaLog(userID, models.LochiaLog{
+		Date:       date,
+		Stage:      input.Stage,
+		Flow:       input.Flow,
+		LargeClots: input.LargeClots,
+		FoulOdor:   input.FoulOdor,
+		Notes:      input.Notes,
+	}, now)
+	switch {
+	case errors.Is(err, services.ErrInvalidLochiaLog), errors.Is(err, services.ErrLochiaDateInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrNoEndedPregnancy):
+		c.JSON(http.StatusConflict, gin.H{"error": "Record the end of your pregnancy before logging lochia"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save lochia log"})
+		return
+	}
+
+	if warnings == nil {
+		warnings = []services.LochiaWarning{}
+	}
+	c.JSON(http.StatusCreated, gin.H{"log": entry, "warnings": warnings})
+}
+
+// GetLochiaLogs lists the lochia logs for the user's latest ended pregnancy
+func GetLochiaLogs(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	pregnancy, err := services.LatestEndedPregnancy(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pregnancy"})
+		return
+	}
+	logs := []models.LochiaLog{}
+	if pregnancy != nil {
+		if err := config.DB.Where("user_id = ? AND pregnancy_id = ?", userID, pregnancy.ID).
+			Order("date asc").Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lochia logs"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+// GetFertilityStatus reports lochia, period return, LAM and whether predictions
+// have resumed. Query: tz (optional IANA zone) overrides the profile time zone
+// when judging night feeds.
+func GetFertilityStatus(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var loc *time.Location
+	if tz := c.Query("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidTimeZone.Error()})
+			return
+		}
+		loc = l
+	} else {
+		l, err := services.UserLocation(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build fertility status"})
+			return
+		}
+		loc = l
+	}
+
+	status, err := services.BuildFertilityStatus(userID, time.Now(), loc)
+	if errors.Is(err, services.ErrNoEndedPregnancy) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No ended pregnancy found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build fertility status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
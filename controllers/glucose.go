@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// glucosePeriod reads from/to (RFC3339) or days (default 14) for a compliance report
+func glucosePeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	fromPtr, toPtr, ok := parseRange(c)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to := time.Now()
+	if toPtr != nil {
+		to = *toPtr
+	}
+	if fromPtr != nil {
+		return *fromPtr, to, true
+	}
+
+	days := 14
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 180"})
+			return time.Time{}, time.Time{}, false
+		}
+		days = n
+	}
+	return to.AddDate(0, 0, -(days - 1)), to, true
+}
+
+// patientWithConsentOrAbort reads :patient_id and checks the doctor is on the patient's care team
+func patientWithConsentOrAbort(c *gin.Context) uuid.UUID {
+	patientID := utils.ParseUUIDParamOrAbort(c, "patient_id")
+	if patientID == uuid.Nil {
+		return uuid.Nil
+	}
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+	if doctorID == uuid.Nil {
+		return uuid.Nil
+	}
+
+	ok, err := services.HasCareTeamConsent(patientID, doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check care team consent"})
+		return uuid.Nil
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Patient has not added you to their care team"})
+		return uuid.Nil
+	}
+	return patientID
+}
+
+func respondGlucoseSummary(c *gin.Context, userID uuid.UUID) {
+	from, to, ok := glucosePeriod(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.BuildGlucoseCompliance(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build glucose summary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// writeGlucoseReportCSV streams a clinic-ready glucose report
+func writeGlucoseReportCSV(c *gin.Context, userID uuid.UUID, filename string) {
+	from, to, ok := glucosePeriod(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.BuildGlucoseCompliance(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build glucose report"})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", "text/csv")
+	w := csv.NewWriter(c.Writer)
+	defer w.Flush()
+
+	t := report.Target
+	_ = w.Write([]string{"Glucose Report", report.From.Format("2006-01-02"), report.To.Format("2006-01-02")})
+	_ = w.Write([]string{"Targets (mg/dL)", "Low", "Fasting", "Pre-meal", "1h post-meal", "2h post-meal", "Checks/day"})
+	_ = w.Write([]string{"", fmtFloat(t.LowMin), fmtFloat(t.FastingMax), fmtFloat(t.PreMealMax),
+		fmtFloat(t.PostMeal1hMax), fmtFloat(t.PostMeal2hMax), fmtInt(t.ChecksPerDay)})
+	_ = w.Write([]string{"In range %", fmtFloat(report.InRangePercent), "Logging adherence %", fmtFloat(report.AdherencePercent)})
+	_ = w.Write([]string{})
+
+	_ = w.Write([]string{"Readings"})
+	_ = w.Write([]string{"Time", "Meal Context", "Glucose", "Target Max", "In Range", "Low"})
+	for _, d := range report.Daily {
+		for _, r := range d.Readings {
+			var max, inRange string
+			if r.InRange != nil {
+				max, inRange = fmtFloat(r.TargetMax), strconv.FormatBool(*r.InRange)
+			}
+			_ = w.Write([]string{r.MeasuredAt.UTC().Format(time.RFC3339), r.MealContext, fmtFloat(r.Value), max, inRange, strconv.FormatBool(r.Low)})
+		}
+	}
+	_ = w.Write([]string{})
+
+	_ = w.Write([]string{"Daily Summary"})
+	_ = w.Write([]string{"Date", "Readings", "Targeted", "In Range", "High", "Low", "Fasting Logged", "Complete"})
+	for _, d := range report.Daily {
+		_ = w.Write([]string{d.Date.Format("2006-01-02"), fmtInt(d.ReadingCount), fmtInt(d.TargetedCount), fmtInt(d.InRangeCount),
+			fmtInt(d.HighCount), fmtInt(d.LowCount), strconv.FormatBool(d.FastingLogged), strconv.FormatBool(d.Complete)})
+	}
+	_ = w.Write([]string{})
+
+	_ = w.Write([]string{"Weekly Summary"})
+	_ = w.Write([]string{"Week Start", "Days Logged", "Complete Days", "Readings", "In Range %", "Fasting Avg", "Post-meal Avg", "High", "Low"})
+	for _, wk := range report.Weekly {
+		var fasting, postMeal string
+		if wk.FastingAverage != nil {
+			fasting = fmtFloat(*wk.FastingAverage)
+		}
+		if wk.PostMealAvg != nil {
+			postMeal = fmtFloat(*wk.PostMealAvg)
+		}
+		_ = w.Write([]string{wk.WeekStart.Format("2006-01-02"), fmtInt(wk.DaysLogged), fmtInt(wk.CompleteDays), fmtInt(wk.ReadingCount),
+			fmtFloat(wk.InRangePercent), fasting, postMeal, fmtInt(wk.HighCount), fmtInt(wk.LowCount)})
+	}
+}
+
+// GetGlucoseTarget returns the authenticated user's glucose targets
+func GetGlucoseTarget(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	target, err := services.GetGlucoseTarget(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve glucose targets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
+// GetGlucoseSummary returns daily and weekly glucose compliance for the authenticated user
+func GetGlucoseSummary(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	respondGlucoseSummary(c, userID)
+}
+
+// ExportGlucoseReportCSV downloads the authenticated user's glucose report for their clinic
+func ExportGlucoseReportCSV(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	writeGlucoseReportCSV(c, userID, "glucose_report.csv")
+}
+
+// SetPatientGlucoseTarget sets a patient's glucose targets (doctor on the care team only)
+func SetPatientGlucoseTarget(c *gin.Context) {
+	patientID := patientWithConsentOrAbort(c)
+	if patientID == uuid.Nil {
+		return
+	}
+	doctorID := utils.GetUserIDFromContextOrAbort(c)
+
+	var input struct {
+		LowMin        float64 `json:"low_min" binding:"required,gt=0"`
+		FastingMax    float64 `json:"fasting_max" binding:"required,gtfield=LowMin"`
+		PreMealMax    float64 `json:"pre_meal_max" binding:"required,gtfield=LowMin"`
+		PostMeal1hMax float64 `json:"post_meal_1h_max" binding:"required,gtfield=LowMin"`
+		PostMeal2hMax float64 `json:"post_meal_2h_max" binding:"required,gtfield=LowMin"`
+		ChecksPerDay  int     `json:"checks_per_day" binding:"required,min=1,max=10"`
+		Notes         string  `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	target, err := services.SetGlucoseTarget(models.GlucoseTarget{
+		UserID:        patientID,
+		LowMin:        input.LowMin,
+		FastingMax:    input.FastingMax,
+		PreMealMax:    input.PreMealMax,
+		PostMeal1hMax: input.PostMeal1hMax,
+		PostMeal2hMax: input.PostMeal2hMax,
+		ChecksPerDay:  input.ChecksPerDay,
+		SetBy:         doctorID,
+		Notes:         input.Notes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save glucose targets"})
+		return
+	}
+	services.InvalidateAnalyticsCacheForUser(patientID)
+
+	c.JSON(http.StatusOK, target)
+}
+
+// GetPatientGlucoseSummary returns a patient's glucose compliance (doctor on the care team only)
+func GetPatientGlucoseSummary(c *gin.Context) {
+	patientID := patientWithConsentOrAbort(c)
+	if patientID == uuid.Nil {
+		return
+	}
+	respondGlucoseSummary(c, patientID)
+}
+
+// ExportPatientGlucoseReportCSV downloads a patient's glucose report (doctor on the care team only)
+func ExportPatientGlucoseReportCSV(c *gin.Context) {
+	patientID := patientWithConsentOrAbort(c)
+	if patientID == uuid.Nil {
+		return
+	}
+	writeGlucoseReportCSV(c, patientID, "patient_glucose_report.csv")
+}
@@ -8,21 +8,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/shem958/cycle-backend/config"
-	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
 )
 
 // CycleInsight contains prediction data for user's cycle
 type CycleInsight struct {
-	AverageLength      float64   `json:"average_length"`
-	NextPeriodStart    time.Time `json:"next_period_start"`
-	PredictedOvulation time.Time `json:"predicted_ovulation"`
-	FertileWindowStart time.Time `json:"fertile_window_start"`
-	FertileWindowEnd   time.Time `json:"fertile_window_end"`
-	IsIrregular        bool      `json:"is_irregular"`
-	CommonMood         string    `json:"common_mood,omitempty"`
-	CommonSymptoms     []string  `json:"common_symptoms,omitempty"`
-	TrackedCycleCount  int       `json:"tracked_cycle_count"`
+	AverageLength      float64    `json:"average_length"`
+	NextPeriodStart    time.Time  `json:"next_period_start"`
+	PredictedOvulation *time.Time `json:"predicted_ovulation"`  // nil on hormonal contraception
+	FertileWindowStart *time.Time `json:"fertile_window_start"` // nil on hormonal contraception
+	FertileWindowEnd   *time.Time `json:"fertile_window_end"`   // nil on hormonal contraception
+	IsIrregular        bool       `json:"is_irregular"`
+	CommonMood         string     `json:"common_mood,omitempty"`
+	CommonSymptoms     []string   `json:"common_symptoms,omitempty"`
+	TrackedCycleCount  int        `json:"tracked_cycle_count"`
+
+	// On hormonal contraception bleeds are withdrawal bleeds, not periods,
+	// and ovulation/fertility predictions do not apply.
+	OnHormonalContraception bool   `json:"on_hormonal_contraception"`
+	BleedType               string `json:"bleed_type"` // "period" or "withdrawal"
+
+	// Set when only cycles since the end of the last pregnancy are used
+	BaselineSince *time.Time `json:"baseline_since,omitempty"`
 }
 
 func GetCycleInsights(c *gin.Context) {
@@ -33,24 +40,63 @@ func GetCycleInsights(c *gin.Context) {
 		return
 	}
 
-	var cycles []models.Cycle
-	if err := config.DB.Where("user_id = ?", userID).Order("start_date asc").Find(&cycles).Error; err != nil {
+	// Predictions are paused during pregnancy and until periods return postpartum
+	paused, mode, err := services.CyclePredictionsPaused(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tracking mode"})
+		return
+	}
+	if paused {
+		c.JSON(http.StatusOK, gin.H{
+			"tracking_mode":      mode,
+			"predictions_paused": true,
+			"message":            "Cycle predictions are paused while you are in " + mode + " mode.",
+		})
+		return
+	}
+
+	// After a pregnancy only the periods since it ended form the baseline
+	cycles, since, err := services.BaselineCycles(userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cycle data"})
 		return
 	}
 
-	if len(cycles) < 2 {
+	hormonal, err := services.OnHormonalContraception(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contraception data"})
+		return
+	}
+	bleedType := "period"
+	if hormonal {
+		bleedType = "withdrawal"
+	}
+
+	if len(cycles) < services.MinBaselineCycles {
 		// Return empty insight with default values when not enough data
+		now := time.Now()
 		insight := CycleInsight{
-			AverageLength:      0,
-			NextPeriodStart:    time.Now(),
-			PredictedOvulation: time.Now(),
-			FertileWindowStart: time.Now(),
-			FertileWindowEnd:   time.Now(),
-			IsIrregular:        false,
-			CommonMood:         "",
-			CommonSymptoms:     []string{},
-			TrackedCycleCount:  len(cycles),
+			AverageLength:           0,
+			NextPeriodStart:         now,
+			IsIrregular:             false,
+			CommonMood:              "",
+			CommonSymptoms:          []string{},
+			TrackedCycleCount:       len(cycles),
+			OnHormonalContraception: hormonal,
+			BleedType:               bleedType,
+		}
+		if !hormonal {
+			insight.PredictedOvulation = &now
+			insight.FertileWindowStart = &now
+			insight.FertileWindowEnd = &now
+		}
+		if since != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"insight":             insight,
+				"postpartum_baseline": true,
+				"message":             "Predictions restart once you have logged at least 2 periods since your pregnancy ended. Cycles from before the pregnancy are not used.",
+			})
+			return
 		}
 		c.JSON(http.StatusOK, gin.H{
 			"insight": insight,
@@ -104,15 +150,20 @@ func GetCycleInsights(c *gin.Context) {
 	commonSymptoms := topSymptoms(symptomCounts)
 
 	insight := CycleInsight{
-		AverageLength:      avgLength,
-		NextPeriodStart:    nextStart,
-		PredictedOvulation: ovulation,
-		FertileWindowStart: fertileStart,
-		FertileWindowEnd:   fertileEnd,
-		IsIrregular:        isIrregular,
-		CommonMood:         commonMood,
-		CommonSymptoms:     commonSymptoms,
-		TrackedCycleCount:  len(cycles),
+		AverageLength:           avgLength,
+		NextPeriodStart:         nextStart,
+		IsIrregular:             isIrregular,
+		CommonMood:              commonMood,
+		CommonSymptoms:          commonSymptoms,
+		TrackedCycleCount:       len(cycles),
+		OnHormonalContraception: hormonal,
+		BleedType:               bleedType,
+		BaselineSince:           since,
+	}
+	if !hormonal {
+		insight.PredictedOvulation = &ovulation
+		insight.FertileWindowStart = &fertileStart
+		insight.FertileWindowEnd = &fertileEnd
 	}
 
 	c.JSON(http.StatusOK, insight)
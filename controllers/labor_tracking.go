@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+	"gorm.io/gorm"
+)
+
+// respondSessionError maps kick counter and contraction timer errors to responses
+func respondSessionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or unauthorized"})
+	case errors.Is(err, services.ErrTrackingNeedsPregnancy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidEventTime):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSessionInProgress), errors.Is(err, services.ErrSessionEnded),
+		errors.Is(err, services.ErrContractionOpen), errors.Is(err, services.ErrNoContractionOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// eventTime reads an optional "at" timestamp from the body, defaulting to now
+func eventTime(c *gin.Context) (time.Time, bool) {
+	var input struct {
+		At *time.Time `json:"at"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return time.Time{}, false
+		}
+	}
+	if input.At != nil {
+		return *input.At, true
+	}
+	return time.Now(), true
+}
+
+// sessionNotes reads optional notes from the body when ending a session
+func sessionNotes(c *gin.Context) (string, bool) {
+	var input struct {
+		Notes string `json:"notes"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return "", false
+		}
+	}
+	return input.Notes, true
+}
+
+// StartKickSession begins a fetal movement counting session
+func StartKickSession(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	session, err := services.StartKickSession(userID, time.Now())
+	if err != nil {
+		respondSessionError(c, err, "Failed to start kick counting session")
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// RecordKick logs a movement in an open kick counting session
+func RecordKick(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	at, ok := eventTime(c)
+	if !ok {
+		return
+	}
+
+	session, err := services.RecordKick(userID, sessionID, at)
+	if err != nil {
+		respondSessionError(c, err, "Failed to record kick")
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// EndKickSession stops a kick counting session and checks for reduced movement
+func EndKickSession(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	notes, ok := sessionNotes(c)
+	if !ok {
+		return
+	}
+
+	session, baseline, err := services.EndKickSession(userID, sessionID, notes, time.Now())
+	if err != nil {
+		respondSessionError(c, err, "Failed to end kick counting session")
+		return
+	}
+
+	response := gin.H{"session": session, "baseline": baseline}
+	if session.ReducedMovement {
+		response["alert"] = "Your baby's movements seem reduced. Please contact your midwife or maternity unit now."
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetKickSessions lists the authenticated user's kick counting sessions, newest first
+func GetKickSessions(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var sessions []models.KickSession
+	if err := config.DB.Where("user_id = ?", userID).Order("started_at desc").Limit(100).Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetKickSession returns a kick counting session with each recorded movement
+func GetKickSession(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var session models.KickSession
+	if err := config.DB.Preload("Kicks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("occurred_at asc")
+	}).Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GetKickBaseline returns the user's usual time to reach ten movements in the current pregnancy
+func GetKickBaseline(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	pregnancy, err := services.GetActivePregnancy(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoActivePregnancy) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No active pregnancy"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pregnancy"})
+		return
+	}
+
+	baseline, err := services.GetKickBaseline(userID, pregnancy.ID, uuid.Nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute baseline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, baseline)
+}
+
+// StartContractionSession begins a contraction timing session
+func StartContractionSession(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	session, err := services.StartContractionSession(userID, time.Now())
+	if err != nil {
+		respondSessionError(c, err, "Failed to start contraction timer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// StartContraction marks the start of a contraction
+func StartContraction(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	at, ok := eventTime(c)
+	if !ok {
+		return
+	}
+
+	contraction, err := services.StartContraction(userID, sessionID, at)
+	if err != nil {
+		respondSessionError(c, err, "Failed to start contraction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, contraction)
+}
+
+// StopContraction marks the end of the current contraction and returns updated statistics
+func StopContraction(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	at, ok := eventTime(c)
+	if !ok {
+		return
+	}
+
+	session, stats, err := services.StopContraction(userID, sessionID, at)
+	if err != nil {
+		respondSessionError(c, err, "Failed to stop contraction")
+		return
+	}
+
+	response := gin.H{"session": session, "stats": stats}
+	if stats.Rule511 {
+		response["alert"] = "Your contractions meet the 5-1-1 pattern. Contact your midwife or maternity unit."
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// EndContractionSession stops the contraction timer
+func EndContractionSession(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	notes, ok := sessionNotes(c)
+	if !ok {
+		return
+	}
+
+	session, err := services.EndContractionSession(userID, sessionID, notes, time.Now())
+	if err != nil {
+		respondSessionError(c, err, "Failed to end contraction timer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session, "stats": services.ComputeContractionStats(session.Contractions)})
+}
+
+// GetContractionSessions lists the authenticated user's contraction timing sessions, newest first
+func GetContractionSessions(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var sessions []models.ContractionSession
+	if err := config.DB.Where("user_id = ?", userID).Order("started_at desc").Limit(100).Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetContractionSession returns a session with its contractions and statistics
+func GetContractionSession(c *gin.Context) {
+	sessionID := utils.ParseUUIDParamOrAbort(c, "id")
+	if sessionID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	session, err := services.LoadContractionSession(userID, sessionID)
+	if err != nil {
+		respondSessionError(c, err, "Failed to retrieve session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session, "stats": services.ComputeContractionStats(session.Contractions)})
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+	"gorm.io/gorm/clause"
+)
+
+var validMedicationKinds = map[string]bool{
+	models.MedicationKindPill:      true,
+	models.MedicationKindPatch:     true,
+	models.MedicationKindRing:      true,
+	models.MedicationKindIUD:       true,
+	models.MedicationKindImplant:   true,
+	models.MedicationKindInjection: true,
+	models.MedicationKindOther:     true,
+}
+
+var validDoseFrequencies = map[string]bool{
+	models.DoseFrequencyDaily:      true,
+	models.DoseFrequencyDaily21Of7: true,
+	models.DoseFrequencyWeekly:     true,
+	models.DoseFrequencyMonthly:    true,
+	models.DoseFrequencyAsNeeded:   true,
+	models.DoseFrequencyContinuous: true,
+}
+
+type medicationInput struct {
+	Name             string     `json:"name" binding:"required"`
+	Kind             string     `json:"kind" binding:"required"`
+	IsContraceptive  bool       `json:"is_contraceptive"`
+	IsHormonal       bool       `json:"is_hormonal"`
+	Dosage           string     `json:"dosage"`
+	Frequency        string     `json:"frequency" binding:"required"`
+	DoseTime         string     `json:"dose_time"`
+	StartDate        time.Time  `json:"start_date" binding:"required"`
+	EndDate          *time.Time `json:"end_date"`
+	Active           *bool      `json:"active"`
+	RemindersEnabled *bool      `json:"reminders_enabled"`
+	Notes            string     `json:"notes"`
+}
+
+// validateMedicationInput writes a 400 response and returns false when the input is invalid
+func validateMedicationInput(c *gin.Context, input medicationInput) bool {
+	if !validMedicationKinds[input.Kind] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind. Use pill, patch, ring, iud, implant, injection or other"})
+		return false
+	}
+	if !validDoseFrequencies[input.Frequency] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frequency. Use daily, daily_21_7, weekly, monthly, as_needed or continuous"})
+		return false
+	}
+	if _, _, err := services.ParseDoseTime(input.DoseTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if input.EndDate != nil && input.EndDate.Before(input.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date"})
+		return false
+	}
+	return true
+}
+
+func applyMedicationInput(med *models.Medication, input medicationInput) {
+	med.Name = input.Name
+	med.Kind = input.Kind
+	med.IsContraceptive = input.IsContraceptive
+	med.IsHormonal = input.IsHormonal
+	med.Dosage = input.Dosage
+	med.Frequency = input.Frequency
+	med.DoseTime = input.DoseTime
+	med.StartDate = input.StartDate
+	med.EndDate = input.EndDate
+	med.Notes = input.Notes
+	if input.Active != nil {
+		med.Active = *input.Active
+	}
+	if input.RemindersEnabled != nil {
+		med.RemindersEnabled = *input.RemindersEnabled
+	}
+}
+
+// findUserMedication loads a medication owned by the user or writes a 404
+func findUserMedication(c *gin.Context, userID uuid.UUID) (*models.Medication, bool) {
+	medID := utils.ParseUUIDParamOrAbort(c, "id")
+	if medID == uuid.Nil {
+		return nil, false
+	}
+	var med models.Medication
+	if err := config.DB.Where("id = ? AND user_id = ?", medID, userID).First(&med).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Medication not found or unauthorized"})
+		return nil, false
+	}
+	return &med, true
+}
+
+// CreateMedication adds a contraceptive method or medication for the authenticated user
+func CreateMedication(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input medicationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if !validateMedicationInput(c, input) {
+		return
+	}
+
+	med := models.Medication{
+		ID:               uuid.New(),
+		UserID:           userID,
+		Active:           true,
+		RemindersEnabled: true,
+	}
+	applyMedicationInput(&med, input)
+
+	if err := config.DB.Create(&med).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create medication"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, med)
+}
+
+// GetMedications lists the authenticated user's medications
+func GetMedications(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	var meds []models.Medication
+	if err := query.Order("start_date desc").Find(&meds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve medications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, meds)
+}
+
+// UpdateMedication updates a medication owned by the authenticated user
+func UpdateMedication(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	med, ok := findUserMedication(c, userID)
+	if !ok {
+		return
+	}
+
+	var input medicationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid update data", "details": err.Error()})
+		return
+	}
+	if !validateMedicationInput(c, input) {
+		return
+	}
+	applyMedicationInput(med, input)
+
+	if err := config.DB.Save(med).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update medication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, med)
+}
+
+// DeleteMedication removes a medication and its dose history
+func DeleteMedication(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	med, ok := findUserMedication(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Where("medication_id = ?", med.ID).Delete(&models.DoseLog{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete dose history"})
+		return
+	}
+	if err := config.DB.Delete(med).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete medication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Medication deleted successfully"})
+}
+
+// LogDose records a dose as taken, skipped or missed. Logging a dose that was
+// automatically marked missed overwrites it.
+func LogDose(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	med, ok := findUserMedication(c, userID)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Status       string     `json:"status" binding:"required"` // "taken", "skipped", "missed"
+		ScheduledFor *time.Time `json:"scheduled_for"`             // defaults to the nearest scheduled slot
+		TakenAt      *time.Time `json:"taken_at"`
+		Notes        string     `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if input.Status != models.DoseStatusTaken && input.Status != models.DoseStatusSkipped && input.Status != models.DoseStatusMissed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be taken, skipped or missed"})
+		return
+	}
+
+	now := time.Now()
+	var scheduledFor time.Time
+	switch {
+	case input.ScheduledFor != nil:
+		scheduledFor = input.ScheduledFor.UTC()
+	case med.Frequency == models.DoseFrequencyAsNeeded || med.Frequency == models.DoseFrequencyContinuous:
+		scheduledFor = now
+		if input.TakenAt != nil {
+			scheduledFor = input.TakenAt.UTC()
+		}
+	default:
+		at := now
+		if input.TakenAt != nil {
+			at = *input.TakenAt
+		}
+		slot, ok := services.NearestScheduledDose(*med, at)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scheduled_for is required: no scheduled dose near this time"})
+			return
+		}
+		scheduledFor = slot
+	}
+	takenAt := input.TakenAt
+	if input.Status == models.DoseStatusTaken && takenAt == nil {
+		takenAt = &now
+	}
+	if input.Status != models.DoseStatusTaken {
+		takenAt = nil
+	}
+
+	dose := models.DoseLog{
+		ID:           uuid.New(),
+		MedicationID: med.ID,
+		UserID:       userID,
+		ScheduledFor: scheduledFor,
+		TakenAt:      takenAt,
+		Status:       input.Status,
+		Notes:        input.Notes,
+		CreatedAt:    now,
+	}
+
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "medication_id"}, {Name: "scheduled_for"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "taken_at", "notes"}),
+	}).Create(&dose).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log dose"})
+		return
+	}
+	// On conflict the existing row keeps its ID and created_at, so return what was stored
+	if err := config.DB.Where("medication_id = ? AND scheduled_for = ?", med.ID, scheduledFor).First(&dose).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logged dose"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dose)
+}
+
+// GetDoseLogs lists dose logs for a medication, newest first
+func GetDoseLogs(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	med, ok := findUserMedication(c, userID)
+	if !ok {
+		return
+	}
+
+	var logs []models.DoseLog
+	if err := config.DB.Where("medication_id = ?", med.ID).Order("scheduled_for desc").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dose logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+// GetMedicationAdherence returns adherence statistics over the last N days (default 30)
+func GetMedicationAdherence(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	med, ok := findUserMedication(c, userID)
+	if !ok {
+		return
+	}
+
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+
+	var logs []models.DoseLog
+	if err := config.DB.Where("medication_id = ? AND scheduled_for BETWEEN ? AND ?", med.ID, from, to).
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dose logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.CalculateAdherence(*med, logs, from, to))
+}
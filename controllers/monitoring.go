@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,34 +9,31 @@ import (
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
 	"github.com/shem958/cycle-backend/utils"
 )
 
-func CreateMonitoringRecord(c *gin.Context) {
-	var input struct {
-		Type      string `json:"type" binding:"required"` // "pregnancy" or "postpartum"
-		Data      string `json:"data"`
-		Notes     string `json:"notes"`
-		StartDate string `json:"start_date" binding:"required"`
-		EndDate   string `json:"end_date"`
-	}
-
-	userIDStr := c.MustGet("user_id").(string)
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
-		return
-	}
+// monitoringRecordInput is the request body for creating or replacing a record
+type monitoringRecordInput struct {
+	Type      string                 `json:"type" binding:"required"` // template type, e.g. "pregnancy" or "postpartum"
+	Data      map[string]interface{} `json:"data"`
+	Notes     string                 `json:"notes"`
+	StartDate string                 `json:"start_date" binding:"required"`
+	EndDate   string                 `json:"end_date"`
+}
 
+// bindMonitoringInput parses the request body into a service input
+func bindMonitoringInput(c *gin.Context) (services.MonitoringInput, bool) {
+	var input monitoringRecordInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return services.MonitoringInput{}, false
 	}
 
 	start, err := time.Parse(time.RFC3339, input.StartDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date"})
-		return
+		return services.MonitoringInput{}, false
 	}
 
 	var end *time.Time
@@ -43,68 +41,226 @@ func CreateMonitoringRecord(c *gin.Context) {
 		e, err := time.Parse(time.RFC3339, input.EndDate)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date"})
-			return
+			return services.MonitoringInput{}, false
 		}
 		end = &e
 	}
 
-	// 🔐 Encrypt data and notes
-	encryptedData, err := utils.Encrypt(input.Data)
+	if input.Data == nil {
+		input.Data = map[string]interface{}{}
+	}
+	return services.MonitoringInput{
+		Type:      input.Type,
+		StartDate: start,
+		EndDate:   end,
+		Data:      input.Data,
+		Notes:     input.Notes,
+	}, true
+}
+
+// respondMonitoringError maps monitoring errors to responses
+func respondMonitoringError(c *gin.Context, err error, fallback string) {
+	var validation *services.MonitoringValidationError
+	switch {
+	case errors.As(err, &validation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": validation.Fields})
+	case errors.Is(err, services.ErrInvalidMonitoringData), errors.Is(err, services.ErrInvalidMonitoringFields),
+		errors.Is(err, services.ErrInvalidMonitoringFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnknownMonitoringType), errors.Is(err, services.ErrMonitoringRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBuiltInMonitoringType):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// CreateMonitoringRecord validates data against the type's template and stores it encrypted
+func CreateMonitoringRecord(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	input, ok := bindMonitoringInput(c)
+	if !ok {
+		return
+	}
+
+	record, err := services.CreateMonitoringRecord(userID, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt data"})
+		respondMonitoringError(c, err, "Failed to create record")
 		return
 	}
 
-	encryptedNotes, err := utils.Encrypt(input.Notes)
+	c.JSON(http.StatusCreated, record)
+}
+
+// GetUserMonitoringRecords lists the user's records, optionally narrowed by
+// type, from/to (RFC3339) and repeated filter=key:op:value
+func GetUserMonitoringRecords(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	from, to, ok := parseRange(c)
+	if !ok {
+		return
+	}
+
+	query := services.MonitoringQuery{Type: c.Query("type"), From: from, To: to}
+	for _, raw := range c.QueryArray("filter") {
+		filter, err := services.ParseMonitoringFilter(raw)
+		if err != nil {
+			respondMonitoringError(c, err, "Invalid filter")
+			return
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	records, err := services.QueryMonitoringRecords(userID, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt notes"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
 		return
 	}
 
-	record := models.MonitoringRecord{
-		ID:        uuid.New(),
-		UserID:    userID,
-		Type:      input.Type,
-		Data:      encryptedData,
-		Notes:     encryptedNotes,
-		StartDate: start,
-		EndDate:   end,
+	c.JSON(http.StatusOK, records)
+}
+
+// GetMonitoringRecord returns one of the user's records decrypted
+func GetMonitoringRecord(c *gin.Context) {
+	recordID := utils.ParseUUIDParamOrAbort(c, "id")
+	if recordID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
 	}
 
-	if err := config.DB.Create(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create record"})
+	var record models.MonitoringRecord
+	if err := config.DB.Where("id = ? AND user_id = ?", recordID, userID).First(&record).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found or unauthorized"})
+		return
+	}
+	view, err := services.DecryptMonitoringRecord(record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decrypt record"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Record created"})
+	c.JSON(http.StatusOK, view)
 }
 
-func GetUserMonitoringRecords(c *gin.Context) {
-	userIDStr := c.MustGet("user_id").(string)
-	userID, err := uuid.Parse(userIDStr)
+// UpdateMonitoringRecord replaces a record after validating it against the current template
+func UpdateMonitoringRecord(c *gin.Context) {
+	recordID := utils.ParseUUIDParamOrAbort(c, "id")
+	if recordID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	input, ok := bindMonitoringInput(c)
+	if !ok {
+		return
+	}
+
+	record, err := services.UpdateMonitoringRecord(userID, recordID, input)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		respondMonitoringError(c, err, "Failed to update record")
 		return
 	}
 
-	var records []models.MonitoringRecord
-	if err := config.DB.Where("user_id = ?", userID).Order("start_date desc").Find(&records).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+	c.JSON(http.StatusOK, record)
+}
+
+// DeleteMonitoringRecord removes one of the user's records
+func DeleteMonitoringRecord(c *gin.Context) {
+	recordID := utils.ParseUUIDParamOrAbort(c, "id")
+	if recordID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
 		return
 	}
 
-	// 🔓 Decrypt each record
-	for i, record := range records {
-		decryptedData, err := utils.Decrypt(record.Data)
-		if err == nil {
-			records[i].Data = decryptedData
-		}
+	result := config.DB.Where("id = ? AND user_id = ?", recordID, userID).Delete(&models.MonitoringRecord{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete record"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found or unauthorized"})
+		return
+	}
+	services.InvalidateAnalyticsCacheForUser(userID)
 
-		decryptedNotes, err := utils.Decrypt(record.Notes)
-		if err == nil {
-			records[i].Notes = decryptedNotes
-		}
+	c.JSON(http.StatusOK, gin.H{"message": "Record deleted successfully"})
+}
+
+// GetMonitoringTemplates lists the field templates for every monitoring type
+func GetMonitoringTemplates(c *gin.Context) {
+	templates, err := services.ListMonitoringTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
 	}
 
-	c.JSON(http.StatusOK, records)
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetMonitoringTemplate returns the field template for one monitoring type
+func GetMonitoringTemplate(c *gin.Context) {
+	template, err := services.GetMonitoringTemplate(c.Param("type"))
+	if err != nil {
+		respondMonitoringError(c, err, "Failed to fetch template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// SaveMonitoringTemplate lets an admin create or replace the template for a type
+func SaveMonitoringTemplate(c *gin.Context) {
+	adminID := utils.GetUserIDFromContextOrAbort(c)
+	if adminID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Name        string                  `json:"name" binding:"required"`
+		Description string                  `json:"description"`
+		Fields      models.MonitoringFields `json:"fields" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	template, err := services.SaveMonitoringTemplate(c.Param("type"), input.Name, input.Description, input.Fields, adminID)
+	if err != nil {
+		respondMonitoringError(c, err, "Failed to save template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteMonitoringTemplate removes a custom template or resets a built-in one
+func DeleteMonitoringTemplate(c *gin.Context) {
+	monitoringType := c.Param("type")
+	err := services.DeleteMonitoringTemplate(monitoringType)
+	if err != nil {
+		respondMonitoringError(c, err, "Failed to delete template")
+		return
+	}
+
+	if _, builtIn := services.DefaultMonitoringTemplates[monitoringType]; builtIn {
+		c.JSON(http.StatusOK, gin.H{"message": "Template reset to the built-in default"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
 }
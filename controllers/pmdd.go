@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+	"gorm.io/gorm/clause"
+)
+
+// GetDRSPQuestionnaire returns the daily questionnaire items and rating scale
+func GetDRSPQuestionnaire(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"items": services.DRSPItems,
+		"scale": gin.H{
+			"min": services.DRSPMinRating,
+			"max": services.DRSPMaxRating,
+			"labels": []string{
+				"Not at all", "Minimal", "Mild", "Moderate", "Severe", "Extreme",
+			},
+		},
+	})
+}
+
+// SaveDRSPEntry creates or replaces the authenticated user's ratings for a day
+func SaveDRSPEntry(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Date    string  `json:"date" binding:"required"` // YYYY-MM-DD
+		Ratings []int64 `json:"ratings" binding:"required"`
+		Notes   string  `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (use YYYY-MM-DD)"})
+		return
+	}
+	if date.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ratings cannot be recorded for future dates"})
+		return
+	}
+	if err := services.ValidateDRSPRatings(input.Ratings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cycleDay, err := services.CycleDayFor(userID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to determine cycle day"})
+		return
+	}
+
+	entry := models.DRSPEntry{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Date:     date,
+		CycleDay: cycleDay,
+		Ratings:  pq.Int64Array(input.Ratings),
+		Notes:    input.Notes,
+	}
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cycle_day", "ratings", "notes", "updated_at"}),
+	}).Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save ratings"})
+		return
+	}
+
+	// Reload so an updated entry reports its original ID
+	if err := config.DB.Where("user_id = ? AND date = ?", userID, date).First(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load ratings"})
+		return
+	}
+
+	// Keep recommendations in step with the latest screening result
+	if screening, err := services.BuildDRSPScreening(userID); err == nil {
+		if err := services.SyncScreeningRecommendation(userID, screening); err != nil {
+			log.Printf("❌ Failed to sync screening recommendation for %s: %v", userID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetDRSPEntries lists the authenticated user's daily ratings, optionally between from and to (YYYY-MM-DD)
+func GetDRSPEntries(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("date >= ?", t)
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("date <= ?", t)
+	}
+
+	var entries []models.DRSPEntry
+	if err := query.Order("date desc").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ratings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetPMDDScreening scores the user's ratings across completed cycles
+func GetPMDDScreening(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	screening, err := services.BuildDRSPScreening(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to score ratings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, screening)
+}
+
+var pmddSummaryTemplate = template.Must(template.New("pmdd-summary").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2 Jan 2006") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Premenstrual symptom summary</title>
+<style>
+body { font-family: Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 4px 8px; font-size: 0.9em; text-align: left; }
+.elevated { font-weight: bold; background: #f3e0e0; }
+.note { font-size: 0.85em; color: #555; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Premenstrual symptom summary</h1>
+<p>Prepared for {{.Name}} on {{date .Generated}} from prospective daily ratings
+(Daily Record of Severity of Problems, 1 = not at all to 6 = extreme).</p>
+<h2>Result: {{.Result}}</h2>
+<p>{{.Screening.Message}}</p>
+<p>Cycles scored: {{.Screening.ScoredCycles}} (at least {{.Screening.Required}} required)</p>
+{{range .Screening.Cycles}}
+<h3>Cycle starting {{date .CycleStart}}</h3>
+<p>Premenstrual days rated: {{.PremenstrualDays}} &middot; Postmenstrual days rated: {{.PostmenstrualDays}}<br>
+Mean daily total (items 1–21): {{.PremenstrualTotal}} premenstrual vs {{.PostmenstrualTotal}} postmenstrual ({{.PercentIncrease}}% change)<br>
+Functional impairment: {{if .Impairment}}yes{{else}}no{{end}} &middot; Classification: {{.Classification}}</p>
+<table>
+<tr><th>#</th><th>Domain</th><th>Premenstrual mean</th><th>Postmenstrual mean</th><th>Change %</th></tr>
+{{range .Items}}<tr{{if .Elevated}} class="elevated"{{end}}><td>{{.Number}}</td><td>{{.Domain}}</td><td>{{.PremenstrualMean}}</td><td>{{.PostmenstrualMean}}</td><td>{{.PercentIncrease}}</td></tr>
+{{end}}</table>
+{{end}}
+<p class="note">Premenstrual window: the 7 days before the next period. Postmenstrual window: cycle days 4–10.
+An item is highlighted when its premenstrual mean is at least 3 and at least 30% above its postmenstrual mean.</p>
+<p class="note">{{.Screening.Disclaimer}}</p>
+</body>
+</html>
+`))
+
+// GetPMDDSummary renders the screening as a printable HTML page for a clinician
+func GetPMDDSummary(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	screening, err := services.BuildDRSPScreening(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to score ratings"})
+		return
+	}
+
+	results := map[string]string{
+		services.ScreeningInsufficientData: "Not enough data",
+		services.ScreeningNotIndicated:     "No consistent premenstrual pattern",
+		services.ScreeningPMSLikely:        "Pattern consistent with PMS",
+		services.ScreeningPMDDLikely:       "Pattern consistent with PMDD",
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := pmddSummaryTemplate.Execute(c.Writer, gin.H{
+		"Name":      user.Username,
+		"Generated": time.Now(),
+		"Result":    results[screening.Result],
+		"Screening": screening,
+	}); err != nil {
+		log.Printf("❌ Failed to render PMDD summary: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
 )
 
 // CreatePostpartumLog handles adding a new postpartum entry
@@ -58,6 +60,7 @@ func CreatePostpartumLog(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save postpartum log"})
 		return
 	}
+	services.InvalidateAnalyticsCacheForUser(userID)
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Postpartum log created"})
 }
@@ -88,6 +91,15 @@ func GetPostpartumDashboard(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
+	// The dashboard carries recovery trends, so it is only shown to its owner
+	callerID := utils.GetUserIDFromContextOrAbort(c)
+	if callerID == uuid.Nil {
+		return
+	}
+	if callerID != parsedID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own dashboard"})
+		return
+	}
 
 	// Get logs
 	var logs []models.PostpartumLog
@@ -103,16 +115,28 @@ func GetPostpartumDashboard(c *gin.Context) {
 		return
 	}
 
+	// After a pregnancy loss, baby-related content is left out of the dashboard
+	sensitive, err := services.InPregnancyLossRecovery(parsedID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pregnancy history"})
+		return
+	}
+
 	// Construct dashboard response
+	var logsView interface{} = logs
+	if sensitive {
+		logsView = logsWithoutBreastfeeding(logs)
+	}
 	dashboard := gin.H{
-		"logs":     logs,
-		"checkups": checkups,
+		"logs":           logsView,
+		"checkups":       checkups,
+		"sensitive_mode": sensitive,
 	}
 
 	// Add latest metrics if available
 	if len(logs) > 0 {
 		latestLog := logs[0]
-		dashboard["latestMetrics"] = gin.H{
+		metrics := gin.H{
 			"mood":                latestLog.Mood,
 			"pain_level":          latestLog.PainLevel,
 			"sleep_hours":         latestLog.SleepHours,
@@ -122,7 +146,40 @@ func GetPostpartumDashboard(c *gin.Context) {
 			"date":                latestLog.Date,
 			"notes":               latestLog.Notes,
 		}
+		if sensitive {
+			delete(metrics, "breastfeeding")
+		}
+		dashboard["latestMetrics"] = metrics
+
+		trends, err := services.GetPostpartumTrends(parsedID, nil, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute recovery trends"})
+			return
+		}
+		dashboard["trends"] = trends
 	}
 
 	c.JSON(http.StatusOK, dashboard)
 }
+
+// logsWithoutBreastfeeding lists postpartum logs with the breastfeeding answer
+// left out, for the dashboard of someone recovering from a pregnancy loss
+func logsWithoutBreastfeeding(logs []models.PostpartumLog) []gin.H {
+	views := make([]gin.H, 0, len(logs))
+	for _, l := range logs {
+		views = append(views, gin.H{
+			"ID":                l.ID,
+			"UserID":            l.UserID,
+			"Date":              l.Date,
+			"Mood":              l.Mood,
+			"PainLevel":         l.PainLevel,
+			"Notes":             l.Notes,
+			"SleepHours":        l.SleepHours,
+			"AppetiteLevel":     l.AppetiteLevel,
+			"FollowUpScheduled": l.FollowUpScheduled,
+			"CreatedAt":         l.CreatedAt,
+			"UpdatedAt":         l.UpdatedAt,
+		})
+	}
+	return views
+}
@@ -16,10 +16,20 @@ func CreatePostpartumCheckup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	callerID := patientOrCareTeamOrAbort(c, checkup.UserID)
+	if callerID == uuid.Nil {
+		return
+	}
+	// A doctor records the checkup as their own
+	if callerID != checkup.UserID {
+		checkup.DoctorID = callerID
+	}
+	checkup.AppointmentID, checkup.FollowUpAppointmentID = nil, nil
 	if err := services.CreatePostpartumCheckup(&checkup); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	checkup.FollowUpAppointmentID = syncFollowUp(models.CheckupKindPostpartum, checkup.ID, callerID, checkup.DoctorID, nil)
 	c.JSON(http.StatusCreated, checkup)
 }
 
@@ -30,6 +40,9 @@ func GetPostpartumCheckupsByUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
 		return
 	}
+	if patientOrCareTeamOrAbort(c, userID) == uuid.Nil {
+		return
+	}
 	checkups, err := services.GetPostpartumCheckupsByUser(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -50,6 +63,9 @@ func GetPostpartumCheckupByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "checkup not found"})
 		return
 	}
+	if checkupPartyOrAbort(c, checkup.UserID, checkup.DoctorID) == uuid.Nil {
+		return
+	}
 	c.JSON(http.StatusOK, checkup)
 }
 
@@ -61,17 +77,31 @@ func UpdatePostpartumCheckup(c *gin.Context) {
 		return
 	}
 
+	existing, err := services.GetPostpartumCheckupByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checkup not found"})
+		return
+	}
+	callerID := checkupPartyOrAbort(c, existing.UserID, existing.DoctorID)
+	if callerID == uuid.Nil {
+		return
+	}
+
 	var updated models.PostpartumCheckup
 	if err := c.ShouldBindJSON(&updated); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	updated.ID = id
+	// The patient, doctor and appointment links are kept by the server
+	updated.UserID, updated.DoctorID = existing.UserID, existing.DoctorID
+	updated.AppointmentID, updated.FollowUpAppointmentID = existing.AppointmentID, existing.FollowUpAppointmentID
 
 	if err := services.UpdatePostpartumCheckup(&updated); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	updated.FollowUpAppointmentID = syncFollowUp(models.CheckupKindPostpartum, id, callerID, updated.DoctorID, updated.FollowUpAppointmentID)
 	c.JSON(http.StatusOK, updated)
 }
 
@@ -82,6 +112,14 @@ func DeletePostpartumCheckup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkup ID"})
 		return
 	}
+	existing, err := services.GetPostpartumCheckupByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checkup not found"})
+		return
+	}
+	if checkupPartyOrAbort(c, existing.UserID, existing.DoctorID) == uuid.Nil {
+		return
+	}
 	if err := services.DeletePostpartumCheckup(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestLogsWithoutBreastfeeding(t *testing.T) {
+	logs := []models.PostpartumLog{
+		{ID: uuid.New(), Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Mood: "tired", Breastfeeding: true, SleepHours: 5},
+	}
+
+	body, err := json.Marshal(logsWithoutBreastfeeding(logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var views []map[string]interface{}
+	if err := json.Unmarshal(body, &views); err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 1 {
+		t.Fatalf("got %d logs, want 1", len(views))
+	}
+	if _, ok := views[0]["Breastfeeding"]; ok {
+		t.Error("breastfeeding answer was not removed")
+	}
+	if views[0]["Mood"] != "tired" || views[0]["SleepHours"] != 5.0 {
+		t.Errorf("other fields changed: %v", views[0])
+	}
+}
+
+func TestPostpartumDashboardSelfOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Params = gin.Params{{Key: "id", Value: uuid.New().String()}}
+	c.Set("user_id", uuid.New().String())
+
+	GetPostpartumDashboard(c)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("another user's dashboard: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,14 +9,22 @@ import (
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
 )
 
-// CreatePregnancy starts a new pregnancy record
+// CreatePregnancy starts a new pregnancy record. The due date is calculated from
+// start_date (LMP) or from an explicit dating method.
 func CreatePregnancy(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
 	var payload struct {
-		UserID    string    `json:"user_id" binding:"required"`
-		StartDate time.Time `json:"start_date" binding:"required"`
-		Notes     string    `json:"notes"`
+		StartDate time.Time             `json:"start_date"` // last menstrual period
+		Dating    *services.DatingInput `json:"dating"`     // conception, IVF or ultrasound dating
+		Notes     string                `json:"notes"`
 	}
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -23,23 +32,25 @@ func CreatePregnancy(c *gin.Context) {
 		return
 	}
 
-	userUUID, err := uuid.Parse(payload.UserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
+	dating := services.DatingInput{Method: models.DatingMethodLMP, Date: payload.StartDate}
+	if payload.Dating != nil {
+		dating = *payload.Dating
 	}
-
-	pregnancy := models.Pregnancy{
-		ID:          uuid.New(),
-		UserID:      userUUID,
-		StartDate:   payload.StartDate,
-		CurrentWeek: int(time.Since(payload.StartDate).Hours() / (24 * 7)),
-		Notes:       payload.Notes,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	if dating.Date.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date or dating is required"})
+		return
 	}
 
-	if err := config.DB.Create(&pregnancy).Error; err != nil {
+	pregnancy, err := services.CreateDatedPregnancy(userID, dating, payload.Notes)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": datingErrorMessage})
+			return
+		}
+		if errors.Is(err, services.ErrActivePregnancyExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have an active pregnancy"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pregnancy"})
 		return
 	}
@@ -47,12 +58,20 @@ func CreatePregnancy(c *gin.Context) {
 	c.JSON(http.StatusCreated, pregnancy)
 }
 
-// GetPregnanciesByUser retrieves pregnancy records for a user
+const datingErrorMessage = "Invalid dating. Use method lmp, conception, ivf (embryo_age_days 3 or 5) or ultrasound (gestational_weeks/days at the scan) with a past date"
+
+// GetPregnanciesByUser retrieves pregnancy records for a user; only the user may view them
 func GetPregnanciesByUser(c *gin.Context) {
-	userID := c.Param("user_id")
-	userUUID, err := uuid.Parse(userID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	userUUID := utils.ParseUUIDParamOrAbort(c, "user_id")
+	if userUUID == uuid.Nil {
+		return
+	}
+	callerID := utils.GetUserIDFromContextOrAbort(c)
+	if callerID == uuid.Nil {
+		return
+	}
+	if callerID != userUUID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own pregnancies"})
 		return
 	}
 
@@ -67,8 +86,12 @@ func GetPregnanciesByUser(c *gin.Context) {
 
 // LogSymptom allows a user to log a symptom during pregnancy
 func LogSymptom(c *gin.Context) {
+	userUUID := utils.GetUserIDFromContextOrAbort(c)
+	if userUUID == uuid.Nil {
+		return
+	}
+
 	var payload struct {
-		UserID      string    `json:"user_id" binding:"required"`
 		PregnancyID string    `json:"pregnancy_id" binding:"required"`
 		Date        time.Time `json:"date" binding:"required"`
 		Symptoms    string    `json:"symptoms" binding:"required"`
@@ -80,17 +103,21 @@ func LogSymptom(c *gin.Context) {
 		return
 	}
 
-	userUUID, err := uuid.Parse(payload.UserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
 	pregnancyUUID, err := uuid.Parse(payload.PregnancyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pregnancy ID"})
 		return
 	}
+	var owned int64
+	if err := config.DB.Model(&models.Pregnancy{}).Where("id = ? AND user_id = ?", pregnancyUUID, userUUID).
+		Count(&owned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log symptom"})
+		return
+	}
+	if owned == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+		return
+	}
 
 	symptom := models.SymptomLog{
 		ID:          uuid.New(),
@@ -112,18 +139,191 @@ func LogSymptom(c *gin.Context) {
 
 // GetSymptoms retrieves all symptom logs for a pregnancy
 func GetSymptoms(c *gin.Context) {
-	pregnancyID := c.Param("pregnancy_id")
-	pregnancyUUID, err := uuid.Parse(pregnancyID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pregnancy ID"})
+	pregnancyUUID := utils.ParseUUIDParamOrAbort(c, "pregnancy_id")
+	if pregnancyUUID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
 		return
 	}
 
 	var symptoms []models.SymptomLog
-	if err := config.DB.Where("pregnancy_id = ?", pregnancyUUID).Order("date desc").Find(&symptoms).Error; err != nil {
+	if err := config.DB.Where("pregnancy_id = ? AND user_id = ?", pregnancyUUID, userID).Order("date desc").Find(&symptoms).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve symptoms"})
 		return
 	}
 
 	c.JSON(http.StatusOK, symptoms)
 }
+
+// RecordPregnancyOutcome moves an active pregnancy to delivered, miscarried,
+// ectopic, terminated or stillbirth and starts the matching follow-up
+func RecordPregnancyOutcome(c *gin.Context) {
+	pregnancyID := utils.ParseUUIDParamOrAbort(c, "id")
+	if pregnancyID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var payload services.PregnancyOutcome
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	pregnancy, err := services.RecordPregnancyOutcome(userID, pregnancyID, payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPregnancyOutcome):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be delivered, miscarried, ectopic, terminated or stillbirth"})
+		case errors.Is(err, services.ErrInvalidDeliveryMode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Deliveries need a delivery_mode of vaginal, assisted_vaginal or cesarean"})
+		case errors.Is(err, services.ErrInvalidOutcomeDate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPregnancyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+		case errors.Is(err, services.ErrPregnancyNotActive):
+			c.JSON(http.StatusConflict, gin.H{"error": "Pregnancy is no longer active"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record pregnancy outcome"})
+		}
+		return
+	}
+
+	message := "Pregnancy outcome recorded. Postpartum tracking started."
+	if models.IsPregnancyLoss(pregnancy.Status) {
+		message = "We're so sorry for your loss. Your record has been updated and cycle predictions will resume once your period returns."
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"pregnancy": pregnancy,
+	})
+}
+
+// UpdatePregnancyDating recalculates the due date, e.g. after a dating ultrasound
+func UpdatePregnancyDating(c *gin.Context) {
+	pregnancyID := utils.ParseUUIDParamOrAbort(c, "id")
+	if pregnancyID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var payload struct {
+		services.DatingInput
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	pregnancy, err := services.UpdatePregnancyDating(userID, pregnancyID, payload.DatingInput, payload.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDating):
+			c.JSON(http.StatusBadRequest, gin.H{"error": datingErrorMessage})
+		case errors.Is(err, services.ErrPregnancyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+		case errors.Is(err, services.ErrPregnancyNotActive):
+			c.JSON(http.StatusConflict, gin.H{"error": "Only an active pregnancy can be re-dated"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pregnancy dating"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, pregnancy)
+}
+
+// GetDueDateHistory lists every due date a pregnancy has had
+func GetDueDateHistory(c *gin.Context) {
+	pregnancyID := utils.ParseUUIDParamOrAbort(c, "id")
+	if pregnancyID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	history, err := services.GetDueDateHistory(userID, pregnancyID)
+	if err != nil {
+		if errors.Is(err, services.ErrPregnancyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve due date history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetPregnancyTimeline returns gestational age, trimester and milestones for a pregnancy
+func GetPregnancyTimeline(c *gin.Context) {
+	pregnancyID := utils.ParseUUIDParamOrAbort(c, "id")
+	if pregnancyID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var pregnancy models.Pregnancy
+	if err := config.DB.Where("id = ? AND user_id = ?", pregnancyID, userID).First(&pregnancy).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.BuildPregnancyTimeline(&pregnancy, time.Now()))
+}
+
+// UpdatePregnancyBaseline records pre-pregnancy weight and height for weight-gain guidance
+func UpdatePregnancyBaseline(c *gin.Context) {
+	pregnancyID := utils.ParseUUIDParamOrAbort(c, "id")
+	if pregnancyID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		PrePregnancyWeightKg float64 `json:"pre_pregnancy_weight_kg" binding:"required,gt=20,lt=400"`
+		HeightCm             float64 `json:"height_cm" binding:"required,gt=100,lt=250"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	var pregnancy models.Pregnancy
+	if err := config.DB.Where("id = ? AND user_id = ?", pregnancyID, userID).First(&pregnancy).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pregnancy not found or unauthorized"})
+		return
+	}
+
+	pregnancy.PrePregnancyWeightKg = &input.PrePregnancyWeightKg
+	pregnancy.HeightCm = &input.HeightCm
+	if err := config.DB.Model(&pregnancy).Updates(map[string]interface{}{
+		"pre_pregnancy_weight_kg": input.PrePregnancyWeightKg,
+		"height_cm":               input.HeightCm,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update baseline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pregnancy":   pregnancy,
+		"weight_gain": services.IOMWeightGainRange(input.PrePregnancyWeightKg, input.HeightCm, pregnancy.CurrentWeek),
+	})
+}
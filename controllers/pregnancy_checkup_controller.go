@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/models"
 	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +21,62 @@ func NewPregnancyCheckupController(service *services.PregnancyCheckupService) *P
 	return &PregnancyCheckupController{Service: service}
 }
 
+// syncFollowUp proposes or moves the checkup's follow-up appointment and
+// returns its ID. Only the checkup's doctor can do this; for anyone else the
+// current link is returned unchanged. Failures are logged because the checkup
+// itself has been saved.
+func syncFollowUp(kind string, checkupID, callerID, doctorID uuid.UUID, current *uuid.UUID) *uuid.UUID {
+	if doctorID == uuid.Nil || callerID != doctorID {
+		return current
+	}
+	appt, err := services.SyncCheckupFollowUp(kind, checkupID, callerID, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to propose follow-up for checkup %s: %v", checkupID, err)
+		return current
+	}
+	if appt == nil {
+		return current
+	}
+	return &appt.ID
+}
+
+// patientOrCareTeamOrAbort lets through the patient and doctors on their care
+// team and returns the caller
+func patientOrCareTeamOrAbort(c *gin.Context, patientID uuid.UUID) uuid.UUID {
+	callerID := utils.GetUserIDFromContextOrAbort(c)
+	if callerID == uuid.Nil || callerID == patientID {
+		return callerID
+	}
+	if c.GetString("user_role") != models.RoleDoctor {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only access your own checkups or your patients'"})
+		return uuid.Nil
+	}
+	ok, err := services.HasCareTeamConsent(patientID, callerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check care team consent"})
+		return uuid.Nil
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Patient has not added you to their care team"})
+		return uuid.Nil
+	}
+	return callerID
+}
+
+// checkupPartyOrAbort lets only the patient and the checkup's doctor through
+// and returns the caller
+func checkupPartyOrAbort(c *gin.Context, userID, doctorID uuid.UUID) uuid.UUID {
+	callerID := utils.GetUserIDFromContextOrAbort(c)
+	if callerID == uuid.Nil {
+		return uuid.Nil
+	}
+	if callerID != userID && (doctorID == uuid.Nil || callerID != doctorID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only access your own checkups or those you recorded"})
+		return uuid.Nil
+	}
+	return callerID
+}
+
 // Create new checkup
 func (pc *PregnancyCheckupController) CreateCheckup(c *gin.Context) {
 	var input struct {
@@ -36,6 +94,15 @@ func (pc *PregnancyCheckupController) CreateCheckup(c *gin.Context) {
 		return
 	}
 
+	callerID := patientOrCareTeamOrAbort(c, input.UserID)
+	if callerID == uuid.Nil {
+		return
+	}
+	// A doctor records the checkup as their own
+	if callerID != input.UserID {
+		input.DoctorID = callerID
+	}
+
 	checkup := models.PregnancyCheckup{
 		UserID:        input.UserID,
 		DoctorID:      input.DoctorID,
@@ -50,6 +117,10 @@ func (pc *PregnancyCheckupController) CreateCheckup(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save checkup"})
 		return
 	}
+	if err := services.EvaluatePregnancyCheckup(&checkup); err != nil {
+		log.Printf("❌ Failed to evaluate prenatal vitals for checkup %s: %v", checkup.ID, err)
+	}
+	checkup.FollowUpAppointmentID = syncFollowUp(models.CheckupKindPregnancy, checkup.ID, callerID, checkup.DoctorID, checkup.FollowUpAppointmentID)
 
 	c.JSON(http.StatusCreated, checkup)
 }
@@ -62,6 +133,9 @@ func (pc *PregnancyCheckupController) GetUserCheckups(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
 		return
 	}
+	if patientOrCareTeamOrAbort(c, uid) == uuid.Nil {
+		return
+	}
 
 	checkups, err := pc.Service.GetCheckupsByUser(uid)
 	if err != nil {
@@ -90,6 +164,9 @@ func (pc *PregnancyCheckupController) GetCheckup(c *gin.Context) {
 		}
 		return
 	}
+	if checkupPartyOrAbort(c, checkup.UserID, checkup.DoctorID) == uuid.Nil {
+		return
+	}
 
 	c.JSON(http.StatusOK, checkup)
 }
@@ -109,15 +186,29 @@ func (pc *PregnancyCheckupController) UpdateCheckup(c *gin.Context) {
 		return
 	}
 
+	callerID := checkupPartyOrAbort(c, checkup.UserID, checkup.DoctorID)
+	if callerID == uuid.Nil {
+		return
+	}
+
+	// The patient, doctor and appointment links are kept by the server
+	userID, doctorID := checkup.UserID, checkup.DoctorID
+	appointmentID, followUpID := checkup.AppointmentID, checkup.FollowUpAppointmentID
 	if err := c.ShouldBindJSON(checkup); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	checkup.ID, checkup.UserID, checkup.DoctorID = cid, userID, doctorID
+	checkup.AppointmentID, checkup.FollowUpAppointmentID = appointmentID, followUpID
 
 	if err := pc.Service.UpdateCheckup(checkup); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update checkup"})
 		return
 	}
+	if err := services.EvaluatePregnancyCheckup(checkup); err != nil {
+		log.Printf("❌ Failed to evaluate prenatal vitals for checkup %s: %v", checkup.ID, err)
+	}
+	checkup.FollowUpAppointmentID = syncFollowUp(models.CheckupKindPregnancy, checkup.ID, callerID, checkup.DoctorID, checkup.FollowUpAppointmentID)
 
 	c.JSON(http.StatusOK, checkup)
 }
@@ -131,6 +222,15 @@ func (pc *PregnancyCheckupController) DeleteCheckup(c *gin.Context) {
 		return
 	}
 
+	checkup, err := pc.Service.GetCheckupByID(cid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checkup not found"})
+		return
+	}
+	if checkupPartyOrAbort(c, checkup.UserID, checkup.DoctorID) == uuid.Nil {
+		return
+	}
+
 	if err := pc.Service.DeleteCheckup(cid); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete checkup"})
 		return
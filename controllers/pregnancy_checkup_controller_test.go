@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/models"
+)
+
+func checkupTestContext(callerID uuid.UUID, role string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user_id", callerID.String())
+	c.Set("user_role", role)
+	return c, w
+}
+
+func TestPatientOrCareTeamOrAbort(t *testing.T) {
+	patient, other := uuid.New(), uuid.New()
+
+	c, _ := checkupTestContext(patient, "user")
+	if got := patientOrCareTeamOrAbort(c, patient); got != patient {
+		t.Errorf("patient was refused their own checkups")
+	}
+
+	c, w := checkupTestContext(other, "user")
+	if got := patientOrCareTeamOrAbort(c, patient); got != uuid.Nil || w.Code != http.StatusForbidden {
+		t.Errorf("another user got through: caller %s, status %d", got, w.Code)
+	}
+}
+
+func TestCheckupPartyOrAbort(t *testing.T) {
+	patient, doctor, other := uuid.New(), uuid.New(), uuid.New()
+
+	for _, caller := range []uuid.UUID{patient, doctor} {
+		c, _ := checkupTestContext(caller, models.RoleDoctor)
+		if got := checkupPartyOrAbort(c, patient, doctor); got != caller {
+			t.Errorf("party %s was refused", caller)
+		}
+	}
+
+	c, w := checkupTestContext(other, models.RoleDoctor)
+	if got := checkupPartyOrAbort(c, patient, doctor); got != uuid.Nil || w.Code != http.StatusForbidden {
+		t.Errorf("another doctor got through: caller %s, status %d", got, w.Code)
+	}
+
+	// Without a doctor on the checkup only the patient is let in
+	c, w = checkupTestContext(other, models.RoleDoctor)
+	if got := checkupPartyOrAbort(c, patient, uuid.Nil); got != uuid.Nil || w.Code != http.StatusForbidden {
+		t.Errorf("caller got into a checkup with no doctor: caller %s, status %d", got, w.Code)
+	}
+}
+
+func TestSyncFollowUpOnlyForCheckupDoctor(t *testing.T) {
+	current := uuid.New()
+	patient, doctor := uuid.New(), uuid.New()
+
+	// Anyone but the checkup's doctor leaves the follow-up alone
+	for _, caller := range []uuid.UUID{patient, uuid.New()} {
+		if got := syncFollowUp(models.CheckupKindPregnancy, uuid.New(), caller, doctor, &current); got != &current {
+			t.Errorf("caller %s changed the follow-up", caller)
+		}
+	}
+	if got := syncFollowUp(models.CheckupKindPregnancy, uuid.New(), patient, uuid.Nil, nil); got != nil {
+		t.Errorf("a checkup without a doctor got a follow-up")
+	}
+}
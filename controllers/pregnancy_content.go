@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+type pregnancyContentInput struct {
+	Week             int    `json:"week" binding:"required"`
+	Title            string `json:"title" binding:"required"`
+	BabyDevelopment  string `json:"baby_development"`
+	WhatToExpect     string `json:"what_to_expect"`
+	RecommendedTests string `json:"recommended_tests"`
+	Tips             string `json:"tips"`
+	Published        bool   `json:"published"`
+}
+
+func applyPregnancyContentInput(content *models.PregnancyWeekContent, input pregnancyContentInput) {
+	content.Week = input.Week
+	content.Trimester = models.TrimesterForWeek(input.Week)
+	content.Title = input.Title
+	content.BabyDevelopment = input.BabyDevelopment
+	content.WhatToExpect = input.WhatToExpect
+	content.RecommendedTests = input.RecommendedTests
+	content.Tips = input.Tips
+	content.Published = input.Published
+}
+
+func validContentWeek(week int) bool {
+	return week >= models.MinContentWeek && week <= models.MaxContentWeek
+}
+
+// weekContentTaken reports whether another content entry already covers the week
+func weekContentTaken(week int, exceptID uuid.UUID) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.PregnancyWeekContent{}).Where("week = ? AND id <> ?", week, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+func respondWeekTaken(c *gin.Context, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing content"})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Content for this week already exists"})
+}
+
+// GetCurrentPregnancyContent returns the guide for the user's current week of pregnancy
+func GetCurrentPregnancyContent(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	pregnancy, err := services.GetActivePregnancy(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoActivePregnancy) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No active pregnancy"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pregnancy"})
+		return
+	}
+
+	content, err := services.GetPublishedWeekContent(pregnancy.CurrentWeek)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch content"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"week":      pregnancy.CurrentWeek,
+		"trimester": pregnancy.Trimester,
+		"content":   content,
+	})
+}
+
+// GetPregnancyWeekContent returns the published guide for a gestational week
+func GetPregnancyWeekContent(c *gin.Context) {
+	week, err := strconv.Atoi(c.Param("week"))
+	if err != nil || !validContentWeek(week) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "week must be between 1 and 42"})
+		return
+	}
+
+	content, err := services.GetPublishedWeekContent(week)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch content"})
+		return
+	}
+	if content == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No content for this week yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, content)
+}
+
+// ListPregnancyContent lists the whole content library, including drafts (admin only)
+func ListPregnancyContent(c *gin.Context) {
+	query := config.DB.Order("week asc")
+	if v := c.Query("trimester"); v != "" {
+		trimester, err := strconv.Atoi(v)
+		if err != nil || trimester < 1 || trimester > 3 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "trimester must be 1, 2 or 3"})
+			return
+		}
+		query = query.Where("trimester = ?", trimester)
+	}
+
+	var contents []models.PregnancyWeekContent
+	if err := query.Find(&contents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch content"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contents)
+}
+
+// CreatePregnancyContent adds the guide for a week (admin only)
+func CreatePregnancyContent(c *gin.Context) {
+	var input pregnancyContentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if !validContentWeek(input.Week) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "week must be between 1 and 42"})
+		return
+	}
+
+	if taken, err := weekContentTaken(input.Week, uuid.Nil); err != nil || taken {
+		respondWeekTaken(c, err)
+		return
+	}
+
+	now := time.Now()
+	content := models.PregnancyWeekContent{ID: uuid.New(), CreatedAt: now, UpdatedAt: now}
+	applyPregnancyContentInput(&content, input)
+
+	if err := config.DB.Create(&content).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create content"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, content)
+}
+
+// UpdatePregnancyContent replaces the guide for a week (admin only)
+func UpdatePregnancyContent(c *gin.Context) {
+	contentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if contentID == uuid.Nil {
+		return
+	}
+
+	var content models.PregnancyWeekContent
+	if err := config.DB.First(&content, "id = ?", contentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content not found"})
+		return
+	}
+
+	var input pregnancyContentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid update data", "details": err.Error()})
+		return
+	}
+	if !validContentWeek(input.Week) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "week must be between 1 and 42"})
+		return
+	}
+	if taken, err := weekContentTaken(input.Week, content.ID); err != nil || taken {
+		respondWeekTaken(c, err)
+		return
+	}
+	applyPregnancyContentInput(&content, input)
+	content.UpdatedAt = time.Now()
+
+	if err := config.DB.Save(&content).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update content"})
+		return
+	}
+
+	c.JSON(http.StatusOK, content)
+}
+
+// DeletePregnancyContent removes the guide for a week (admin only)
+func DeletePregnancyContent(c *gin.Context) {
+	contentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if contentID == uuid.Nil {
+		return
+	}
+
+	result := config.DB.Delete(&models.PregnancyWeekContent{}, "id = ?", contentID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete content"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Content deleted successfully"})
+}
@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
 	"github.com/shem958/cycle-backend/utils"
 )
 
@@ -32,15 +34,23 @@ func UpdateProfile(c *gin.Context) {
 	}
 
 	var updates struct {
-		Username  string `json:"username"`
-		Bio       string `json:"bio"`
-		AvatarURL string `json:"avatar_url"`
+		Username  string  `json:"username"`
+		Bio       string  `json:"bio"`
+		AvatarURL string  `json:"avatar_url"`
+		TimeZone  *string `json:"time_zone"` // IANA zone; "" clears it
 	}
 	if err := c.ShouldBindJSON(&updates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
+	if updates.TimeZone != nil && *updates.TimeZone != "" {
+		if _, err := time.LoadLocation(*updates.TimeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidTimeZone.Error()})
+			return
+		}
+	}
+
 	var user models.User
 	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -50,6 +60,9 @@ func UpdateProfile(c *gin.Context) {
 	user.Username = updates.Username
 	user.Bio = updates.Bio
 	user.AvatarURL = updates.AvatarURL
+	if updates.TimeZone != nil {
+		user.TimeZone = *updates.TimeZone
+	}
 
 	if err := config.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
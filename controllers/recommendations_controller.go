@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
 )
 
 // GetRecommendations retrieves personalized health recommendations for a user
@@ -43,12 +45,41 @@ func GetRecommendations(c *gin.Context) {
 		return
 	}
 
+	// Pregnant users get guidance for their current week ahead of the generic defaults
+	if pregnancy, err := services.GetActivePregnancy(parsedID); err == nil {
+		content, err := services.GetPublishedWeekContent(pregnancy.CurrentWeek)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pregnancy content"})
+			return
+		}
+		if content != nil {
+			recommendations = append(services.WeekContentRecommendations(parsedID, content, now), recommendations...)
+		}
+	} else if !errors.Is(err, services.ErrNoActivePregnancy) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pregnancy"})
+		return
+	}
+
+	// After a pregnancy loss, pregnancy and baby advice is left out
+	sensitive, err := services.InPregnancyLossRecovery(parsedID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pregnancy history"})
+		return
+	}
+	if sensitive {
+		recommendations = services.WithoutBabyContent(recommendations)
+	}
+
 	if len(recommendations) == 0 {
 		// If no personalized recommendations, return default ones
+		exercise := "Consider starting with gentle exercises like walking or prenatal yoga."
+		if sensitive {
+			exercise = "When you feel ready, gentle exercise like walking or stretching can help your recovery."
+		}
 		defaultRecommendations := []gin.H{
 			{
 				"category": "Exercise",
-				"advice":   "Consider starting with gentle exercises like walking or prenatal yoga.",
+				"advice":   exercise,
 				"priority": 3,
 			},
 			{
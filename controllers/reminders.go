@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// GetUpcomingReminders lists the reminders still waiting to be sent to the user
+func GetUpcomingReminders(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	jobs := []models.ReminderJob{}
+	if err := config.DB.Where("user_id = ? AND status = ? AND event_at > ?", userID, models.ReminderPending, time.Now()).
+		Order("due_at asc").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reminders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetReminderPreferences returns the user's settings for every reminder type
+func GetReminderPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var prefs []models.ReminderPreference
+	for _, t := range []string{models.ReminderTypeAppointment, models.ReminderTypeCheckup} {
+		pref, err := services.GetReminderPreference(userID, t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+			return
+		}
+		prefs = append(prefs, pref)
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateReminderPreference turns a reminder type on or off and sets its lead times in minutes
+func UpdateReminderPreference(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input struct {
+		Enabled     *bool   `json:"enabled" binding:"required"`
+		LeadMinutes []int64 `json:"lead_minutes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	reminderType := c.Param("type")
+	leads := input.LeadMinutes
+	if len(leads) == 0 {
+		current, err := services.GetReminderPreference(userID, reminderType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+			return
+		}
+		leads = current.LeadMinutes
+	}
+
+	pref, err := services.SetReminderPreference(userID, reminderType, *input.Enabled, leads)
+	if errors.Is(err, services.ErrInvalidReminderPreference) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
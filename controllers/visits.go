@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// respondVisitError maps video visit errors to responses
+func respondVisitError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrNotVirtualVisit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVisitNotConfirmed), errors.Is(err, services.ErrVisitNotOpenYet),
+		errors.Is(err, services.ErrVisitEnded), errors.Is(err, services.ErrVisitNotJoined):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// JoinVisit returns a join token for a virtual appointment during its window
+func JoinVisit(c *gin.Context) {
+	appointmentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if appointmentID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	join, err := services.JoinVisit(appointmentID, userID, time.Now())
+	if err != nil {
+		respondVisitError(c, err, "Could not join the video visit")
+		return
+	}
+
+	c.JSON(http.StatusOK, join)
+}
+
+// LeaveVisit records that the caller left the video visit
+func LeaveVisit(c *gin.Context) {
+	appointmentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if appointmentID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	if err := services.LeaveVisit(appointmentID, userID, time.Now()); err != nil {
+		respondVisitError(c, err, "Could not leave the video visit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left the video visit"})
+}
+
+// GetVisit returns an appointment's video rooms and who joined and left them
+func GetVisit(c *gin.Context) {
+	appointmentID := utils.ParseUUIDParamOrAbort(c, "id")
+	if appointmentID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	appt, err := services.GetAppointmentForParty(appointmentID, userID)
+	if err != nil {
+		respondVisitError(c, err, "Failed to retrieve appointment")
+		return
+	}
+	rooms, events, err := services.GetVisitRooms(appt.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve video visit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mode": appt.Mode, "rooms": rooms, "events": events})
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/services"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+// vitalRanges are the plausible values accepted for each single-value reading type
+var vitalRanges = map[string][2]float64{
+	models.VitalWeight:      {20, 400},
+	models.VitalGlucose:     {10, 800},
+	models.VitalHeartRate:   {20, 250},
+	models.VitalTemperature: {30, 45},
+}
+
+type vitalReadingInput struct {
+	Type        string     `json:"type" binding:"required"`
+	Systolic    *int       `json:"systolic"`
+	Diastolic   *int       `json:"diastolic"`
+	Value       *float64   `json:"value"`
+	Source      string     `json:"source"`
+	Device      string     `json:"device"`
+	MealContext string     `json:"meal_context"`
+	MeasuredAt  *time.Time `json:"measured_at"`
+	Notes       string     `json:"notes"`
+}
+
+var mealContexts = map[string]bool{
+	models.MealContextFasting:   true,
+	models.MealContextPreMeal:   true,
+	models.MealContextPostMeal1: true,
+	models.MealContextPostMeal2: true,
+	models.MealContextBedtime:   true,
+	models.MealContextRandom:    true,
+}
+
+// validateVitalReading checks the reading carries the fields its type needs
+func validateVitalReading(input vitalReadingInput) string {
+	if _, ok := models.VitalUnits[input.Type]; !ok {
+		return "type must be one of blood_pressure, weight, glucose, heart_rate, temperature"
+	}
+	if input.Source != "" && input.Source != models.VitalSourceManual && input.Source != models.VitalSourceDevice {
+		return "source must be manual or device"
+	}
+	if input.MeasuredAt != nil && input.MeasuredAt.After(time.Now().Add(5*time.Minute)) {
+		return "measured_at cannot be in the future"
+	}
+	if input.Type == models.VitalBloodPressure {
+		if input.Systolic == nil || input.Diastolic == nil {
+			return "systolic and diastolic are required for blood pressure"
+		}
+		if *input.Systolic < 50 || *input.Systolic > 300 || *input.Diastolic < 30 || *input.Diastolic > 200 || *input.Diastolic >= *input.Systolic {
+			return "blood pressure reading is out of range"
+		}
+		return ""
+	}
+	if input.Value == nil {
+		return "value is required"
+	}
+	if input.Type == models.VitalGlucose && !mealContexts[input.MealContext] {
+		return "meal_context must be one of fasting, pre_meal, post_meal_1h, post_meal_2h, bedtime, random"
+	}
+	r := vitalRanges[input.Type]
+	if *input.Value < r[0] || *input.Value > r[1] {
+		return "value is out of range for " + input.Type + " (" + models.VitalUnits[input.Type] + ")"
+	}
+	return ""
+}
+
+// LogVitalReading records a home measurement and checks it against the prenatal rules
+func LogVitalReading(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	var input vitalReadingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if msg := validateVitalReading(input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	reading := models.VitalReading{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Type:       input.Type,
+		Unit:       models.VitalUnits[input.Type],
+		Source:     input.Source,
+		Device:     input.Device,
+		MeasuredAt: time.Now(),
+		Notes:      input.Notes,
+		CreatedAt:  time.Now(),
+	}
+	if reading.Source == "" {
+		reading.Source = models.VitalSourceManual
+	}
+	if input.MeasuredAt != nil {
+		reading.MeasuredAt = *input.MeasuredAt
+	}
+	if input.Type == models.VitalBloodPressure {
+		reading.Systolic, reading.Diastolic = input.Systolic, input.Diastolic
+	} else {
+		reading.Value = input.Value
+	}
+	if input.Type == models.VitalGlucose {
+		reading.MealContext = input.MealContext
+	}
+
+	if err := config.DB.Create(&reading).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reading"})
+		return
+	}
+	services.InvalidateAnalyticsCacheForUser(userID)
+	if err := services.EvaluateVitalReading(&reading); err != nil {
+		log.Printf("❌ Failed to evaluate vital reading %s: %v", reading.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, reading)
+}
+
+// GetVitalReadings lists the user's home readings, optionally filtered by type and date range
+func GetVitalReadings(c *gin.Context) {
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+	fromPtr, toPtr, ok := parseRange(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if t := c.Query("type"); t != "" {
+		if _, ok := models.VitalUnits[t]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown vital type"})
+			return
+		}
+		query = query.Where("type = ?", t)
+	}
+	if fromPtr != nil {
+		query = query.Where("measured_at >= ?", *fromPtr)
+	}
+	if toPtr != nil {
+		query = query.Where("measured_at <= ?", *toPtr)
+	}
+
+	var readings []models.VitalReading
+	if err := query.Order("measured_at desc").Limit(500).Find(&readings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve readings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, readings)
+}
+
+// DeleteVitalReading removes one of the user's home readings
+func DeleteVitalReading(c *gin.Context) {
+	readingID := utils.ParseUUIDParamOrAbort(c, "id")
+	if readingID == uuid.Nil {
+		return
+	}
+	userID := utils.GetUserIDFromContextOrAbort(c)
+	if userID == uuid.Nil {
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", readingID, userID).Delete(&models.VitalReading{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reading"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reading not found or unauthorized"})
+		return
+	}
+	services.InvalidateAnalyticsCacheForUser(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading deleted successfully"})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/shem958/cycle-backend/config"
 	"github.com/shem958/cycle-backend/routes"
+	"github.com/shem958/cycle-backend/services"
 )
 
 func main() {
@@ -18,6 +19,9 @@ func main() {
 	// Connect to the database
 	config.ConnectDB()
 
+	// Start background jobs (reminders, missed doses, ...)
+	services.StartScheduler()
+
 	// Initialize and setup router
 	router := routes.SetupRouter()
 
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// BackfillAppointmentEndsAt adds ends_at to an existing appointments table.
+// Appointments booked before lengths were recorded are given the default
+// 30 minutes (services.DefaultAppointmentMinutes).
+func BackfillAppointmentEndsAt(db *gorm.DB) error {
+	if !db.Migrator().HasTable("appointments") || db.Migrator().HasColumn("appointments", "ends_at") {
+		return nil
+	}
+	log.Println("🔧 Adding appointments.ends_at...")
+	if err := db.Exec("ALTER TABLE appointments ADD COLUMN ends_at timestamptz").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("UPDATE appointments SET ends_at = scheduled_at + interval '30 minutes' WHERE ends_at IS NULL").Error; err != nil {
+		return err
+	}
+	return db.Exec("ALTER TABLE appointments ALTER COLUMN ends_at SET NOT NULL").Error
+}
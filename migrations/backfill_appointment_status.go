@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// BackfillAppointmentStatus adds the lifecycle columns to an existing
+// appointments table. AutoMigrate cannot add NOT NULL columns without a default
+// to a table that has rows, so they are added nullable, filled in and then
+// tightened. Appointments booked before the lifecycle existed count as confirmed.
+func BackfillAppointmentStatus(db *gorm.DB) error {
+	if !db.Migrator().HasTable("appointments") {
+		return nil
+	}
+	if !db.Migrator().HasColumn("appointments", "status") {
+		log.Println("🔧 Adding appointments.status...")
+		if err := db.Exec("ALTER TABLE appointments ADD COLUMN status varchar(20)").Error; err != nil {
+			return err
+		}
+		if err := db.Exec("UPDATE appointments SET status = 'confirmed' WHERE status IS NULL").Error; err != nil {
+			return err
+		}
+		if err := db.Exec("ALTER TABLE appointments ALTER COLUMN status SET NOT NULL").Error; err != nil {
+			return err
+		}
+	}
+	if !db.Migrator().HasColumn("appointments", "reschedule_count") {
+		log.Println("🔧 Adding appointments.reschedule_count...")
+		if err := db.Exec("ALTER TABLE appointments ADD COLUMN reschedule_count bigint NOT NULL DEFAULT 0").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
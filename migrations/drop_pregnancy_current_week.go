@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// DropPregnancyCurrentWeek removes the stored current_week column, which went
+// stale as soon as it was written; the week is now computed when a pregnancy is read
+func DropPregnancyCurrentWeek(db *gorm.DB) error {
+	if !db.Migrator().HasTable("pregnancies") || !db.Migrator().HasColumn("pregnancies", "current_week") {
+		return nil
+	}
+	log.Println("🗑️  Dropping stale pregnancies.current_week column...")
+	return db.Exec("ALTER TABLE pregnancies DROP COLUMN current_week").Error
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// RenameCompletedPregnancies moves pregnancies stored with the old "completed"
+// status to "delivered", which replaced it when outcomes were recorded
+func RenameCompletedPregnancies(db *gorm.DB) error {
+	if !db.Migrator().HasTable("pregnancies") {
+		return nil
+	}
+	result := db.Exec("UPDATE pregnancies SET status = 'delivered' WHERE status = 'completed'")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🔁 Marked %d completed pregnancies as delivered", result.RowsAffected)
+	}
+	return nil
+}
@@ -16,6 +16,30 @@ func RunMigrations(db *gorm.DB) error {
 		return err
 	}
 
+	// Gestational age is computed on read now
+	if err := DropPregnancyCurrentWeek(db); err != nil {
+		log.Printf("❌ Migration failed: %v", err)
+		return err
+	}
+
+	// "completed" pregnancies are "delivered" now
+	if err := RenameCompletedPregnancies(db); err != nil {
+		log.Printf("❌ Migration failed: %v", err)
+		return err
+	}
+
+	// Appointment lifecycle columns on tables created before it existed
+	if err := BackfillAppointmentStatus(db); err != nil {
+		log.Printf("❌ Migration failed: %v", err)
+		return err
+	}
+
+	// Appointment end times, added with slot booking
+	if err := BackfillAppointmentEndsAt(db); err != nil {
+		log.Printf("❌ Migration failed: %v", err)
+		return err
+	}
+
 	log.Println("✅ All migrations completed successfully")
 	return nil
 }
@@ -6,22 +6,88 @@ import (
 	"github.com/google/uuid"
 )
 
+// Appointment statuses
+const (
+	AppointmentRequested = "requested"
+	AppointmentConfirmed = "confirmed"
+	AppointmentCancelled = "cancelled"
+	AppointmentCompleted = "completed"
+	AppointmentNoShow    = "no_show"
+)
+
+// Appointment lifecycle actions recorded in the history
+const (
+	AppointmentActionRequested   = "requested"
+	AppointmentActionConfirmed   = "confirmed"
+	AppointmentActionRescheduled = "rescheduled"
+	AppointmentActionCancelled   = "cancelled"
+	AppointmentActionCompleted   = "completed"
+	AppointmentActionNoShow      = "no_show"
+)
+
+// How an appointment takes place
+const (
+	AppointmentModeInPerson = "in_person"
+	AppointmentModeVirtual  = "virtual" // a video visit, see VisitRoom
+)
+
+// Kinds of checkup record an appointment can be linked to
+const (
+	CheckupKindPregnancy  = "pregnancy"
+	CheckupKindPostpartum = "postpartum"
+)
+
 type Appointment struct {
-	ID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID" json:"-"`
+
+	DoctorID uuid.UUID `gorm:"type:uuid;not null;index" json:"doctor_id"`
+	Doctor   User      `gorm:"foreignKey:DoctorID" json:"-"`
 
-	UserID uuid.UUID `gorm:"type:uuid;not null"`
-	User   User      `gorm:"foreignKey:UserID"`
+	Title       string    `gorm:"type:varchar(255)" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Location    string    `gorm:"type:varchar(255)" json:"location"`
+	Mode        string    `gorm:"type:varchar(20);not null;default:'in_person'" json:"mode"` // in_person or virtual
+	ScheduledAt time.Time `gorm:"not null" json:"scheduled_at"`
+	EndsAt      time.Time `gorm:"not null;index" json:"ends_at"`
 
-	DoctorID uuid.UUID `gorm:"type:uuid;not null"`
-	Doctor   User      `gorm:"foreignKey:DoctorID"`
+	AppointmentTypeID *uuid.UUID `gorm:"type:uuid" json:"appointment_type_id,omitempty"`
 
-	Title       string    `gorm:"type:varchar(255)"`
-	Description string    `gorm:"type:text"`
-	Location    string    `gorm:"type:varchar(255)"`
-	ScheduledAt time.Time `gorm:"not null"`
+	IsFollowUp bool `gorm:"default:false" json:"is_follow_up"`
 
-	IsFollowUp bool `gorm:"default:false"`
+	// The checkup recorded when this visit was completed
+	CheckupKind string     `gorm:"type:varchar(20)" json:"checkup_kind,omitempty"` // pregnancy or postpartum
+	CheckupID   *uuid.UUID `gorm:"type:uuid;index" json:"checkup_id,omitempty"`
+	// The checkup whose NextCheckupAt proposed this follow-up
+	SourceCheckupKind string     `gorm:"type:varchar(20)" json:"source_checkup_kind,omitempty"`
+	SourceCheckupID   *uuid.UUID `gorm:"type:uuid;index" json:"source_checkup_id,omitempty"`
+
+	Status             string     `gorm:"type:varchar(20);not null;index" json:"status"` // requested, confirmed, cancelled, completed, no_show
+	RescheduleCount    int        `gorm:"not null;default:0" json:"reschedule_count"`
+	CancellationReason string     `gorm:"type:text" json:"cancellation_reason,omitempty"`
+	CancelledBy        *uuid.UUID `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	ConfirmedAt        *time.Time `json:"confirmed_at,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"` // also set for no-shows
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
 
-	CreatedAt time.Time
-	UpdatedAt time.Time
+// AppointmentEvent is one step in an appointment's history. Reschedules keep
+// both the old and the new time.
+type AppointmentEvent struct {
+	ID                  uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	AppointmentID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"appointment_id"`
+	ActorID             uuid.UUID  `gorm:"type:uuid;not null" json:"actor_id"`
+	ActorRole           string     `gorm:"type:varchar(10);not null" json:"actor_role"` // "patient" or "doctor"
+	Action              string     `gorm:"type:varchar(20);not null" json:"action"`
+	FromStatus          string     `gorm:"type:varchar(20)" json:"from_status,omitempty"`
+	ToStatus            string     `gorm:"type:varchar(20);not null" json:"to_status"`
+	PreviousScheduledAt *time.Time `json:"previous_scheduled_at,omitempty"`
+	NewScheduledAt      *time.Time `json:"new_scheduled_at,omitempty"`
+	Reason              string     `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
 }
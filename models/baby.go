@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Baby sexes, used to pick the WHO growth standard
+const (
+	BabySexFemale = "female"
+	BabySexMale   = "male"
+)
+
+// Baby care log kinds
+const (
+	CareLogFeeding = "feeding"
+	CareLogDiaper  = "diaper"
+	CareLogSleep   = "sleep"
+)
+
+// Baby is an infant born from a delivered pregnancy. Twins and other multiples
+// share a pregnancy and are told apart by BirthOrder.
+type Baby struct {
+	ID                       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID                   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	PregnancyID              uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_babies_pregnancy_order" json:"pregnancy_id"`
+	BirthOrder               int       `gorm:"not null;default:1;uniqueIndex:idx_babies_pregnancy_order" json:"birth_order"`
+	Name                     string    `json:"name,omitempty"`
+	Sex                      string    `gorm:"type:varchar(10)" json:"sex"` // "female", "male"
+	BirthDate                time.Time `gorm:"not null" json:"birth_date"`
+	BirthWeightKg            float64   `json:"birth_weight_kg,omitempty"`
+	BirthLengthCm            float64   `json:"birth_length_cm,omitempty"`
+	BirthHeadCircumferenceCm float64   `json:"birth_head_circumference_cm,omitempty"`
+	Notes                    string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
+
+// GrowthMeasurement is a weight/length/head measurement of a baby
+type GrowthMeasurement struct {
+	ID                  uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BabyID              uuid.UUID `gorm:"type:uuid;not null;index" json:"baby_id"`
+	UserID              uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	MeasuredAt          time.Time `gorm:"not null" json:"measured_at"`
+	WeightKg            *float64  `json:"weight_kg,omitempty"`
+	LengthCm            *float64  `json:"length_cm,omitempty"`
+	HeadCircumferenceCm *float64  `json:"head_circumference_cm,omitempty"`
+	Notes               string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// WHO percentiles, computed on read
+	WeightPercentile *float64 `gorm:"-" json:"weight_percentile,omitempty"`
+	LengthPercentile *float64 `gorm:"-" json:"length_percentile,omitempty"`
+}
+
+// BabyCareLog records a feeding, diaper change or sleep
+type BabyCareLog struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BabyID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_baby_care_logs_baby_started" json:"baby_id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Kind      string     `gorm:"type:varchar(20);not null" json:"kind"` // "feeding", "diaper", "sleep"
+	StartedAt time.Time  `gorm:"not null;index:idx_baby_care_logs_baby_started" json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+
+	FeedingType string  `gorm:"type:varchar(20)" json:"feeding_type,omitempty"` // "breast", "bottle", "solids"
+	AmountMl    float64 `json:"amount_ml,omitempty"`
+	DiaperType  string  `gorm:"type:varchar(20)" json:"diaper_type,omitempty"` // "wet", "dirty", "mixed"
+
+	Notes     string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Vaccination is a scheduled or administered vaccine dose
+type Vaccination struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BabyID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"baby_id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Vaccine        string     `gorm:"not null" json:"vaccine"`
+	Dose           int        `gorm:"default:1" json:"dose"`
+	DueDate        time.Time  `gorm:"type:date;not null;index" json:"due_date"`
+	AdministeredAt *time.Time `json:"administered_at,omitempty"`
+	ReminderSentAt *time.Time `json:"-"`
+	// Set once the parent has been told the dose is overdue
+	OverdueReminderSentAt *time.Time `json:"-"`
+	Notes                 string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
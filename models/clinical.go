@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clinical alert severities
+const (
+	AlertSeverityWarning = "warning"
+	AlertSeverityUrgent  = "urgent"
+)
+
+// Sources a clinical alert can be raised from
+const (
+	AlertSourcePregnancyCheckup = "pregnancy_checkup"
+	AlertSourceVitalReading     = "vital_reading"
+	AlertSourceEPDSResponse     = "epds_response"
+)
+
+// VitalThreshold overrides a default clinical rule threshold. Admins edit these;
+// keys without a row use the built-in default.
+type VitalThreshold struct {
+	Key         string    `gorm:"type:varchar(50);primaryKey" json:"key"`
+	Value       float64   `gorm:"not null" json:"value"`
+	Description string    `json:"description,omitempty"`
+	UpdatedBy   uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ClinicalAlert is raised when a reading breaks a clinical rule. The unique index
+// keeps re-evaluation of the same reading from duplicating alerts.
+type ClinicalAlert struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	PregnancyID    *uuid.UUID `gorm:"type:uuid;index" json:"pregnancy_id,omitempty"`
+	Rule           string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_clinical_alerts_source_rule" json:"rule"`
+	Severity       string     `gorm:"type:varchar(20);not null" json:"severity"` // "warning", "urgent"
+	Message        string     `gorm:"type:text;not null" json:"message"`
+	Value          string     `json:"value,omitempty"` // the reading that triggered the rule
+	SourceType     string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_clinical_alerts_source_rule" json:"source_type"`
+	SourceID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_clinical_alerts_source_rule" json:"source_id"`
+	ObservedAt     time.Time  `json:"observed_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CareTeamLink records a user's consent for a doctor to see their data and receive their alerts
+type CareTeamLink struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_care_team_links_user_doctor" json:"user_id"`
+	DoctorID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_care_team_links_user_doctor;index" json:"doctor_id"`
+	ShareAlerts bool       `gorm:"not null" json:"share_alerts"`
+	ConsentedAt time.Time  `gorm:"not null" json:"consented_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailyLog is a day-level entry of mood, symptoms and flow during cycle tracking
+type DailyLog struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_daily_logs_user_date" json:"user_id"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_daily_logs_user_date" json:"date"`
+	Mood      string    `gorm:"type:varchar(100)" json:"mood,omitempty"`
+	Symptoms  string    `gorm:"type:text" json:"symptoms,omitempty"`    // comma-separated
+	Flow      string    `gorm:"type:varchar(10)" json:"flow,omitempty"` // "none", "spotting", "light", "medium", "heavy"
+	Notes     string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
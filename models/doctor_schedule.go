@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DoctorAvailability is a weekly window when a doctor takes appointments, in
+// the doctor's own time zone
+type DoctorAvailability struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	DoctorID  uuid.UUID `gorm:"type:uuid;not null;index" json:"doctor_id"`
+	Weekday   int       `gorm:"not null" json:"weekday"`                    // 0 = Sunday
+	StartTime string    `gorm:"type:varchar(5);not null" json:"start_time"` // "09:00" local
+	EndTime   string    `gorm:"type:varchar(5);not null" json:"end_time"`   // "17:00" local
+	TimeZone  string    `gorm:"type:varchar(64);not null" json:"time_zone"` // IANA name, e.g. "Africa/Nairobi"
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DoctorTimeOff blocks a doctor's availability between two instants
+type DoctorTimeOff struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	DoctorID  uuid.UUID `gorm:"type:uuid;not null;index" json:"doctor_id"`
+	StartsAt  time.Time `gorm:"not null" json:"starts_at"`
+	EndsAt    time.Time `gorm:"not null" json:"ends_at"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AppointmentType is a kind of visit a doctor offers and how long it takes
+type AppointmentType struct {
+	ID              uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	DoctorID        uuid.UUID `gorm:"type:uuid;not null;index" json:"doctor_id"`
+	Name            string    `gorm:"type:varchar(100);not null" json:"name"`
+	Description     string    `gorm:"type:text" json:"description,omitempty"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	Active          bool      `gorm:"not null" json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DRSPEntry is one day of the Daily Record of Severity of Problems questionnaire.
+// Ratings holds the 24 item scores (21 symptom items + 3 impairment items), each 1–6.
+type DRSPEntry struct {
+	ID        uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_drsp_entries_user_date" json:"user_id"`
+	Date      time.Time     `gorm:"type:date;not null;uniqueIndex:idx_drsp_entries_user_date" json:"date"`
+	CycleDay  int           `json:"cycle_day,omitempty"` // day of the cycle at the time of rating, 0 if unknown
+	Ratings   pq.Int64Array `gorm:"type:integer[];not null" json:"ratings"`
+	Notes     string        `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EPDS risk levels
+const (
+	EPDSRiskLow      = "low"
+	EPDSRiskPossible = "possible_depression"
+	EPDSRiskProbable = "probable_depression"
+)
+
+// EPDSResponse is one completed Edinburgh Postnatal Depression Scale questionnaire.
+// Answers holds the chosen option (0–3, in the order served) for each of the 10 items.
+type EPDSResponse struct {
+	ID              uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID          uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Answers         pq.Int64Array `gorm:"type:integer[];not null" json:"answers"`
+	Score           int           `gorm:"not null" json:"score"`         // 0–30
+	AnxietyScore    int           `gorm:"not null" json:"anxiety_score"` // items 3–5, 0–9
+	SelfHarmScore   int           `gorm:"not null" json:"self_harm_score"`
+	RiskLevel       string        `gorm:"type:varchar(30);not null" json:"risk_level"`
+	Escalated       bool          `gorm:"default:false" json:"escalated"`
+	WeeksPostpartum *int          `json:"weeks_postpartum,omitempty"`
+	CompletedAt     time.Time     `gorm:"not null;index" json:"completed_at"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
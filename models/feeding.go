@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Feeding session kinds
+const (
+	FeedingBreast = "breast"
+	FeedingBottle = "bottle"
+	FeedingPump   = "pump"
+)
+
+// Breast sides
+const (
+	SideLeft  = "left"
+	SideRight = "right"
+	SideBoth  = "both"
+)
+
+// Where pumped milk is kept
+const (
+	MilkStorageRoom      = "room"
+	MilkStorageFridge    = "fridge"
+	MilkStorageFreezer   = "freezer"
+	MilkStorageUsed      = "used"
+	MilkStorageDiscarded = "discarded"
+)
+
+// FeedingSession is one breastfeed, bottle feed or pumping session. Breast and
+// pump sessions may be timed live: EndedAt stays nil until the timer is stopped.
+type FeedingSession struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index:idx_feeding_sessions_user_started" json:"user_id"`
+	BabyID           *uuid.UUID `gorm:"type:uuid;index" json:"baby_id,omitempty"`
+	Kind             string     `gorm:"type:varchar(10);not null" json:"kind"`  // "breast", "bottle", "pump"
+	Side             string     `gorm:"type:varchar(10)" json:"side,omitempty"` // breast and pump only
+	StartedAt        time.Time  `gorm:"not null;index:idx_feeding_sessions_user_started" json:"started_at"`
+	EndedAt          *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds  int        `json:"duration_seconds"`
+	BottleContent    string     `gorm:"type:varchar(20)" json:"bottle_content,omitempty"` // "breast_milk", "formula"
+	VolumeMl         float64    `json:"volume_ml,omitempty"`                              // bottle volume or pumped output
+	Storage          string     `gorm:"type:varchar(10)" json:"storage,omitempty"`        // pumped milk only
+	StorageExpiresAt *time.Time `json:"storage_expires_at,omitempty"`
+	Notes            string     `gorm:"type:text" json:"notes,omitempty"`
+	ReminderSentAt   *time.Time `json:"-"` // feeding-interval reminder sent after this feed
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// FeedingPreference holds a user's feeding-interval reminder settings
+type FeedingPreference struct {
+	UserID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RemindersEnabled bool      `gorm:"not null" json:"reminders_enabled"`
+	IntervalMinutes  int       `gorm:"not null" json:"interval_minutes"` // time from the start of one feed to the next
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GlucoseTarget holds the glucose ranges a doctor set for a patient, in mg/dL.
+// Readings with meal context bedtime or random are not checked against a target.
+type GlucoseTarget struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	LowMin        float64   `gorm:"not null" json:"low_min"` // below this is hypoglycaemia
+	FastingMax    float64   `gorm:"not null" json:"fasting_max"`
+	PreMealMax    float64   `gorm:"not null" json:"pre_meal_max"`
+	PostMeal1hMax float64   `gorm:"column:post_meal1h_max;not null" json:"post_meal_1h_max"`
+	PostMeal2hMax float64   `gorm:"column:post_meal2h_max;not null" json:"post_meal_2h_max"`
+	ChecksPerDay  int       `gorm:"not null" json:"checks_per_day"` // targeted readings expected each day
+	SetBy         uuid.UUID `gorm:"type:uuid" json:"set_by,omitempty"`
+	Notes         string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import job statuses
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+// ImportJob tracks a bulk import of cycle history from a file
+type ImportJob struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	Format   string `gorm:"type:varchar(20);not null" json:"format"` // "csv" or "json"
+	Layout   string `gorm:"type:varchar(20)" json:"layout"`          // "cycles" or "daily"
+	FileName string `gorm:"type:varchar(255)" json:"file_name,omitempty"`
+	Status   string `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+
+	// The upload and its column mapping, kept until the job finishes so an
+	// import interrupted by a restart can be run again
+	Data    []byte `gorm:"type:bytea" json:"-"`
+	Mapping string `gorm:"type:text" json:"-"`
+
+	TotalRows     int    `json:"total_rows"`
+	ImportedRows  int    `json:"imported_rows"`
+	DuplicateRows int    `json:"duplicate_rows"`
+	ErrorRows     int    `json:"error_rows"`
+	Errors        string `gorm:"type:text" json:"-"` // JSON-encoded per-row errors
+	FailureReason string `gorm:"type:text" json:"failure_reason,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KickSession is a fetal movement counting session
+type KickSession struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	PregnancyID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"pregnancy_id"`
+	StartedAt       time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	KickCount       int        `gorm:"default:0" json:"kick_count"`
+	TargetKicks     int        `gorm:"default:10" json:"target_kicks"`
+	MinutesToTarget *float64   `json:"minutes_to_target,omitempty"` // time taken to reach TargetKicks
+	ReducedMovement bool       `gorm:"default:false" json:"reduced_movement"`
+	Notes           string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+
+	Kicks []KickEvent `gorm:"foreignKey:SessionID" json:"kicks,omitempty"`
+}
+
+// KickEvent is a single movement felt during a session
+type KickEvent struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	SessionID  uuid.UUID `gorm:"type:uuid;not null;index" json:"session_id"`
+	OccurredAt time.Time `gorm:"not null" json:"occurred_at"`
+}
+
+// ContractionSession groups the contractions timed in one sitting
+type ContractionSession struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	PregnancyID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"pregnancy_id"`
+	StartedAt    time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	Rule511MetAt *time.Time `json:"rule_511_met_at,omitempty"` // when the 5-1-1 pattern was first reached
+	Notes        string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Contractions []Contraction `gorm:"foreignKey:SessionID" json:"contractions,omitempty"`
+}
+
+// Contraction is one timed contraction
+type Contraction struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	SessionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"session_id"`
+	StartedAt time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
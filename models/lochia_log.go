@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lochia stages, from fresh red bleeding to the pale discharge of late recovery
+const (
+	LochiaRubra   = "rubra"   // red
+	LochiaSerosa  = "serosa"  // pink or brown
+	LochiaAlba    = "alba"    // yellow or white
+	LochiaStopped = "stopped" // no discharge
+)
+
+// LochiaLog is a daily record of post-birth bleeding and discharge
+type LochiaLog struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lochia_logs_user_date" json:"user_id"`
+	PregnancyID uuid.UUID `gorm:"type:uuid;not null;index" json:"pregnancy_id"`
+	Date        time.Time `gorm:"type:date;not null;uniqueIndex:idx_lochia_logs_user_date" json:"date"`
+	Stage       string    `gorm:"type:varchar(10);not null" json:"stage"`
+	Flow        string    `gorm:"type:varchar(10)" json:"flow,omitempty"` // "heavy", "moderate", "light", "spotting"
+	LargeClots  bool      `json:"large_clots"`
+	FoulOdor    bool      `json:"foul_odor"`
+	Notes       string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Medication kinds, covering contraception methods and general medications
+const (
+	MedicationKindPill      = "pill"
+	MedicationKindPatch     = "patch"
+	MedicationKindRing      = "ring"
+	MedicationKindIUD       = "iud"
+	MedicationKindImplant   = "implant"
+	MedicationKindInjection = "injection"
+	MedicationKindOther     = "other"
+)
+
+// Dose frequencies. "daily_21_7" is a daily pill with a 7-day pill-free break
+// after every 21 days. "as_needed" and "continuous" (IUD, implant) have no
+// scheduled doses.
+const (
+	DoseFrequencyDaily      = "daily"
+	DoseFrequencyDaily21Of7 = "daily_21_7"
+	DoseFrequencyWeekly     = "weekly"
+	DoseFrequencyMonthly    = "monthly"
+	DoseFrequencyAsNeeded   = "as_needed"
+	DoseFrequencyContinuous = "continuous"
+)
+
+// Dose log statuses
+const (
+	DoseStatusTaken   = "taken"
+	DoseStatusMissed  = "missed"
+	DoseStatusSkipped = "skipped"
+)
+
+// Medication is a contraceptive method or medication a user is taking
+type Medication struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	Name            string `gorm:"type:varchar(255);not null" json:"name"`
+	Kind            string `gorm:"type:varchar(20);not null" json:"kind"` // "pill", "patch", "ring", "iud", "implant", "injection", "other"
+	IsContraceptive bool   `gorm:"default:false" json:"is_contraceptive"`
+	IsHormonal      bool   `gorm:"default:false" json:"is_hormonal"`
+	Dosage          string `gorm:"type:varchar(100)" json:"dosage,omitempty"`
+
+	Frequency string     `gorm:"type:varchar(20);not null" json:"frequency"` // "daily", "daily_21_7", "weekly", "monthly", "as_needed", "continuous"
+	DoseTime  string     `gorm:"type:varchar(5)" json:"dose_time,omitempty"` // "HH:MM" (UTC)
+	StartDate time.Time  `gorm:"not null" json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+
+	Active           bool   `gorm:"default:true" json:"active"`
+	RemindersEnabled bool   `gorm:"default:true" json:"reminders_enabled"`
+	Notes            string `gorm:"type:text" json:"notes,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DoseLog records whether a scheduled (or as-needed) dose was taken
+type DoseLog struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MedicationID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_dose_logs_medication_scheduled" json:"medication_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ScheduledFor time.Time  `gorm:"not null;uniqueIndex:idx_dose_logs_medication_scheduled" json:"scheduled_for"`
+	TakenAt      *time.Time `json:"taken_at,omitempty"`
+	Status       string     `gorm:"type:varchar(10);not null" json:"status"` // "taken", "missed", "skipped"
+	Notes        string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
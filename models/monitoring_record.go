@@ -1,19 +1,94 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Monitoring field types
+const (
+	MonitoringFieldNumber  = "number"
+	MonitoringFieldInteger = "integer"
+	MonitoringFieldBoolean = "boolean"
+	MonitoringFieldText    = "text"
+	MonitoringFieldEnum    = "enum"
+	MonitoringFieldDate    = "date" // YYYY-MM-DD
+)
+
+// Built-in monitoring types
+const (
+	MonitoringTypePregnancy  = "pregnancy"
+	MonitoringTypePostpartum = "postpartum"
+)
+
+// MonitoringField describes one entry of a monitoring record
+type MonitoringField struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	Unit      string   `json:"unit,omitempty"`
+	Min       *float64 `json:"min,omitempty"`        // number and integer
+	Max       *float64 `json:"max,omitempty"`        // number and integer
+	MaxLength int      `json:"max_length,omitempty"` // text
+	Options   []string `json:"options,omitempty"`    // enum
+}
+
+// MonitoringFields is stored as a JSON column
+type MonitoringFields []MonitoringField
+
+// Value implements driver.Valuer
+func (f MonitoringFields) Value() (driver.Value, error) {
+	if f == nil {
+		f = MonitoringFields{}
+	}
+	b, err := json.Marshal(f)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (f *MonitoringFields) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case nil:
+		*f = nil
+		return nil
+	}
+	return errors.New("unsupported monitoring fields value")
+}
+
+// MonitoringTemplate is the admin-defined set of fields for a monitoring type.
+// Built-in types fall back to a default template until an admin saves one.
+type MonitoringTemplate struct {
+	ID          uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Type        string           `gorm:"type:varchar(50);not null;uniqueIndex" json:"type"`
+	Name        string           `gorm:"not null" json:"name"`
+	Description string           `gorm:"type:text" json:"description,omitempty"`
+	Fields      MonitoringFields `gorm:"type:jsonb;not null" json:"fields"`
+	Version     int              `gorm:"not null" json:"version"` // bumped whenever the fields change
+	UpdatedBy   *uuid.UUID       `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// MonitoringRecord holds encrypted field data validated against the template for its type
 type MonitoringRecord struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null"`
-	StartDate time.Time `gorm:"not null"`
-	EndDate   *time.Time
-	Type      string `gorm:"not null"`  // "pregnancy" or "postpartum"
-	Data      string `gorm:"type:text"` // JSON-encoded dynamic field data
-	Notes     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index:idx_monitoring_records_user_type" json:"user_id"`
+	PregnancyID     *uuid.UUID `gorm:"type:uuid;index" json:"pregnancy_id,omitempty"` // the pregnancy the record belongs to
+	StartDate       time.Time  `gorm:"not null" json:"start_date"`
+	EndDate         *time.Time `json:"end_date,omitempty"`
+	Type            string     `gorm:"not null;index:idx_monitoring_records_user_type" json:"type"` // template type, e.g. "pregnancy" or "postpartum"
+	TemplateVersion int        `json:"template_version"`
+	Data            string     `gorm:"type:text" json:"-"` // encrypted JSON-encoded field data
+	Notes           string     `json:"-"`                  // encrypted
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
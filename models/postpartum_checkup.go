@@ -20,6 +20,11 @@ type PostpartumCheckup struct {
 	MentalHealth      string    `gorm:"type:text" json:"mental_health,omitempty"`
 	NextCheckupAt     time.Time `json:"next_checkup_at,omitempty"`
 
+	// Set by the server: the appointment this visit was recorded from, and the
+	// follow-up appointment proposed for NextCheckupAt
+	AppointmentID         *uuid.UUID `gorm:"type:uuid;index" json:"appointment_id,omitempty"`
+	FollowUpAppointmentID *uuid.UUID `gorm:"type:uuid;index" json:"follow_up_appointment_id,omitempty"`
+
 	// File attachments (e.g. prescriptions, scans, reports)
 	Attachments []PostpartumCheckupFile `gorm:"foreignKey:CheckupID;constraint:OnDelete:CASCADE" json:"attachments,omitempty"`
 
@@ -4,16 +4,114 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// Pregnancy statuses. A pregnancy starts active and moves to exactly one outcome.
+const (
+	PregnancyStatusActive     = "active"
+	PregnancyStatusDelivered  = "delivered"
+	PregnancyStatusMiscarried = "miscarried"
+	PregnancyStatusEctopic    = "ectopic"
+	PregnancyStatusTerminated = "terminated"
+	PregnancyStatusStillbirth = "stillbirth"
+)
+
+// Delivery modes
+const (
+	DeliveryModeVaginal         = "vaginal"
+	DeliveryModeAssistedVaginal = "assisted_vaginal"
+	DeliveryModeCesarean        = "cesarean"
+)
+
+// IsPregnancyLoss reports whether an outcome status is a pregnancy loss
+func IsPregnancyLoss(status string) bool {
+	switch status {
+	case PregnancyStatusMiscarried, PregnancyStatusEctopic, PregnancyStatusTerminated, PregnancyStatusStillbirth:
+		return true
+	}
+	return false
+}
+
+// How the due date was established
+const (
+	DatingMethodLMP        = "lmp"
+	DatingMethodConception = "conception"
+	DatingMethodIVF        = "ivf"
+	DatingMethodUltrasound = "ultrasound"
+)
+
+// PregnancyLengthDays is a full-term pregnancy measured from the last menstrual period
+const PregnancyLengthDays = 280
+
 type Pregnancy struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	StartDate   time.Time `gorm:"not null" json:"start_date"`
-	DueDate     time.Time `json:"due_date"` // calculated (StartDate + 40 weeks)
-	CurrentWeek int       `json:"current_week"`
-	Status      string    `gorm:"default:'active'" json:"status"` // "active", "completed", "miscarried"
-	Notes       string    `json:"notes,omitempty"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	StartDate time.Time `gorm:"not null" json:"start_date"` // LMP, or the equivalent implied by the dating method
+	DueDate   time.Time `json:"due_date"`
+
+	DatingMethod string     `gorm:"type:varchar(20);default:'lmp'" json:"dating_method"` // "lmp", "conception", "ivf", "ultrasound"
+	DatingDate   *time.Time `json:"dating_date,omitempty"`                               // conception, transfer or scan date
+	// IVF: embryo age in days at transfer (3 or 5); ultrasound: gestational age in days at the scan
+	DatingAgeDays int `json:"dating_age_days,omitempty"`
+
+	// Baseline for weight-gain guidance (IOM ranges by pre-pregnancy BMI)
+	PrePregnancyWeightKg *float64 `json:"pre_pregnancy_weight_kg,omitempty"`
+	HeightCm             *float64 `json:"height_cm,omitempty"`
+
+	Status  string     `gorm:"default:'active'" json:"status"` // "active", "delivered", "miscarried", "ectopic", "terminated", "stillbirth"
+	EndDate *time.Time `json:"end_date,omitempty"`             // outcome date: delivery or loss
+
+	// Outcome details, set when the pregnancy leaves the active state
+	DeliveryMode              string `gorm:"type:varchar(20)" json:"delivery_mode,omitempty"` // "vaginal", "assisted_vaginal", "cesarean"
+	GestationalWeeksAtOutcome int    `json:"gestational_weeks_at_outcome,omitempty"`
+	GestationalDaysAtOutcome  int    `json:"gestational_days_at_outcome,omitempty"`
+	OutcomeNotes              string `gorm:"type:text" json:"outcome_notes,omitempty"`
+
+	Notes     string `json:"notes,omitempty"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Computed on read from StartDate, never stored
+	CurrentWeek      int `gorm:"-" json:"current_week"`
+	GestationalDays  int `gorm:"-" json:"gestational_days"` // days past the completed week
+	Trimester        int `gorm:"-" json:"trimester"`
+	DaysUntilDueDate int `gorm:"-" json:"days_until_due_date"`
+}
+
+// ComputeGestationalAge fills the computed timeline fields as of the given time.
+// Ended pregnancies are measured at their end date.
+func (p *Pregnancy) ComputeGestationalAge(now time.Time) {
+	if p.EndDate != nil {
+		now = *p.EndDate
+	}
+	days := int(now.Sub(p.StartDate).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+
+	p.CurrentWeek = days / 7
+	p.GestationalDays = days % 7
+	p.Trimester = TrimesterForWeek(p.CurrentWeek)
+	p.DaysUntilDueDate = int(p.DueDate.Sub(now).Hours() / 24)
+}
+
+// AfterFind keeps gestational age current on every read
+func (p *Pregnancy) AfterFind(tx *gorm.DB) (err error) {
+	p.ComputeGestationalAge(time.Now())
+	return
+}
+
+// DueDateHistory records every change to a pregnancy's due date
+type DueDateHistory struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PregnancyID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"pregnancy_id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	PreviousDueDate *time.Time `json:"previous_due_date,omitempty"`
+	DueDate         time.Time  `gorm:"not null" json:"due_date"`
+	DatingMethod    string     `gorm:"type:varchar(20);not null" json:"dating_method"`
+	DatingDate      *time.Time `json:"dating_date,omitempty"`
+	DatingAgeDays   int        `json:"dating_age_days,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
@@ -19,6 +19,11 @@ type PregnancyCheckup struct {
 	BloodPressure string    `gorm:"type:varchar(20)" json:"blood_pressure,omitempty"`
 	NextCheckupAt time.Time `json:"next_checkup_at,omitempty"`
 
+	// Set by the server: the appointment this visit was recorded from, and the
+	// follow-up appointment proposed for NextCheckupAt
+	AppointmentID         *uuid.UUID `gorm:"type:uuid;index" json:"appointment_id,omitempty"`
+	FollowUpAppointmentID *uuid.UUID `gorm:"type:uuid;index" json:"follow_up_appointment_id,omitempty"`
+
 	// ✅ Relation to attachments
 	Attachments []PregnancyCheckupFile `gorm:"foreignKey:CheckupID;constraint:OnDelete:CASCADE" json:"attachments,omitempty"`
 
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Gestational weeks covered by the content library
+const (
+	MinContentWeek = 1
+	MaxContentWeek = 42
+)
+
+// PregnancyWeekContent is the admin-managed guide for one week of pregnancy
+type PregnancyWeekContent struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Week             int       `gorm:"not null;uniqueIndex" json:"week"`
+	Trimester        int       `gorm:"not null;index" json:"trimester"` // derived from Week
+	Title            string    `gorm:"not null" json:"title"`
+	BabyDevelopment  string    `gorm:"type:text" json:"baby_development"`
+	WhatToExpect     string    `gorm:"type:text" json:"what_to_expect"`
+	RecommendedTests string    `gorm:"type:text" json:"recommended_tests,omitempty"` // comma-separated
+	Tips             string    `gorm:"type:text" json:"tips,omitempty"`
+	Published        bool      `gorm:"default:false" json:"published"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PregnancyContentDelivery records that a week's content was sent for a pregnancy
+type PregnancyContentDelivery struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PregnancyID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_pregnancy_content_deliveries_week" json:"pregnancy_id"`
+	Week        int       `gorm:"not null;uniqueIndex:idx_pregnancy_content_deliveries_week" json:"week"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ContentID   uuid.UUID `gorm:"type:uuid;not null" json:"content_id"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// TrimesterForWeek returns the trimester of a completed gestational week
+func TrimesterForWeek(week int) int {
+	switch {
+	case week < 14:
+		return 1
+	case week < 28:
+		return 2
+	default:
+		return 3
+	}
+}
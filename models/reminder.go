@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Reminder types a user can configure
+const (
+	ReminderTypeAppointment = "appointment"
+	ReminderTypeCheckup     = "checkup"
+)
+
+// What a reminder job points at
+const (
+	ReminderSourceAppointment       = "appointment"
+	ReminderSourcePregnancyCheckup  = "pregnancy_checkup"
+	ReminderSourcePostpartumCheckup = "postpartum_checkup"
+)
+
+// Reminder job states
+const (
+	ReminderPending   = "pending"
+	ReminderSent      = "sent"
+	ReminderSkipped   = "skipped"   // turned off, superseded by a shorter lead, or too late
+	ReminderCancelled = "cancelled" // the event was cancelled or moved
+)
+
+// ReminderJob is one reminder to send at a lead time before an event. Jobs are
+// stored so reminders survive restarts and are never sent twice.
+type ReminderJob struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_reminder_jobs_source_lead" json:"user_id"`
+	SourceType  string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_reminder_jobs_source_lead" json:"source_type"`
+	SourceID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_reminder_jobs_source_lead" json:"source_id"`
+	LeadMinutes int        `gorm:"not null;uniqueIndex:idx_reminder_jobs_source_lead" json:"lead_minutes"`
+	EventAt     time.Time  `gorm:"not null" json:"event_at"`
+	DueAt       time.Time  `gorm:"not null;index:idx_reminder_jobs_status_due" json:"due_at"`
+	Status      string     `gorm:"type:varchar(20);not null;index:idx_reminder_jobs_status_due" json:"status"`
+	Note        string     `gorm:"type:text" json:"note,omitempty"` // why a job was skipped or cancelled
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ReminderPreference holds a user's settings for one reminder type
+type ReminderPreference struct {
+	UserID       uuid.UUID     `gorm:"type:uuid;primaryKey" json:"user_id"`
+	ReminderType string        `gorm:"type:varchar(20);primaryKey" json:"reminder_type"`
+	Enabled      bool          `gorm:"not null" json:"enabled"`
+	LeadMinutes  pq.Int64Array `gorm:"type:integer[];not null" json:"lead_minutes"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
@@ -14,6 +14,13 @@ const (
 	RoleAdmin  = "admin"
 )
 
+// Tracking modes decide which part of the app drives a user's predictions
+const (
+	TrackingModeCycle      = "cycle"
+	TrackingModePregnancy  = "pregnancy"
+	TrackingModePostpartum = "postpartum"
+)
+
 type User struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Username  string    `gorm:"uniqueIndex;not null" json:"username"`
@@ -26,6 +33,9 @@ type User struct {
 
 	Verified bool `gorm:"default:false" json:"verified"` // ✅ NEW: true if doctor is verified
 	Banned   bool `gorm:"default:false"`
+
+	TrackingMode string `gorm:"type:varchar(20);default:cycle" json:"tracking_mode"` // "cycle", "pregnancy", "postpartum"
+	TimeZone     string `gorm:"type:varchar(64)" json:"time_zone,omitempty"`         // IANA zone for day/night rules, e.g. "Africa/Nairobi"; UTC when empty
 }
 
 // Block represents a user blocking or muting another user
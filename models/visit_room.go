@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Visit room statuses
+const (
+	VisitRoomOpen   = "open"
+	VisitRoomClosed = "closed"
+)
+
+// Visit event actions
+const (
+	VisitEventJoined = "joined"
+	VisitEventLeft   = "left"
+)
+
+// VisitRoom is the video room for a virtual appointment. It only admits
+// participants between OpensAt and ClosesAt; an appointment has at most one
+// open room at a time.
+type VisitRoom struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	AppointmentID  uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_visit_rooms_open_appointment,where:status = 'open'" json:"appointment_id"`
+	Provider       string     `gorm:"type:varchar(30);not null" json:"provider"`
+	ProviderRoomID string     `gorm:"type:varchar(255);not null" json:"provider_room_id"`
+	OpensAt        time.Time  `gorm:"not null" json:"opens_at"`
+	ClosesAt       time.Time  `gorm:"not null;index" json:"closes_at"`
+	Status         string     `gorm:"type:varchar(10);not null" json:"status"` // open or closed
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// VisitEvent records a participant joining or leaving a visit room
+type VisitEvent struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	RoomID        uuid.UUID `gorm:"type:uuid;not null;index" json:"room_id"`
+	AppointmentID uuid.UUID `gorm:"type:uuid;not null;index" json:"appointment_id"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Role          string    `gorm:"type:varchar(10);not null" json:"role"` // "patient" or "doctor"
+	Action        string    `gorm:"type:varchar(10);not null" json:"action"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vital reading types
+const (
+	VitalBloodPressure = "blood_pressure"
+	VitalWeight        = "weight"
+	VitalGlucose       = "glucose"
+	VitalHeartRate     = "heart_rate"
+	VitalTemperature   = "temperature"
+)
+
+// VitalUnits is the unit every reading of a type is stored in
+var VitalUnits = map[string]string{
+	VitalBloodPressure: "mmHg",
+	VitalWeight:        "kg",
+	VitalGlucose:       "mg/dL",
+	VitalHeartRate:     "bpm",
+	VitalTemperature:   "°C",
+}
+
+// Where a vital reading came from
+const (
+	VitalSourceManual = "manual"
+	VitalSourceDevice = "device"
+)
+
+// Meal context of a glucose reading
+const (
+	MealContextFasting   = "fasting"
+	MealContextPreMeal   = "pre_meal"
+	MealContextPostMeal1 = "post_meal_1h"
+	MealContextPostMeal2 = "post_meal_2h"
+	MealContextBedtime   = "bedtime"
+	MealContextRandom    = "random"
+)
+
+// VitalReading is a measurement the user took at home, outside a clinic checkup.
+// Blood pressure uses Systolic/Diastolic; every other type uses Value.
+type VitalReading struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index:idx_vital_readings_user_type_time" json:"user_id"`
+	Type        string    `gorm:"type:varchar(20);not null;index:idx_vital_readings_user_type_time" json:"type"`
+	Systolic    *int      `json:"systolic,omitempty"`
+	Diastolic   *int      `json:"diastolic,omitempty"`
+	Value       *float64  `json:"value,omitempty"`
+	Unit        string    `gorm:"type:varchar(10)" json:"unit"`
+	MealContext string    `gorm:"type:varchar(20)" json:"meal_context,omitempty"` // glucose only
+	Source      string    `gorm:"type:varchar(20);default:manual" json:"source"`  // "manual", "device"
+	Device      string    `gorm:"type:varchar(100)" json:"device,omitempty"`      // e.g. "Omron M7"
+	MeasuredAt  time.Time `gorm:"not null;index:idx_vital_readings_user_type_time" json:"measured_at"`
+	Notes       string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -3,12 +3,32 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
 )
 
-func RegisterAppointmentRoutes(router *gin.Engine) {
-	appointments := router.Group("/appointments")
+// RegisterAppointmentRoutes registers appointment booking and lifecycle routes
+func RegisterAppointmentRoutes(api *gin.RouterGroup) {
+	appointments := api.Group("/appointments")
+	appointments.Use(middleware.AuthMiddleware())
 	{
 		appointments.POST("/", controllers.CreateAppointment)
+		appointments.GET("/", controllers.GetMyAppointments)
 		appointments.GET("/user/:id", controllers.GetAppointmentsForUser)
+		appointments.GET("/doctor", middleware.DoctorMiddleware(), controllers.GetDoctorAppointments)
+		appointments.GET("/:id", controllers.GetAppointment)
+
+		// Either party
+		appointments.POST("/:id/reschedule", controllers.RescheduleAppointment)
+		appointments.POST("/:id/cancel", controllers.CancelAppointment)
+		appointments.POST("/:id/confirm", controllers.ConfirmAppointment) // whoever did not set the time
+
+		// Video visits
+		appointments.GET("/:id/visit", controllers.GetVisit)
+		appointments.POST("/:id/visit/join", controllers.JoinVisit)
+		appointments.POST("/:id/visit/leave", controllers.LeaveVisit)
+
+		// Doctor side
+		appointments.POST("/:id/complete", middleware.DoctorMiddleware(), controllers.CompleteAppointment)
+		appointments.POST("/:id/no-show", middleware.DoctorMiddleware(), controllers.MarkAppointmentNoShow)
 	}
 }
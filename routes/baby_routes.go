@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterBabyRoutes registers baby records, growth, care log and vaccination routes
+func RegisterBabyRoutes(api *gin.RouterGroup) {
+	babies := api.Group("/babies")
+	babies.Use(middleware.AuthMiddleware())
+
+	babies.POST("/from-pregnancy/:pregnancy_id", controllers.CreateBabiesFromPregnancy)
+	babies.GET("", controllers.GetBabies)
+	babies.GET("/:id", controllers.GetBaby)
+	babies.PUT("/:id", controllers.UpdateBaby)
+
+	babies.POST("/:id/growth", controllers.AddGrowthMeasurement)
+	babies.GET("/:id/growth", controllers.GetGrowthChart)
+
+	babies.POST("/:id/care-logs", controllers.AddCareLog)
+	babies.GET("/:id/care-logs", controllers.GetCareLogs)
+	babies.DELETE("/:id/care-logs/:log_id", controllers.DeleteCareLog)
+
+	babies.GET("/:id/vaccinations", controllers.GetVaccinations)
+	babies.POST("/:id/vaccinations", controllers.AddVaccination)
+	babies.PUT("/:id/vaccinations/:vaccination_id", controllers.UpdateVaccination)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterCalendarFeedRoutes sets up the ICS subscription endpoints
+func RegisterCalendarFeedRoutes(api *gin.RouterGroup) {
+	calendar := api.Group("/calendar")
+
+	// Public: calendar apps cannot send a bearer token, so the secret token in the URL is the credential
+	calendar.GET("/feed/:token", controllers.ServeCalendarFeed)
+
+	// Protected: manage the subscription URL
+	protected := calendar.Group("")
+	protected.Use(middleware.AuthMiddleware())
+	{
+		protected.GET("/feed", controllers.GetCalendarFeed)
+		protected.POST("/feed/rotate", controllers.RotateCalendarFeed)
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterClinicalAlertRoutes sets up clinical alert and care team consent routes
+func RegisterClinicalAlertRoutes(api *gin.RouterGroup) {
+	alerts := api.Group("/clinical-alerts")
+	alerts.Use(middleware.AuthMiddleware())
+	{
+		alerts.GET("", controllers.GetClinicalAlerts)
+		alerts.PUT("/:id/acknowledge", controllers.AcknowledgeClinicalAlert)
+		alerts.GET("/patients", middleware.DoctorMiddleware(), controllers.GetPatientClinicalAlerts)
+	}
+
+	careTeam := api.Group("/care-team")
+	careTeam.Use(middleware.AuthMiddleware())
+	{
+		careTeam.POST("", controllers.GrantCareTeamAccess)
+		careTeam.GET("", controllers.GetCareTeam)
+		careTeam.DELETE("/:doctor_id", controllers.RevokeCareTeamAccess)
+		careTeam.GET("/patients", middleware.DoctorMiddleware(), controllers.GetCareTeamPatients)
+	}
+}
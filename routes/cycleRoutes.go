@@ -17,4 +17,21 @@ func RegisterCycleRoutes(rg *gin.RouterGroup) {
 	cycle.POST("/", controllers.AddCycle)
 	cycle.PUT("/:id", controllers.UpdateCycle)
 	cycle.DELETE("/:id", controllers.DeleteCycle)
+
+	// Day-by-day phase calendar
+	cycle.GET("/calendar", controllers.GetCycleCalendar)
+
+	// Day-level mood, symptom & flow logs
+	cycle.GET("/daily-logs", controllers.GetDailyLogs)
+	cycle.POST("/daily-logs", controllers.UpsertDailyLog)
+	cycle.DELETE("/daily-logs/:id", controllers.DeleteDailyLog)
+
+	// Bulk import of cycle history
+	cycle.POST("/import", controllers.ImportCycles)
+	cycle.GET("/import", controllers.GetImportJobs)
+	cycle.GET("/import/:id", controllers.GetImportJob)
+
+	// Late period detection and pregnancy transition
+	cycle.GET("/late-check", controllers.GetLatePeriodStatus)
+	cycle.POST("/confirm-pregnancy", controllers.ConfirmPregnancy)
 }
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterDoctorScheduleRoutes registers doctor availability, time off, appointment types and slot search
+func RegisterDoctorScheduleRoutes(api *gin.RouterGroup) {
+	doctors := api.Group("/doctors")
+	doctors.Use(middleware.AuthMiddleware())
+
+	// The calling doctor's own schedule
+	me := doctors.Group("/me")
+	me.Use(middleware.DoctorMiddleware())
+	{
+		me.GET("/availability", controllers.GetMyAvailability)
+		me.PUT("/availability", controllers.SetMyAvailability)
+		me.GET("/time-off", controllers.GetMyTimeOff)
+		me.POST("/time-off", controllers.AddMyTimeOff)
+		me.DELETE("/time-off/:id", controllers.DeleteMyTimeOff)
+		me.GET("/appointment-types", controllers.GetMyAppointmentTypes)
+		me.POST("/appointment-types", controllers.CreateAppointmentType)
+		me.PUT("/appointment-types/:id", controllers.UpdateAppointmentType)
+	}
+
+	// Patient-facing booking information
+	doctors.GET("/:id/appointment-types", controllers.GetDoctorAppointmentTypes)
+	doctors.GET("/:id/slots", controllers.GetDoctorSlots)
+}
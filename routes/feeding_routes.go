@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterFeedingRoutes sets up breastfeeding, bottle and pumping session routes
+func RegisterFeedingRoutes(api *gin.RouterGroup) {
+	feedings := api.Group("/feedings")
+	feedings.Use(middleware.AuthMiddleware())
+	{
+		feedings.POST("", controllers.LogFeeding)
+		feedings.GET("", controllers.GetFeedings)
+		feedings.PUT("/:id/stop", controllers.StopFeeding)
+		feedings.PUT("/:id/storage", controllers.UpdateMilkStorage)
+		feedings.DELETE("/:id", controllers.DeleteFeeding)
+		feedings.GET("/summary", controllers.GetFeedingSummary)
+		feedings.GET("/next-side", controllers.GetNextFeedingSide)
+		feedings.GET("/stash", controllers.GetMilkStash)
+		feedings.GET("/preferences", controllers.GetFeedingPreferences)
+		feedings.PUT("/preferences", controllers.UpdateFeedingPreferences)
+	}
+}
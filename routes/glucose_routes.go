@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterGlucoseRoutes sets up gestational diabetes glucose targets and reports.
+// Readings themselves are logged through /vitals with type "glucose".
+func RegisterGlucoseRoutes(api *gin.RouterGroup) {
+	glucose := api.Group("/glucose")
+	glucose.Use(middleware.AuthMiddleware())
+	{
+		glucose.GET("/targets", controllers.GetGlucoseTarget)
+		glucose.GET("/summary", controllers.GetGlucoseSummary)
+		glucose.GET("/report.csv", controllers.ExportGlucoseReportCSV)
+	}
+
+	patients := glucose.Group("/patients/:patient_id")
+	patients.Use(middleware.DoctorMiddleware())
+	{
+		patients.PUT("/targets", controllers.SetPatientGlucoseTarget)
+		patients.GET("/summary", controllers.GetPatientGlucoseSummary)
+		patients.GET("/report.csv", controllers.ExportPatientGlucoseReportCSV)
+	}
+}
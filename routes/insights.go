@@ -11,4 +11,5 @@ func RegisterInsightsRoutes(rg *gin.RouterGroup) {
 	insight.Use(middleware.AuthMiddleware())
 
 	insight.GET("/cycle", controllers.GetCycleInsights)
+	insight.GET("/correlations", controllers.GetCorrelationInsights)
 }
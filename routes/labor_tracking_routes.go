@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterLaborTrackingRoutes registers the kick counter and contraction timer
+func RegisterLaborTrackingRoutes(api *gin.RouterGroup) {
+	kicks := api.Group("/kicks")
+	kicks.Use(middleware.AuthMiddleware())
+	{
+		kicks.GET("/baseline", controllers.GetKickBaseline)
+		kicks.POST("/sessions", controllers.StartKickSession)
+		kicks.GET("/sessions", controllers.GetKickSessions)
+		kicks.GET("/sessions/:id", controllers.GetKickSession)
+		kicks.POST("/sessions/:id/kick", controllers.RecordKick)
+		kicks.POST("/sessions/:id/end", controllers.EndKickSession)
+	}
+
+	contractions := api.Group("/contractions")
+	contractions.Use(middleware.AuthMiddleware())
+	{
+		contractions.POST("/sessions", controllers.StartContractionSession)
+		contractions.GET("/sessions", controllers.GetContractionSessions)
+		contractions.GET("/sessions/:id", controllers.GetContractionSession)
+		contractions.POST("/sessions/:id/start", controllers.StartContraction)
+		contractions.POST("/sessions/:id/stop", controllers.StopContraction)
+		contractions.POST("/sessions/:id/end", controllers.EndContractionSession)
+	}
+}
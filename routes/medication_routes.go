@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterMedicationRoutes sets up contraception & medication tracking endpoints
+func RegisterMedicationRoutes(api *gin.RouterGroup) {
+	medications := api.Group("/medications")
+	medications.Use(middleware.AuthMiddleware())
+
+	medications.GET("", controllers.GetMedications)
+	medications.POST("", controllers.CreateMedication)
+	medications.PUT("/:id", controllers.UpdateMedication)
+	medications.DELETE("/:id", controllers.DeleteMedication)
+
+	// Dose logging & adherence
+	medications.POST("/:id/doses", controllers.LogDose)
+	medications.GET("/:id/doses", controllers.GetDoseLogs)
+	medications.GET("/:id/adherence", controllers.GetMedicationAdherence)
+}
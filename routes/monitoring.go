@@ -6,10 +6,18 @@ import (
 	"github.com/shem958/cycle-backend/middleware"
 )
 
+// RegisterMonitoringRoutes registers monitoring record and template routes
 func RegisterMonitoringRoutes(rg *gin.RouterGroup) {
 	monitoring := rg.Group("/monitoring")
 	monitoring.Use(middleware.AuthMiddleware())
 
+	// Field templates, used to render forms
+	monitoring.GET("/templates", controllers.GetMonitoringTemplates)
+	monitoring.GET("/templates/:type", controllers.GetMonitoringTemplate)
+
 	monitoring.POST("/", controllers.CreateMonitoringRecord)
 	monitoring.GET("/", controllers.GetUserMonitoringRecords)
+	monitoring.GET("/:id", controllers.GetMonitoringRecord)
+	monitoring.PUT("/:id", controllers.UpdateMonitoringRecord)
+	monitoring.DELETE("/:id", controllers.DeleteMonitoringRecord)
 }
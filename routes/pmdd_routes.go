@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterPMDDRoutes registers PMS/PMDD daily rating and screening routes
+func RegisterPMDDRoutes(api *gin.RouterGroup) {
+	pmdd := api.Group("/pmdd")
+	pmdd.Use(middleware.AuthMiddleware())
+
+	pmdd.GET("/questionnaire", controllers.GetDRSPQuestionnaire)
+	pmdd.POST("/entries", controllers.SaveDRSPEntry)
+	pmdd.GET("/entries", controllers.GetDRSPEntries)
+	pmdd.GET("/screening", controllers.GetPMDDScreening)
+	pmdd.GET("/summary", controllers.GetPMDDSummary) // printable HTML
+}
@@ -18,6 +18,19 @@ func RegisterPostpartumRoutes(api *gin.RouterGroup) {
 	postpartum.POST("/", controllers.CreatePostpartumLog)
 	postpartum.GET("/logs/:id", controllers.GetPostpartumLogs)
 
+	// EPDS depression screening
+	epds := postpartum.Group("/epds")
+	{
+		epds.GET("/questionnaire", controllers.GetEPDSQuestionnaire)
+		epds.POST("", controllers.SubmitEPDS)
+		epds.GET("/history", controllers.GetEPDSHistory)
+	}
+
+	// Lochia and return of fertility
+	postpartum.POST("/lochia", controllers.LogLochia)
+	postpartum.GET("/lochia", controllers.GetLochiaLogs)
+	postpartum.GET("/fertility", controllers.GetFertilityStatus)
+
 	// Postpartum checkups
 	checkups := postpartum.Group("/checkups")
 	{
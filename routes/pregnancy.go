@@ -3,14 +3,20 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
 )
 
-func RegisterPregnancyRoutes(router *gin.Engine) {
-	pregnancy := router.Group("/pregnancy")
+func RegisterPregnancyRoutes(rg *gin.RouterGroup) {
+	pregnancy := rg.Group("/pregnancy")
+	pregnancy.Use(middleware.AuthMiddleware())
 	{
 		pregnancy.POST("/", controllers.CreatePregnancy)
 		pregnancy.GET("/user/:user_id", controllers.GetPregnanciesByUser)
-
+		pregnancy.PUT("/:id/outcome", controllers.RecordPregnancyOutcome)
+		pregnancy.PUT("/:id/dating", controllers.UpdatePregnancyDating)
+		pregnancy.GET("/:id/due-date-history", controllers.GetDueDateHistory)
+		pregnancy.GET("/:id/timeline", controllers.GetPregnancyTimeline)
+		pregnancy.PUT("/:id/baseline", controllers.UpdatePregnancyBaseline)
 		pregnancy.POST("/symptom", controllers.LogSymptom)
 		pregnancy.GET("/symptom/:pregnancy_id", controllers.GetSymptoms)
 	}
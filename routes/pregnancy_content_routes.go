@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterPregnancyContentRoutes registers the week-by-week pregnancy guide
+func RegisterPregnancyContentRoutes(api *gin.RouterGroup) {
+	content := api.Group("/pregnancy-content")
+	content.Use(middleware.AuthMiddleware())
+
+	content.GET("/current", controllers.GetCurrentPregnancyContent)
+	content.GET("/week/:week", controllers.GetPregnancyWeekContent)
+
+	// Content library management (admin only)
+	adminRoutes := content.Group("")
+	adminRoutes.Use(middleware.AdminMiddleware())
+	{
+		adminRoutes.GET("", controllers.ListPregnancyContent)
+		adminRoutes.POST("", controllers.CreatePregnancyContent)
+		adminRoutes.PUT("/:id", controllers.UpdatePregnancyContent)
+		adminRoutes.DELETE("/:id", controllers.DeletePregnancyContent)
+	}
+}
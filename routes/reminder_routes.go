@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterReminderRoutes registers appointment and checkup reminder routes
+func RegisterReminderRoutes(api *gin.RouterGroup) {
+	reminders := api.Group("/reminders")
+	reminders.Use(middleware.AuthMiddleware())
+	{
+		reminders.GET("", controllers.GetUpcomingReminders)
+		reminders.GET("/preferences", controllers.GetReminderPreferences)
+		reminders.PUT("/preferences/:type", controllers.UpdateReminderPreference)
+	}
+}
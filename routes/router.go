@@ -33,6 +33,7 @@ func SetupRouter() *gin.Engine {
 	RegisterProfileRoutes(api)
 	RegisterModerationRoutes(api) // if applicable
 	RegisterInsightsRoutes(api)
+	RegisterPregnancyRoutes(api)
 
 	// ✅ Pregnancy Checkup Routes
 	RegisterPregnancyCheckupRoutes(api, config.DB)
@@ -40,6 +41,20 @@ func SetupRouter() *gin.Engine {
 	RegisterRecommendationsRoutes(api) // Health recommendations
 	RegisterNotificationsRoutes(api)   // User notifications
 	RegisterAnalyticsRoutes(api)
+	RegisterCalendarFeedRoutes(api)     // ICS subscription feed
+	RegisterMedicationRoutes(api)       // Contraception & medications
+	RegisterPMDDRoutes(api)             // PMS/PMDD screening
+	RegisterBabyRoutes(api)             // Infant records
+	RegisterPregnancyContentRoutes(api) // Week-by-week pregnancy guide
+	RegisterLaborTrackingRoutes(api)    // Kick counter & contraction timer
+	RegisterClinicalAlertRoutes(api)    // Prenatal vitals alerts & care team consent
+	RegisterVitalRoutes(api)            // Home vital readings
+	RegisterGlucoseRoutes(api)          // Gestational diabetes targets & reports
+	RegisterFeedingRoutes(api)          // Breastfeeding & pumping sessions
+	RegisterMonitoringRoutes(api)       // Template-validated monitoring records
+	RegisterAppointmentRoutes(api)      // Appointment booking & lifecycle
+	RegisterDoctorScheduleRoutes(api)   // Doctor availability & bookable slots
+	RegisterReminderRoutes(api)         // Appointment & checkup reminders
 
 	// ✅ Block/Mute routes (protected)
 	api.POST("/block", middleware.AuthMiddleware(), controllers.BlockOrMuteUser)
@@ -58,5 +73,14 @@ func SetupRouter() *gin.Engine {
 	admin.DELETE("/comments/:id", controllers.DeleteComment)
 	admin.PUT("/users/:id/suspend", controllers.SuspendUser)
 
+	// Clinical rule thresholds
+	admin.GET("/vital-thresholds", controllers.GetVitalThresholds)
+	admin.PUT("/vital-thresholds/:key", controllers.UpdateVitalThreshold)
+
+	// Monitoring field templates
+	admin.GET("/monitoring-templates", controllers.GetMonitoringTemplates)
+	admin.PUT("/monitoring-templates/:type", controllers.SaveMonitoringTemplate)
+	admin.DELETE("/monitoring-templates/:type", controllers.DeleteMonitoringTemplate)
+
 	return router
 }
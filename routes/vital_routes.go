@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shem958/cycle-backend/controllers"
+	"github.com/shem958/cycle-backend/middleware"
+)
+
+// RegisterVitalRoutes sets up home vital reading routes
+func RegisterVitalRoutes(api *gin.RouterGroup) {
+	vitals := api.Group("/vitals")
+	vitals.Use(middleware.AuthMiddleware())
+	{
+		vitals.POST("", controllers.LogVitalReading)
+		vitals.GET("", controllers.GetVitalReadings)
+		vitals.DELETE("/:id", controllers.DeleteVitalReading)
+	}
+}
@@ -15,9 +15,17 @@ import (
 
 // ====== PUBLIC TYPES ======
 
+// Trend point sources
+const (
+	TrendSourceCheckup = "checkup"
+	TrendSourceHome    = "home"
+)
+
 type TimeValue struct {
-	Time  time.Time `json:"time"`
-	Value float64   `json:"value"`
+	Time    time.Time `json:"time"`
+	Value   float64   `json:"value"`
+	Source  string    `json:"source,omitempty"`  // "checkup" | "home"
+	Context string    `json:"context,omitempty"` // meal context for glucose
 }
 
 type BloodPressurePoint struct {
@@ -25,6 +33,7 @@ type BloodPressurePoint struct {
 	Systolic  *int      `json:"systolic,omitempty"`
 	Diastolic *int      `json:"diastolic,omitempty"`
 	Raw       string    `json:"raw"`
+	Source    string    `json:"source,omitempty"` // "checkup" | "home"
 }
 
 type CheckupItem struct {
@@ -44,6 +53,12 @@ type CombinedAnalytics struct {
 	UpcomingNextCheckup *time.Time           `json:"upcoming_next_checkup,omitempty"`
 	WeightTrend         []TimeValue          `json:"weight_trend"`
 	BloodPressure       []BloodPressurePoint `json:"blood_pressure"`
+	GlucoseTrend        []TimeValue          `json:"glucose_trend"`
+	HeartRateTrend      []TimeValue          `json:"heart_rate_trend"`
+	TemperatureTrend    []TimeValue          `json:"temperature_trend"`
+	GlucoseCompliance   *GlucoseCompliance   `json:"glucose_compliance,omitempty"`
+	PostpartumTrends    *PostpartumTrends    `json:"postpartum_trends,omitempty"`
+	Monitoring          []MonitoringSeries   `json:"monitoring,omitempty"` // only for the user and their care team
 	Timeline            []CheckupItem        `json:"timeline"`
 }
 
@@ -133,13 +148,40 @@ func firstNonEmpty(strs ...string) string {
 	return ""
 }
 
-// GetCombinedAnalytics aggregates pregnancy + postpartum checkups for a user within optional date range.
+// GetCombinedAnalytics aggregates pregnancy + postpartum checkups and home vital readings
+// for a user within optional date range, as seen by viewerID.
 // Uses an in-memory cache to reduce DB load.
-func GetCombinedAnalytics(userID uuid.UUID, from, to *time.Time) (*CombinedAnalytics, error) {
+func GetCombinedAnalytics(userID, viewerID uuid.UUID, from, to *time.Time) (*CombinedAnalytics, error) {
 	// Try cache
 	if data, ok := getFromCache(userID, from, to); ok {
+		return analyticsForViewer(data, viewerID)
+	}
+	data, err := buildCombinedAnalytics(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return analyticsForViewer(data, viewerID)
+}
+
+// analyticsForViewer withholds the decrypted monitoring series unless the
+// viewer is the user or a doctor on the user's care team
+func analyticsForViewer(data *CombinedAnalytics, viewerID uuid.UUID) (*CombinedAnalytics, error) {
+	if viewerID == data.UserID {
 		return data, nil
 	}
+	ok, err := careTeamConsent(data.UserID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return data, nil
+	}
+	withheld := *data
+	withheld.Monitoring = nil
+	return &withheld, nil
+}
+
+func buildCombinedAnalytics(userID uuid.UUID, from, to *time.Time) (*CombinedAnalytics, error) {
 
 	var preg []models.PregnancyCheckup
 	var post []models.PostpartumCheckup
@@ -163,6 +205,18 @@ func GetCombinedAnalytics(userID uuid.UUID, from, to *time.Time) (*CombinedAnaly
 		return nil, err
 	}
 
+	var vitals []models.VitalReading
+	qv := config.DB.Where("user_id = ?", userID)
+	if from != nil {
+		qv = qv.Where("measured_at >= ?", *from)
+	}
+	if to != nil {
+		qv = qv.Where("measured_at <= ?", *to)
+	}
+	if err := qv.Find(&vitals).Error; err != nil {
+		return nil, err
+	}
+
 	analytics := &CombinedAnalytics{
 		UserID:          userID,
 		From:            from,
@@ -174,16 +228,17 @@ func GetCombinedAnalytics(userID uuid.UUID, from, to *time.Time) (*CombinedAnaly
 	// Build trends and unified timeline
 	var weights []TimeValue
 	var bps []BloodPressurePoint
+	var glucose, heartRate, temperature []TimeValue
 	var timeline []CheckupItem
 
 	for _, c := range preg {
 		if c.Weight > 0 {
-			weights = append(weights, TimeValue{Time: c.VisitDate, Value: c.Weight})
+			weights = append(weights, TimeValue{Time: c.VisitDate, Value: c.Weight, Source: TrendSourceCheckup})
 		}
 		if strings.TrimSpace(c.BloodPressure) != "" {
 			sys, dia := parseBP(c.BloodPressure)
 			bps = append(bps, BloodPressurePoint{
-				Time: c.VisitDate, Systolic: sys, Diastolic: dia, Raw: c.BloodPressure,
+				Time: c.VisitDate, Systolic: sys, Diastolic: dia, Raw: c.BloodPressure, Source: TrendSourceCheckup,
 			})
 		}
 		timeline = append(timeline, CheckupItem{
@@ -201,14 +256,73 @@ func GetCombinedAnalytics(userID uuid.UUID, from, to *time.Time) (*CombinedAnaly
 		})
 	}
 
-	sort.Slice(weights, func(i, j int) bool { return weights[i].Time.Before(weights[j].Time) })
+	for _, v := range vitals {
+		if v.Type == models.VitalBloodPressure {
+			if v.Systolic != nil && v.Diastolic != nil {
+				bps = append(bps, BloodPressurePoint{
+					Time: v.MeasuredAt, Systolic: v.Systolic, Diastolic: v.Diastolic,
+					Raw: strconv.Itoa(*v.Systolic) + "/" + strconv.Itoa(*v.Diastolic), Source: TrendSourceHome,
+				})
+			}
+			continue
+		}
+		if v.Value == nil {
+			continue
+		}
+		point := TimeValue{Time: v.MeasuredAt, Value: *v.Value, Source: TrendSourceHome}
+		switch v.Type {
+		case models.VitalWeight:
+			weights = append(weights, point)
+		case models.VitalGlucose:
+			point.Context = v.MealContext
+			glucose = append(glucose, point)
+		case models.VitalHeartRate:
+			heartRate = append(heartRate, point)
+		case models.VitalTemperature:
+			temperature = append(temperature, point)
+		}
+	}
+
+	for _, trend := range [][]TimeValue{weights, glucose, heartRate, temperature} {
+		sort.Slice(trend, func(i, j int) bool { return trend[i].Time.Before(trend[j].Time) })
+	}
 	sort.Slice(bps, func(i, j int) bool { return bps[i].Time.Before(bps[j].Time) })
 	sort.Slice(timeline, func(i, j int) bool { return timeline[i].VisitDate.Before(timeline[j].VisitDate) })
 
 	analytics.WeightTrend = weights
 	analytics.BloodPressure = bps
+	analytics.GlucoseTrend = glucose
+	analytics.HeartRateTrend = heartRate
+	analytics.TemperatureTrend = temperature
+
+	if len(glucose) > 0 {
+		gFrom, gTo := glucose[0].Time, time.Now()
+		if from != nil {
+			gFrom = *from
+		}
+		if to != nil {
+			gTo = *to
+		}
+		compliance, err := BuildGlucoseCompliance(userID, gFrom, gTo)
+		if err != nil {
+			return nil, err
+		}
+		analytics.GlucoseCompliance = compliance
+	}
 	analytics.Timeline = timeline
 
+	trends, err := GetPostpartumTrends(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	analytics.PostpartumTrends = trends
+
+	monitoring, err := monitoringTrends(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	analytics.Monitoring = monitoring
+
 	// Upcoming next checkup
 	now := time.Now()
 	var candidates []time.Time
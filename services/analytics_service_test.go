@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// Decrypted monitoring series reach only the user and their care team
+func TestAnalyticsForViewerGatesMonitoring(t *testing.T) {
+	owner, onCareTeam, stranger := uuid.New(), uuid.New(), uuid.New()
+	saved := careTeamConsent
+	careTeamConsent = func(userID, doctorID uuid.UUID) (bool, error) {
+		return userID == owner && doctorID == onCareTeam, nil
+	}
+	t.Cleanup(func() { careTeamConsent = saved })
+
+	cached := &CombinedAnalytics{
+		UserID:     owner,
+		Monitoring: []MonitoringSeries{{Type: "blood_pressure", Key: "systolic", Label: "Systolic"}},
+	}
+	cases := []struct {
+		name       string
+		viewer     uuid.UUID
+		monitoring bool
+	}{
+		{"owner", owner, true},
+		{"care team doctor", onCareTeam, true},
+		{"doctor without consent", stranger, false},
+	}
+	for _, tc := range cases {
+		got, err := analyticsForViewer(cached, tc.viewer)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if (len(got.Monitoring) > 0) != tc.monitoring {
+			t.Errorf("%s: monitoring included = %v, want %v", tc.name, len(got.Monitoring) > 0, tc.monitoring)
+		}
+	}
+	if len(cached.Monitoring) == 0 {
+		t.Error("withholding monitoring changed the cached analytics")
+	}
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAppointmentNotFound     = errors.New("appointment not found")
+	ErrPatientNotFound         = errors.New("patient not found")
+	ErrNotAppointmentParty     = errors.New("only the patient or doctor on this appointment can change it")
+	ErrAppointmentTransition   = errors.New("this action is not allowed for the appointment's current status")
+	ErrAppointmentInPast       = errors.New("appointment time must be in the future")
+	ErrAppointmentNotStarted   = errors.New("an appointment can only be completed or marked no-show once its time has passed")
+	ErrCancellationReasonBlank = errors.New("a cancellation reason is required")
+)
+
+// Who performed an appointment action
+const (
+	appointmentActorPatient = "patient"
+	appointmentActorDoctor  = "doctor"
+)
+
+// appointmentRule says who may take an action and from which statuses
+type appointmentRule struct {
+	from     []string
+	actors   []string
+	toStatus string // empty keeps the current status
+}
+
+var appointmentRules = map[string]appointmentRule{
+	models.AppointmentActionConfirmed: {
+		from:     []string{models.AppointmentRequested},
+		actors:   []string{appointmentActorPatient, appointmentActorDoctor}, // whichever side did not set the time
+		toStatus: models.AppointmentConfirmed,
+	},
+	models.AppointmentActionRescheduled: {
+		from:   []string{models.AppointmentRequested, models.AppointmentConfirmed},
+		actors: []string{appointmentActorPatient, appointmentActorDoctor},
+	},
+	models.AppointmentActionCancelled: {
+		from:     []string{models.AppointmentRequested, models.AppointmentConfirmed},
+		actors:   []string{appointmentActorPatient, appointmentActorDoctor},
+		toStatus: models.AppointmentCancelled,
+	},
+	models.AppointmentActionCompleted: {
+		from:     []string{models.AppointmentConfirmed},
+		actors:   []string{appointmentActorDoctor},
+		toStatus: models.AppointmentCompleted,
+	},
+	models.AppointmentActionNoShow: {
+		from:     []string{models.AppointmentConfirmed},
+		actors:   []string{appointmentActorDoctor},
+		toStatus: models.AppointmentNoShow,
+	},
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAppointmentTransition checks the rules allow the actor to take the
+// action on an appointment in the given status
+func checkAppointmentTransition(action, status, role string) error {
+	rule, ok := appointmentRules[action]
+	if !ok {
+		return ErrAppointmentTransition
+	}
+	if !containsString(rule.actors, role) || !containsString(rule.from, status) {
+		return fmt.Errorf("%w: %s cannot be %s by the %s", ErrAppointmentTransition, status, action, role)
+	}
+	return nil
+}
+
+// appointmentActorRole reports whether the user is the appointment's patient or doctor
+func appointmentActorRole(appt *models.Appointment, actorID uuid.UUID) (string, error) {
+	switch actorID {
+	case appt.DoctorID:
+		return appointmentActorDoctor, nil
+	case appt.UserID:
+		return appointmentActorPatient, nil
+	}
+	return "", ErrNotAppointmentParty
+}
+
+// awaitingConfirmationFrom returns who has to confirm a requested appointment:
+// the other side from whoever last set its time
+func awaitingConfirmationFrom(tx *gorm.DB, appt *models.Appointment) (string, error) {
+	var last models.AppointmentEvent
+	err := tx.Where("appointment_id = ? AND action IN ?", appt.ID,
+		[]string{models.AppointmentActionRequested, models.AppointmentActionRescheduled}).
+		Order("created_at desc").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return appointmentActorDoctor, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if last.ActorRole == appointmentActorDoctor {
+		return appointmentActorPatient, nil
+	}
+	return appointmentActorDoctor, nil
+}
+
+// GetAppointmentForParty loads an appointment the user is the patient or doctor on
+func GetAppointmentForParty(appointmentID, userID uuid.UUID) (*models.Appointment, error) {
+	var appt models.Appointment
+	err := config.DB.Where("id = ? AND (user_id = ? OR doctor_id = ?)", appointmentID, userID, userID).First(&appt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAppointmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &appt, nil
+}
+
+// checkDoctorMayBookFor allows a doctor to book for an existing patient who
+// has added them to their care team
+func checkDoctorMayBookFor(patientID, doctorID uuid.UUID) error {
+	var count int64
+	if err := config.DB.Model(&models.User{}).Where("id = ?", patientID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPatientNotFound
+	}
+	ok, err := careTeamConsent(patientID, doctorID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoCareTeamConsent
+	}
+	return nil
+}
+
+// AppointmentRequest is a new appointment from a patient, or one a doctor sets up for a patient
+type AppointmentRequest struct {
+	PatientID   uuid.UUID
+	DoctorID    uuid.UUID
+	Title       string
+	Description string
+	Location    string
+	Mode        string // in_person (default) or virtual
+	ScheduledAt time.Time
+	IsFollowUp  bool
+
+	AppointmentTypeID *uuid.UUID // sets the length; DefaultAppointmentMinutes otherwise
+
+	// Set when the doctor's NextCheckupAt on a checkup proposes this visit
+	SourceCheckupKind string
+	SourceCheckupID   *uuid.UUID
+}
+
+// CreateAppointment books an appointment. A patient's request must fit the
+// doctor's availability and waits for the doctor to confirm; one made by the
+// doctor is confirmed straight away, unless it is a follow-up proposed from a
+// checkup, which waits for the patient. Either way the time is reserved under
+// the doctor's schedule lock so concurrent bookings cannot overlap.
+func CreateAppointment(actorID uuid.UUID, req AppointmentRequest, now time.Time) (*models.Appointment, error) {
+	if !req.ScheduledAt.After(now) {
+		return nil, ErrAppointmentInPast
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = models.AppointmentModeInPerson
+	case models.AppointmentModeInPerson, models.AppointmentModeVirtual:
+	default:
+		return nil, ErrInvalidAppointmentMode
+	}
+	var doctor models.User
+	if err := config.DB.Where("id = ? AND role = ? AND verified = ?", req.DoctorID, models.RoleDoctor, true).
+		First(&doctor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotADoctor
+		}
+		return nil, err
+	}
+
+	if req.SourceCheckupID != nil {
+		req.IsFollowUp = true
+		if req.Title == "" {
+			req.Title = "Follow-up checkup"
+		}
+	}
+
+	duration := DefaultAppointmentMinutes
+	if req.AppointmentTypeID != nil {
+		apptType, err := GetActiveAppointmentType(req.DoctorID, *req.AppointmentTypeID)
+		if err != nil {
+			return nil, err
+		}
+		duration = apptType.DurationMinutes
+		if req.Title == "" {
+			req.Title = apptType.Name
+		}
+	}
+
+	appt := models.Appointment{
+		ID:                uuid.New(),
+		UserID:            req.PatientID,
+		DoctorID:          req.DoctorID,
+		Title:             req.Title,
+		Description:       req.Description,
+		Location:          req.Location,
+		Mode:              req.Mode,
+		ScheduledAt:       req.ScheduledAt,
+		EndsAt:            req.ScheduledAt.Add(time.Duration(duration) * time.Minute),
+		AppointmentTypeID: req.AppointmentTypeID,
+		IsFollowUp:        req.IsFollowUp,
+		SourceCheckupKind: req.SourceCheckupKind,
+		SourceCheckupID:   req.SourceCheckupID,
+		Status:            models.AppointmentRequested,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	role, err := appointmentActorRole(&appt, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if role == appointmentActorDoctor && appt.UserID != actorID {
+		if err := checkDoctorMayBookFor(appt.UserID, actorID); err != nil {
+			return nil, err
+		}
+	}
+	if role == appointmentActorDoctor && appt.SourceCheckupID == nil {
+		appt.Status = models.AppointmentConfirmed
+		appt.ConfirmedAt = &now
+	}
+
+	event := models.AppointmentEvent{
+		ID:             uuid.New(),
+		AppointmentID:  appt.ID,
+		ActorID:        actorID,
+		ActorRole:      role,
+		Action:         models.AppointmentActionRequested,
+		ToStatus:       appt.Status,
+		NewScheduledAt: &appt.ScheduledAt,
+		CreatedAt:      now,
+	}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reserveSlot(tx, appt.DoctorID, appt.ScheduledAt, appt.EndsAt, role == appointmentActorPatient, nil); err != nil {
+			return err
+		}
+		if err := tx.Create(&appt).Error; err != nil {
+			return err
+		}
+		if appt.SourceCheckupID != nil {
+			if err := setCheckupFollowUp(tx, appt.SourceCheckupKind, *appt.SourceCheckupID, &appt.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&event).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	notifyAppointmentParty(&appt, event)
+	ScheduleAppointmentReminders(&appt, now)
+	return &appt, nil
+}
+
+// AppointmentChange is a lifecycle action on an existing appointment
+type AppointmentChange struct {
+	Action      string
+	Reason      string
+	ScheduledAt *time.Time // reschedules only
+
+	Checkup *CheckupDetails // completions only; Reason is used as the doctor's notes when it has none
+}
+
+// ChangeAppointment applies a lifecycle action for the patient or doctor,
+// records it in the history and notifies the other party
+func ChangeAppointment(appointmentID, actorID uuid.UUID, change AppointmentChange, now time.Time) (*models.Appointment, error) {
+	rule, ok := appointmentRules[change.Action]
+	if !ok {
+		return nil, ErrAppointmentTransition
+	}
+	change.Reason = strings.TrimSpace(change.Reason)
+	if change.Action == models.AppointmentActionCancelled && change.Reason == "" {
+		return nil, ErrCancellationReasonBlank
+	}
+	if change.Action == models.AppointmentActionRescheduled && (change.ScheduledAt == nil || !change.ScheduledAt.After(now)) {
+		return nil, ErrAppointmentInPast
+	}
+
+	var appt models.Appointment
+	var event models.AppointmentEvent
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND (user_id = ? OR doctor_id = ?)", appointmentID, actorID, actorID).
+			First(&appt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAppointmentNotFound
+			}
+			return err
+		}
+		role, err := appointmentActorRole(&appt, actorID)
+		if err != nil {
+			return err
+		}
+		if err := checkAppointmentTransition(change.Action, appt.Status, role); err != nil {
+			return err
+		}
+		if (change.Action == models.AppointmentActionCompleted || change.Action == models.AppointmentActionNoShow) &&
+			appt.ScheduledAt.After(now) {
+			return ErrAppointmentNotStarted
+		}
+		awaiting := ""
+		if appt.Status == models.AppointmentRequested {
+			if awaiting, err = awaitingConfirmationFrom(tx, &appt); err != nil {
+				return err
+			}
+		}
+		if change.Action == models.AppointmentActionConfirmed && role != awaiting {
+			return fmt.Errorf("%w: waiting for the %s to confirm", ErrAppointmentTransition, awaiting)
+		}
+
+		event = models.AppointmentEvent{
+			ID:            uuid.New(),
+			AppointmentID: appt.ID,
+			ActorID:       actorID,
+			ActorRole:     role,
+			Action:        change.Action,
+			FromStatus:    appt.Status,
+			Reason:        change.Reason,
+			CreatedAt:     now,
+		}
+
+		switch change.Action {
+		case models.AppointmentActionConfirmed:
+			appt.ConfirmedAt = &now
+		case models.AppointmentActionRescheduled:
+			length := appt.EndsAt.Sub(appt.ScheduledAt)
+			if length <= 0 {
+				length = DefaultAppointmentMinutes * time.Minute
+			}
+			newEnd := change.ScheduledAt.Add(length)
+			if err := reserveSlot(tx, appt.DoctorID, *change.ScheduledAt, newEnd, role == appointmentActorPatient, &appt.ID); err != nil {
+				return err
+			}
+			previous := appt.ScheduledAt
+			event.PreviousScheduledAt = &previous
+			event.NewScheduledAt = change.ScheduledAt
+			appt.ScheduledAt = *change.ScheduledAt
+			appt.EndsAt = newEnd
+			appt.RescheduleCount++
+			if appt.SourceCheckupID != nil {
+				if err := setCheckupNextAt(tx, appt.SourceCheckupKind, *appt.SourceCheckupID, appt.ScheduledAt); err != nil {
+					return err
+				}
+			}
+			// A new time proposed by the patient needs the doctor to confirm it
+			// again; a doctor moving a proposal still waits for the patient
+			if role == appointmentActorPatient || awaiting == appointmentActorPatient {
+				appt.Status = models.AppointmentRequested
+				appt.ConfirmedAt = nil
+			} else {
+				appt.Status = models.AppointmentConfirmed
+				appt.ConfirmedAt = &now
+			}
+		case models.AppointmentActionCancelled:
+			appt.CancellationReason = change.Reason
+			appt.CancelledBy = &actorID
+			appt.CancelledAt = &now
+		case models.AppointmentActionCompleted:
+			appt.CompletedAt = &now
+			if err := recordVisitCheckup(tx, &appt, change.Checkup, change.Reason); err != nil {
+				return err
+			}
+		case models.AppointmentActionNoShow:
+			appt.CompletedAt = &now
+		}
+		if rule.toStatus != "" {
+			appt.Status = rule.toStatus
+		}
+		event.ToStatus = appt.Status
+		appt.UpdatedAt = now
+
+		if err := tx.Save(&appt).Error; err != nil {
+			return err
+		}
+		return tx.Create(&event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifyAppointmentParty(&appt, event)
+	ScheduleAppointmentReminders(&appt, now)
+	if change.Action == models.AppointmentActionCompleted {
+		afterVisitCheckup(&appt, now)
+	}
+	if appt.Mode == models.AppointmentModeVirtual && change.Action != models.AppointmentActionConfirmed {
+		CloseAppointmentVisit(appt.ID, now)
+	}
+	return &appt, nil
+}
+
+// GetAppointmentHistory lists an appointment's events, oldest first
+func GetAppointmentHistory(appointmentID uuid.UUID) ([]models.AppointmentEvent, error) {
+	events := []models.AppointmentEvent{}
+	err := config.DB.Where("appointment_id = ?", appointmentID).Order("created_at asc").Find(&events).Error
+	return events, err
+}
+
+// notifyAppointmentParty tells the other side of the appointment what changed.
+// Failures are logged, not returned, because the change itself has been saved.
+func notifyAppointmentParty(appt *models.Appointment, event models.AppointmentEvent) {
+	recipient, who := appt.DoctorID, "Your patient"
+	if event.ActorRole == appointmentActorDoctor {
+		recipient, who = appt.UserID, "Your doctor"
+	}
+	when := appt.ScheduledAt.UTC().Format("Mon 2 Jan 2006 15:04 MST")
+	label := appt.Title
+	if label == "" {
+		label = "appointment"
+	}
+
+	var title, message string
+	switch event.Action {
+	case models.AppointmentActionRequested:
+		if event.ToStatus == models.AppointmentConfirmed {
+			title, message = "Appointment booked", fmt.Sprintf("%s booked a %s for %s.", who, label, when)
+		} else if appt.SourceCheckupID != nil {
+			title = "Follow-up proposed"
+			message = fmt.Sprintf("%s proposed a %s for %s. Please confirm it or choose another time.", who, label, when)
+		} else {
+			title, message = "New appointment request", fmt.Sprintf("%s requested a %s for %s.", who, label, when)
+		}
+	case models.AppointmentActionConfirmed:
+		title, message = "Appointment confirmed", fmt.Sprintf("%s confirmed your %s for %s.", who, label, when)
+	case models.AppointmentActionRescheduled:
+		title = "Appointment rescheduled"
+		message = fmt.Sprintf("%s moved your %s from %s to %s.", who, label,
+			event.PreviousScheduledAt.UTC().Format("Mon 2 Jan 2006 15:04 MST"), when)
+		if event.ToStatus == models.AppointmentRequested {
+			message += " Please confirm the new time."
+		}
+	case models.AppointmentActionCancelled:
+		title, message = "Appointment cancelled", fmt.Sprintf("%s cancelled your %s on %s. Reason: %s", who, label, when, event.Reason)
+	case models.AppointmentActionCompleted:
+		title, message = "Appointment completed", fmt.Sprintf("Your %s on %s has been marked as completed.", label, when)
+		if appt.CheckupID != nil {
+			message += " Your doctor's notes are in your checkup record."
+		}
+	case models.AppointmentActionNoShow:
+		title, message = "Missed appointment", fmt.Sprintf("You were marked as not attending your %s on %s. Contact your doctor to book again.", label, when)
+	}
+	if event.Reason != "" && event.Action != models.AppointmentActionCancelled {
+		message += " Note: " + event.Reason
+	}
+
+	if err := Notify(recipient, models.NotificationTypeAppointment, title, message, "/appointments/"+appt.ID.String()); err != nil {
+		log.Printf("❌ Failed to send appointment notification for %s: %v", appt.ID, err)
+	}
+}
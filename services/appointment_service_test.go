@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestCheckAppointmentTransition(t *testing.T) {
+	const (
+		patient = appointmentActorPatient
+		doctor  = appointmentActorDoctor
+	)
+	cases := []struct {
+		action, status, role string
+		allowed              bool
+	}{
+		{models.AppointmentActionConfirmed, models.AppointmentRequested, patient, true},
+		{models.AppointmentActionConfirmed, models.AppointmentRequested, doctor, true},
+		{models.AppointmentActionConfirmed, models.AppointmentConfirmed, doctor, false},
+		{models.AppointmentActionRescheduled, models.AppointmentConfirmed, patient, true},
+		{models.AppointmentActionRescheduled, models.AppointmentCancelled, doctor, false},
+		{models.AppointmentActionCancelled, models.AppointmentRequested, patient, true},
+		{models.AppointmentActionCancelled, models.AppointmentCompleted, doctor, false},
+		{models.AppointmentActionCompleted, models.AppointmentConfirmed, doctor, true},
+		{models.AppointmentActionCompleted, models.AppointmentConfirmed, patient, false},
+		{models.AppointmentActionCompleted, models.AppointmentRequested, doctor, false},
+		{models.AppointmentActionNoShow, models.AppointmentConfirmed, doctor, true},
+		{models.AppointmentActionNoShow, models.AppointmentNoShow, doctor, false},
+		{"approved", models.AppointmentRequested, doctor, false},
+	}
+	for _, tc := range cases {
+		err := checkAppointmentTransition(tc.action, tc.status, tc.role)
+		if tc.allowed && err != nil {
+			t.Errorf("%s %s by %s: unexpected %v", tc.action, tc.status, tc.role, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrAppointmentTransition) {
+			t.Errorf("%s %s by %s: got %v, want ErrAppointmentTransition", tc.action, tc.status, tc.role, err)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPregnancyNotDelivered = errors.New("babies can only be added to a delivered pregnancy")
+	ErrBabiesAlreadyAdded    = errors.New("babies have already been added for this pregnancy")
+	ErrInvalidBabySex        = errors.New("sex must be female or male")
+	ErrInvalidBabyCount      = errors.New("between 1 and 6 babies can be added per delivery")
+	ErrVaccineDueBeforeBirth = errors.New("due date cannot be before the baby's birth date")
+)
+
+// Maximum babies per delivery
+const maxBabiesPerPregnancy = 6
+
+// How many days before a vaccine is due the reminder is sent
+const vaccinationReminderDays = 7
+
+// Doses that became overdue longer ago than this are not reminded about, so a
+// baby added weeks after birth does not set off a burst of reminders
+const vaccinationOverdueDays = 30
+
+// scheduledVaccine is an entry of the default immunisation schedule
+type scheduledVaccine struct {
+	Vaccine  string
+	Dose     int
+	AgeWeeks int
+}
+
+// defaultVaccinationSchedule is a common infant schedule for the first 15
+// months. Parents can edit due dates to match their local programme.
+var defaultVaccinationSchedule = []scheduledVaccine{
+	{"Hepatitis B", 1, 0},
+	{"Hepatitis B", 2, 8},
+	{"DTaP", 1, 8},
+	{"Hib", 1, 8},
+	{"Polio (IPV)", 1, 8},
+	{"Pneumococcal (PCV)", 1, 8},
+	{"Rotavirus", 1, 8},
+	{"DTaP", 2, 17},
+	{"Hib", 2, 17},
+	{"Polio (IPV)", 2, 17},
+	{"Pneumococcal (PCV)", 2, 17},
+	{"Rotavirus", 2, 17},
+	{"DTaP", 3, 26},
+	{"Pneumococcal (PCV)", 3, 26},
+	{"Hepatitis B", 3, 26},
+	{"Polio (IPV)", 3, 26},
+	{"Influenza", 1, 26},
+	{"MMR", 1, 52},
+	{"Varicella", 1, 52},
+	{"Hepatitis A", 1, 52},
+	{"Hib", 3, 52},
+	{"Pneumococcal (PCV)", 4, 52},
+	{"DTaP", 4, 65},
+}
+
+// NewBaby describes one baby of a delivery
+type NewBaby struct {
+	Name                     string  `json:"name"`
+	Sex                      string  `json:"sex"`
+	BirthWeightKg            float64 `json:"birth_weight_kg"`
+	BirthLengthCm            float64 `json:"birth_length_cm"`
+	BirthHeadCircumferenceCm float64 `json:"birth_head_circumference_cm"`
+	Notes                    string  `json:"notes"`
+}
+
+// CreateBabiesFromPregnancy adds the babies of a delivered pregnancy in birth
+// order, records their birth measurements and sets up their vaccination schedule.
+func CreateBabiesFromPregnancy(userID, pregnancyID uuid.UUID, babies []NewBaby) ([]models.Baby, error) {
+	var pregnancy models.Pregnancy
+	if err := config.DB.Where("id = ? AND user_id = ?", pregnancyID, userID).First(&pregnancy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPregnancyNotFound
+		}
+		return nil, err
+	}
+	if pregnancy.Status != models.PregnancyStatusDelivered || pregnancy.EndDate == nil {
+		return nil, ErrPregnancyNotDelivered
+	}
+	if len(babies) == 0 || len(babies) > maxBabiesPerPregnancy {
+		return nil, ErrInvalidBabyCount
+	}
+	for _, b := range babies {
+		if b.Sex != models.BabySexFemale && b.Sex != models.BabySexMale {
+			return nil, ErrInvalidBabySex
+		}
+	}
+
+	now := time.Now()
+	var created []models.Baby
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.Baby{}).Where("pregnancy_id = ?", pregnancyID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrBabiesAlreadyAdded
+		}
+
+		for i, b := range babies {
+			baby := models.Baby{
+				ID:                       uuid.New(),
+				UserID:                   userID,
+				PregnancyID:              pregnancyID,
+				BirthOrder:               i + 1,
+				Name:                     b.Name,
+				Sex:                      b.Sex,
+				BirthDate:                *pregnancy.EndDate,
+				BirthWeightKg:            b.BirthWeightKg,
+				BirthLengthCm:            b.BirthLengthCm,
+				BirthHeadCircumferenceCm: b.BirthHeadCircumferenceCm,
+				Notes:                    b.Notes,
+				CreatedAt:                now,
+				UpdatedAt:                now,
+			}
+			if err := tx.Create(&baby).Error; err != nil {
+				return err
+			}
+
+			if b.BirthWeightKg > 0 || b.BirthLengthCm > 0 || b.BirthHeadCircumferenceCm > 0 {
+				birth := models.GrowthMeasurement{
+					ID:         uuid.New(),
+					BabyID:     baby.ID,
+					UserID:     userID,
+					MeasuredAt: baby.BirthDate,
+					Notes:      "Birth measurements",
+					CreatedAt:  now,
+				}
+				if b.BirthWeightKg > 0 {
+					birth.WeightKg = &b.BirthWeightKg
+				}
+				if b.BirthLengthCm > 0 {
+					birth.LengthCm = &b.BirthLengthCm
+				}
+				if b.BirthHeadCircumferenceCm > 0 {
+					birth.HeadCircumferenceCm = &b.BirthHeadCircumferenceCm
+				}
+				if err := tx.Create(&birth).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Create(vaccinationSchedule(baby, now)).Error; err != nil {
+				return err
+			}
+			created = append(created, baby)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// vaccinationSchedule builds the default vaccination records for a baby
+func vaccinationSchedule(baby models.Baby, now time.Time) []models.Vaccination {
+	birth := truncateDay(baby.BirthDate)
+	var schedule []models.Vaccination
+	for _, v := range defaultVaccinationSchedule {
+		schedule = append(schedule, models.Vaccination{
+			ID:        uuid.New(),
+			BabyID:    baby.ID,
+			UserID:    baby.UserID,
+			Vaccine:   v.Vaccine,
+			Dose:      v.Dose,
+			DueDate:   birth.AddDate(0, 0, v.AgeWeeks*7),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	return schedule
+}
+
+// ValidateVaccinationDueDate checks a dose is not due before the baby was born
+func ValidateVaccinationDueDate(baby *models.Baby, due time.Time) error {
+	if truncateDay(due).Before(truncateDay(baby.BirthDate)) {
+		return ErrVaccineDueBeforeBirth
+	}
+	return nil
+}
+
+// Kinds of vaccination reminder
+const (
+	vaccinationDueSoon = "due_soon"
+	vaccinationOverdue = "overdue"
+)
+
+// vaccinationReminderKind says which reminder a dose is owed now, if any: one
+// in the week before it is due and one once it is overdue
+func vaccinationReminderKind(v models.Vaccination, now time.Time) string {
+	if v.AdministeredAt != nil {
+		return ""
+	}
+	today := truncateDay(now)
+	due := truncateDay(v.DueDate)
+	switch {
+	case due.Before(today):
+		if v.OverdueReminderSentAt == nil && !due.Before(today.AddDate(0, 0, -vaccinationOverdueDays)) {
+			return vaccinationOverdue
+		}
+	case !due.After(today.AddDate(0, 0, vaccinationReminderDays)):
+		if v.ReminderSentAt == nil {
+			return vaccinationDueSoon
+		}
+	}
+	return ""
+}
+
+// SendVaccinationReminders notifies parents of vaccines due within the next
+// week and of doses that have become overdue. Each dose is reminded about at
+// most once before and once after its due date. A reminder is marked sent in
+// the same transaction that writes the notification, so a failure leaves it to
+// the next run.
+func SendVaccinationReminders(now time.Time) error {
+	today := truncateDay(now)
+	var candidates []models.Vaccination
+	if err := config.DB.Where("administered_at IS NULL AND ((reminder_sent_at IS NULL AND due_date BETWEEN ? AND ?) OR (overdue_reminder_sent_at IS NULL AND due_date >= ? AND due_date < ?))",
+		today, today.AddDate(0, 0, vaccinationReminderDays), today.AddDate(0, 0, -vaccinationOverdueDays), today).
+		Find(&candidates).Error; err != nil {
+		return err
+	}
+
+	for _, c := range candidates {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			// Lock the dose so a concurrent run skips it, then re-check it is still owed
+			var v models.Vaccination
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("id = ?", c.ID).First(&v).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			kind := vaccinationReminderKind(v, now)
+			if kind == "" {
+				return nil
+			}
+
+			var baby models.Baby
+			if err := tx.First(&baby, "id = ?", v.BabyID).Error; err != nil {
+				return err
+			}
+			name := baby.Name
+			if name == "" {
+				name = "your baby"
+			}
+			title := "Vaccination due soon"
+			message := fmt.Sprintf("%s dose %d for %s is due on %s.", v.Vaccine, v.Dose, name, v.DueDate.Format("Jan 2"))
+			column := "reminder_sent_at"
+			if kind == vaccinationOverdue {
+				title = "Vaccination overdue"
+				message = fmt.Sprintf("%s dose %d for %s was due on %s. If it has been given, mark it as done; otherwise book it with your clinic.",
+					v.Vaccine, v.Dose, name, v.DueDate.Format("Jan 2"))
+				column = "overdue_reminder_sent_at"
+			}
+			if err := notifyTx(tx, v.UserID, models.NotificationTypeReminder, title, message,
+				"/babies/"+v.BabyID.String()+"/vaccinations"); err != nil {
+				return err
+			}
+			return tx.Model(&models.Vaccination{}).Where("id = ?", v.ID).Update(column, now).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
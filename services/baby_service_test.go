@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestVaccinationReminderKind(t *testing.T) {
+	now := time.Date(2026, 6, 15, 9, 0, 0, 0, time.UTC)
+	given := now.AddDate(0, 0, -1)
+	sent := now.AddDate(0, 0, -3)
+
+	cases := []struct {
+		name string
+		v    models.Vaccination
+		want string
+	}{
+		{"due in a week", models.Vaccination{DueDate: date(2026, 6, 22)}, vaccinationDueSoon},
+		{"due today", models.Vaccination{DueDate: date(2026, 6, 15)}, vaccinationDueSoon},
+		{"due later", models.Vaccination{DueDate: date(2026, 6, 23)}, ""},
+		{"due soon, already reminded", models.Vaccination{DueDate: date(2026, 6, 18), ReminderSentAt: &sent}, ""},
+		{"overdue after the early reminder", models.Vaccination{DueDate: date(2026, 6, 14), ReminderSentAt: &sent}, vaccinationOverdue},
+		{"overdue, already reminded", models.Vaccination{DueDate: date(2026, 6, 10), OverdueReminderSentAt: &sent}, ""},
+		{"overdue too long", models.Vaccination{DueDate: date(2026, 5, 15)}, ""},
+		{"given", models.Vaccination{DueDate: date(2026, 6, 10), AdministeredAt: &given}, ""},
+	}
+	for _, tc := range cases {
+		if got := vaccinationReminderKind(tc.v, now); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidateVaccinationDueDate(t *testing.T) {
+	baby := &models.Baby{BirthDate: time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)}
+
+	if err := ValidateVaccinationDueDate(baby, date(2026, 3, 10)); err != nil {
+		t.Errorf("birth day: %v", err)
+	}
+	if err := ValidateVaccinationDueDate(baby, date(2026, 3, 9)); !errors.Is(err, ErrVaccineDueBeforeBirth) {
+		t.Errorf("day before birth: got %v", err)
+	}
+}
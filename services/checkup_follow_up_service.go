@@ -0,0 +1,274 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCheckupKindRequired = errors.New("checkup kind must be \"pregnancy\" or \"postpartum\"; it could not be worked out for this patient")
+	ErrCheckupNotFound     = errors.New("checkup not found")
+	ErrNotCheckupDoctor    = errors.New("only the checkup's doctor can propose its follow-up")
+)
+
+// CheckupDetails is what the doctor records about a visit when completing it
+type CheckupDetails struct {
+	Kind            string     `json:"kind"` // pregnancy or postpartum; worked out from the patient's pregnancies when empty
+	DoctorNotes     string     `json:"doctor_notes"`
+	Weight          float64    `json:"weight"`
+	BloodPressure   string     `json:"blood_pressure"`
+	BabyHealthNotes string     `json:"baby_health_notes"`
+	Complications   string     `json:"complications"`
+	MentalHealth    string     `json:"mental_health"`
+	NextCheckupAt   *time.Time `json:"next_checkup_at"`
+}
+
+// checkupModel returns an empty record of the given kind for queries
+func checkupModel(kind string) (interface{}, error) {
+	switch kind {
+	case models.CheckupKindPregnancy:
+		return &models.PregnancyCheckup{}, nil
+	case models.CheckupKindPostpartum:
+		return &models.PostpartumCheckup{}, nil
+	}
+	return nil, ErrCheckupKindRequired
+}
+
+// checkupPath is the app link to a checkup record
+func checkupPath(kind string, id uuid.UUID) string {
+	if kind == models.CheckupKindPostpartum {
+		return "/postpartum/checkups/" + id.String()
+	}
+	return "/pregnancy-checkups/" + id.String()
+}
+
+// visitCheckupKind decides which checkup record a visit belongs in: prenatal
+// while a pregnancy covers it, postnatal once one has ended
+func visitCheckupKind(userID uuid.UUID, at time.Time) (string, error) {
+	pregnancy, err := PregnancyAt(userID, at)
+	if err != nil {
+		return "", err
+	}
+	if pregnancy != nil && (pregnancy.EndDate == nil || at.Before(*pregnancy.EndDate)) {
+		return models.CheckupKindPregnancy, nil
+	}
+	ended, err := LatestEndedPregnancy(userID)
+	if err != nil {
+		return "", err
+	}
+	if ended != nil && !at.Before(*ended.EndDate) {
+		return models.CheckupKindPostpartum, nil
+	}
+	return "", ErrCheckupKindRequired
+}
+
+// recordVisitCheckup writes the checkup for a completed appointment and links
+// the two. note stands in for the doctor's notes when the details have none.
+// Only a doctor on the patient's care team writes into their record: without
+// consent the visit is completed with no checkup, or refused if the doctor
+// sent checkup details.
+func recordVisitCheckup(tx *gorm.DB, appt *models.Appointment, details *CheckupDetails, note string) error {
+	ok, err := careTeamConsent(appt.UserID, appt.DoctorID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if details != nil {
+			return ErrNoCareTeamConsent
+		}
+		return nil
+	}
+
+	var d CheckupDetails
+	if details != nil {
+		d = *details
+	}
+	if d.DoctorNotes == "" {
+		d.DoctorNotes = note
+	}
+	if d.Kind == "" {
+		kind, err := visitCheckupKind(appt.UserID, appt.ScheduledAt)
+		if err != nil {
+			return err
+		}
+		d.Kind = kind
+	}
+	var next time.Time
+	if d.NextCheckupAt != nil {
+		next = *d.NextCheckupAt
+	}
+
+	id := uuid.New()
+	var record interface{}
+	switch d.Kind {
+	case models.CheckupKindPregnancy:
+		record = &models.PregnancyCheckup{
+			ID:            id,
+			UserID:        appt.UserID,
+			DoctorID:      appt.DoctorID,
+			VisitDate:     appt.ScheduledAt,
+			DoctorNotes:   d.DoctorNotes,
+			Weight:        d.Weight,
+			BloodPressure: d.BloodPressure,
+			NextCheckupAt: next,
+			AppointmentID: &appt.ID,
+		}
+	case models.CheckupKindPostpartum:
+		record = &models.PostpartumCheckup{
+			ID:                id,
+			UserID:            appt.UserID,
+			DoctorID:          appt.DoctorID,
+			VisitDate:         appt.ScheduledAt,
+			MotherHealthNotes: d.DoctorNotes,
+			BabyHealthNotes:   d.BabyHealthNotes,
+			Complications:     d.Complications,
+			MentalHealth:      d.MentalHealth,
+			NextCheckupAt:     next,
+			AppointmentID:     &appt.ID,
+		}
+	default:
+		return ErrCheckupKindRequired
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return err
+	}
+	appt.CheckupKind, appt.CheckupID = d.Kind, &id
+	return nil
+}
+
+// afterVisitCheckup runs the checks that follow a new checkup from a completed
+// visit. Failures are logged because the visit itself has been saved.
+func afterVisitCheckup(appt *models.Appointment, now time.Time) {
+	if appt.CheckupID == nil {
+		return
+	}
+	if appt.CheckupKind == models.CheckupKindPregnancy {
+		var checkup models.PregnancyCheckup
+		if err := config.DB.Where("id = ?", *appt.CheckupID).First(&checkup).Error; err != nil {
+			log.Printf("❌ Failed to load checkup %s: %v", *appt.CheckupID, err)
+		} else if err := EvaluatePregnancyCheckup(&checkup); err != nil {
+			log.Printf("❌ Failed to evaluate prenatal vitals for checkup %s: %v", checkup.ID, err)
+		}
+	}
+	if _, err := SyncCheckupFollowUp(appt.CheckupKind, *appt.CheckupID, appt.DoctorID, now); err != nil {
+		log.Printf("❌ Failed to propose follow-up for checkup %s: %v", *appt.CheckupID, err)
+	}
+}
+
+// setCheckupFollowUp points a checkup at its follow-up appointment
+func setCheckupFollowUp(tx *gorm.DB, kind string, checkupID uuid.UUID, appointmentID *uuid.UUID) error {
+	model, err := checkupModel(kind)
+	if err != nil {
+		return err
+	}
+	return tx.Model(model).Where("id = ?", checkupID).Update("follow_up_appointment_id", appointmentID).Error
+}
+
+// setCheckupNextAt keeps a checkup's next date in step with its follow-up appointment
+func setCheckupNextAt(tx *gorm.DB, kind string, checkupID uuid.UUID, at time.Time) error {
+	model, err := checkupModel(kind)
+	if err != nil {
+		return err
+	}
+	return tx.Model(model).Where("id = ?", checkupID).Update("next_checkup_at", at).Error
+}
+
+// checkupFollowUpRef is the part of a checkup that drives its follow-up
+type checkupFollowUpRef struct {
+	UserID                uuid.UUID
+	DoctorID              uuid.UUID
+	NextCheckupAt         time.Time
+	FollowUpAppointmentID *uuid.UUID
+}
+
+// SyncCheckupFollowUp keeps a checkup's follow-up appointment in line with
+// its NextCheckupAt. A new date proposes an appointment with the checkup's
+// doctor for the patient to confirm, a changed date moves the open follow-up,
+// and a cleared date withdraws a proposal the patient has not accepted. It
+// returns the linked follow-up, if any. actorID must be the checkup's doctor.
+func SyncCheckupFollowUp(kind string, checkupID, actorID uuid.UUID, now time.Time) (*models.Appointment, error) {
+	model, err := checkupModel(kind)
+	if err != nil {
+		return nil, err
+	}
+	var ref checkupFollowUpRef
+	result := config.DB.Model(model).Select("user_id, doctor_id, next_checkup_at, follow_up_appointment_id").
+		Where("id = ?", checkupID).Scan(&ref)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrCheckupNotFound
+	}
+	if ref.DoctorID == uuid.Nil || ref.DoctorID != actorID {
+		return nil, ErrNotCheckupDoctor
+	}
+
+	var current *models.Appointment
+	if ref.FollowUpAppointmentID != nil {
+		var appt models.Appointment
+		err := config.DB.Where("id = ?", *ref.FollowUpAppointmentID).First(&appt).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			current = &appt
+		}
+	}
+	open := current != nil && (current.Status == models.AppointmentRequested || current.Status == models.AppointmentConfirmed)
+
+	switch {
+	case !ref.NextCheckupAt.After(now):
+		if open && current.Status == models.AppointmentRequested && ref.NextCheckupAt.IsZero() {
+			return ChangeAppointment(current.ID, current.DoctorID, AppointmentChange{
+				Action: models.AppointmentActionCancelled,
+				Reason: "The follow-up checkup is no longer needed",
+			}, now)
+		}
+		return current, nil
+	case open && current.DoctorID == ref.DoctorID:
+		if current.ScheduledAt.Equal(ref.NextCheckupAt) {
+			return current, nil
+		}
+		return ChangeAppointment(current.ID, current.DoctorID, AppointmentChange{
+			Action:      models.AppointmentActionRescheduled,
+			Reason:      "Next checkup date changed",
+			ScheduledAt: &ref.NextCheckupAt,
+		}, now)
+	case open:
+		// Handed over to another doctor: withdraw the old follow-up first
+		if _, err := ChangeAppointment(current.ID, current.DoctorID, AppointmentChange{
+			Action: models.AppointmentActionCancelled,
+			Reason: "The follow-up has moved to another doctor",
+		}, now); err != nil {
+			return nil, err
+		}
+	case current != nil && current.ScheduledAt.Equal(ref.NextCheckupAt):
+		// Already cancelled or held for this date; do not propose it again
+		return current, nil
+	}
+
+	appt, err := CreateAppointment(ref.DoctorID, AppointmentRequest{
+		PatientID:         ref.UserID,
+		DoctorID:          ref.DoctorID,
+		ScheduledAt:       ref.NextCheckupAt,
+		SourceCheckupKind: kind,
+		SourceCheckupID:   &checkupID,
+	}, now)
+	if errors.Is(err, ErrSlotTaken) {
+		when := ref.NextCheckupAt.UTC().Format("Mon 2 Jan 2006 15:04 MST")
+		if nerr := Notify(ref.DoctorID, models.NotificationTypeAppointment, "Follow-up not booked",
+			fmt.Sprintf("The next checkup on %s clashes with another appointment. Pick a different time on the checkup to propose a follow-up.", when),
+			checkupPath(kind, checkupID)); nerr != nil {
+			log.Printf("❌ Failed to send follow-up notification for checkup %s: %v", checkupID, nerr)
+		}
+	}
+	return appt, err
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/models"
+)
+
+// withoutCareTeamConsent makes every consent check fail for the test
+func withoutCareTeamConsent(t *testing.T) {
+	t.Helper()
+	saved := careTeamConsent
+	careTeamConsent = func(userID, doctorID uuid.UUID) (bool, error) { return false, nil }
+	t.Cleanup(func() { careTeamConsent = saved })
+}
+
+func TestRecordVisitCheckupNeedsConsent(t *testing.T) {
+	withoutCareTeamConsent(t)
+	appt := &models.Appointment{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		DoctorID:    uuid.New(),
+		ScheduledAt: time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	// No database is needed: nothing may be written without consent
+	details := &CheckupDetails{Kind: models.CheckupKindPregnancy, DoctorNotes: "BP 150/95", BloodPressure: "150/95"}
+	if err := recordVisitCheckup(nil, appt, details, ""); !errors.Is(err, ErrNoCareTeamConsent) {
+		t.Errorf("completing with checkup details: got %v, want ErrNoCareTeamConsent", err)
+	}
+	if err := recordVisitCheckup(nil, appt, nil, "Seen"); err != nil {
+		t.Errorf("completing without details: %v", err)
+	}
+	if appt.CheckupID != nil || appt.CheckupKind != "" {
+		t.Errorf("a checkup was linked without consent: %s %v", appt.CheckupKind, appt.CheckupID)
+	}
+}
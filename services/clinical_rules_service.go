@@ -0,0 +1,476 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Clinical rule identifiers
+const (
+	RuleBPHigh          = "bp_high"
+	RuleBPSevere        = "bp_severe"
+	RuleBPRapidRise     = "bp_rapid_rise"
+	RuleWeightGainAbove = "weight_gain_above_iom"
+	RuleWeightGainBelow = "weight_gain_below_iom"
+	RuleWeightRapidGain = "weight_rapid_gain"
+)
+
+// Threshold keys editable by admins
+const (
+	ThresholdBPSystolicHigh     = "bp_systolic_high"
+	ThresholdBPDiastolicHigh    = "bp_diastolic_high"
+	ThresholdBPSystolicSevere   = "bp_systolic_severe"
+	ThresholdBPDiastolicSevere  = "bp_diastolic_severe"
+	ThresholdBPSystolicRise     = "bp_systolic_rise"
+	ThresholdBPDiastolicRise    = "bp_diastolic_rise"
+	ThresholdWeightGainTolerate = "weight_gain_tolerance_kg"
+	ThresholdWeightRapidGain    = "weight_rapid_gain_kg_per_week"
+)
+
+var ErrUnknownThreshold = errors.New("unknown threshold key")
+
+// DefaultVitalThresholds are used for any key an admin has not overridden
+var DefaultVitalThresholds = map[string]models.VitalThreshold{
+	ThresholdBPSystolicHigh:     {Key: ThresholdBPSystolicHigh, Value: 140, Description: "Systolic mmHg at or above which BP is high"},
+	ThresholdBPDiastolicHigh:    {Key: ThresholdBPDiastolicHigh, Value: 90, Description: "Diastolic mmHg at or above which BP is high"},
+	ThresholdBPSystolicSevere:   {Key: ThresholdBPSystolicSevere, Value: 160, Description: "Systolic mmHg at or above which BP is severe"},
+	ThresholdBPDiastolicSevere:  {Key: ThresholdBPDiastolicSevere, Value: 110, Description: "Diastolic mmHg at or above which BP is severe"},
+	ThresholdBPSystolicRise:     {Key: ThresholdBPSystolicRise, Value: 30, Description: "Systolic rise over the pregnancy baseline, mmHg"},
+	ThresholdBPDiastolicRise:    {Key: ThresholdBPDiastolicRise, Value: 15, Description: "Diastolic rise over the pregnancy baseline, mmHg"},
+	ThresholdWeightGainTolerate: {Key: ThresholdWeightGainTolerate, Value: 1, Description: "Kg outside the IOM range before alerting"},
+	ThresholdWeightRapidGain:    {Key: ThresholdWeightRapidGain, Value: 1, Description: "Kg gained per week between readings considered rapid"},
+}
+
+// GetVitalThresholds returns every threshold with admin overrides applied
+func GetVitalThresholds() (map[string]models.VitalThreshold, error) {
+	thresholds := make(map[string]models.VitalThreshold, len(DefaultVitalThresholds))
+	for k, v := range DefaultVitalThresholds {
+		thresholds[k] = v
+	}
+
+	var overrides []models.VitalThreshold
+	if err := config.DB.Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		if def, ok := thresholds[o.Key]; ok {
+			if o.Description == "" {
+				o.Description = def.Description
+			}
+			thresholds[o.Key] = o
+		}
+	}
+	return thresholds, nil
+}
+
+// SetVitalThreshold stores an admin override for a threshold
+func SetVitalThreshold(key string, value float64, adminID uuid.UUID) (*models.VitalThreshold, error) {
+	def, ok := DefaultVitalThresholds[key]
+	if !ok {
+		return nil, ErrUnknownThreshold
+	}
+	threshold := models.VitalThreshold{
+		Key:         key,
+		Value:       value,
+		Description: def.Description,
+		UpdatedBy:   adminID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := config.DB.Save(&threshold).Error; err != nil {
+		return nil, err
+	}
+	return &threshold, nil
+}
+
+// iomGuideline is the Institute of Medicine (2009) weight-gain guidance for a BMI category
+type iomGuideline struct {
+	Category           string
+	MaxBMI             float64
+	TotalMin, TotalMax float64 // kg over the whole pregnancy
+	WeeklyMin          float64 // kg/week in the 2nd and 3rd trimesters
+	WeeklyMax          float64
+}
+
+var iomGuidelines = []iomGuideline{
+	{"underweight", 18.5, 12.5, 18, 0.44, 0.58},
+	{"normal", 25, 11.5, 16, 0.35, 0.50},
+	{"overweight", 30, 7, 11.5, 0.23, 0.33},
+	{"obese", math.Inf(1), 5, 9, 0.17, 0.27},
+}
+
+// First-trimester gain range assumed by the IOM guidance
+const (
+	iomFirstTrimesterMin = 0.5
+	iomFirstTrimesterMax = 2.0
+	iomFirstTrimesterEnd = 13
+)
+
+// WeightGainRange is the recommended cumulative gain at a gestational week
+type WeightGainRange struct {
+	BMI         float64 `json:"bmi"`
+	BMICategory string  `json:"bmi_category"`
+	Week        int     `json:"week"`
+	MinKg       float64 `json:"min_kg"`
+	MaxKg       float64 `json:"max_kg"`
+	TotalMinKg  float64 `json:"total_min_kg"`
+	TotalMaxKg  float64 `json:"total_max_kg"`
+}
+
+// IOMWeightGainRange returns the recommended cumulative weight gain at a week
+// for the given pre-pregnancy weight and height
+func IOMWeightGainRange(weightKg, heightCm float64, week int) WeightGainRange {
+	heightM := heightCm / 100
+	bmi := weightKg / (heightM * heightM)
+	g := iomGuidelines[len(iomGuidelines)-1]
+	for _, candidate := range iomGuidelines {
+		if bmi < candidate.MaxBMI {
+			g = candidate
+			break
+		}
+	}
+
+	r := WeightGainRange{BMI: round1(bmi), BMICategory: g.Category, Week: week, TotalMinKg: g.TotalMin, TotalMaxKg: g.TotalMax}
+	if week <= iomFirstTrimesterEnd {
+		f := float64(week) / iomFirstTrimesterEnd
+		r.MinKg, r.MaxKg = round1(iomFirstTrimesterMin*f), round1(iomFirstTrimesterMax*f)
+		return r
+	}
+	weeks := float64(week - iomFirstTrimesterEnd)
+	r.MinKg = round1(math.Min(iomFirstTrimesterMin+g.WeeklyMin*weeks, g.TotalMin))
+	r.MaxKg = round1(math.Min(iomFirstTrimesterMax+g.WeeklyMax*weeks, g.TotalMax))
+	return r
+}
+
+// pendingAlert is a rule violation waiting to be stored
+type pendingAlert struct {
+	Rule     string
+	Severity string
+	Message  string
+	Value    string
+}
+
+// PregnancyAt returns the user's pregnancy covering a date, if any
+func PregnancyAt(userID uuid.UUID, at time.Time) (*models.Pregnancy, error) {
+	var pregnancies []models.Pregnancy
+	if err := config.DB.Where("user_id = ? AND start_date <= ?", userID, at).
+		Order("start_date desc").Find(&pregnancies).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range pregnancies {
+		if p.EndDate == nil || !at.After(*p.EndDate) {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// bloodPressureAlerts applies the absolute and rapid-rise BP rules. The baseline
+// is the lowest-systolic of the first readings of the pregnancy.
+func bloodPressureAlerts(sys, dia int, baseline *[2]int, t map[string]models.VitalThreshold) []pendingAlert {
+	var alerts []pendingAlert
+	value := fmt.Sprintf("%d/%d", sys, dia)
+
+	switch {
+	case float64(sys) >= t[ThresholdBPSystolicSevere].Value || float64(dia) >= t[ThresholdBPDiastolicSevere].Value:
+		alerts = append(alerts, pendingAlert{RuleBPSevere, models.AlertSeverityUrgent,
+			"Blood pressure of " + value + " is severely high. Seek medical care immediately, especially with headache, vision changes or upper belly pain.", value})
+	case float64(sys) >= t[ThresholdBPSystolicHigh].Value || float64(dia) >= t[ThresholdBPDiastolicHigh].Value:
+		alerts = append(alerts, pendingAlert{RuleBPHigh, models.AlertSeverityWarning,
+			"Blood pressure of " + value + " is high for pregnancy and can be a sign of preeclampsia. Contact your care provider today.", value})
+	}
+
+	if baseline != nil {
+		sysRise, diaRise := sys-baseline[0], dia-baseline[1]
+		if float64(sysRise) >= t[ThresholdBPSystolicRise].Value || float64(diaRise) >= t[ThresholdBPDiastolicRise].Value {
+			alerts = append(alerts, pendingAlert{RuleBPRapidRise, models.AlertSeverityWarning,
+				fmt.Sprintf("Blood pressure of %s has risen by %d/%d mmHg from your early-pregnancy baseline of %d/%d. Let your care provider know.",
+					value, sysRise, diaRise, baseline[0], baseline[1]), value})
+		}
+	}
+	return alerts
+}
+
+// bpBaseline uses readings from the first 20 weeks, before gestational hypertension typically appears
+func bpBaseline(pregnancy *models.Pregnancy, readings []BloodPressurePoint, exclude time.Time) *[2]int {
+	cutoff := pregnancy.StartDate.AddDate(0, 0, 20*7)
+	var baseline *[2]int
+	for _, r := range readings {
+		if r.Systolic == nil || r.Diastolic == nil || r.Time.After(cutoff) || r.Time.Equal(exclude) {
+			continue
+		}
+		if baseline == nil || *r.Systolic < baseline[0] {
+			baseline = &[2]int{*r.Systolic, *r.Diastolic}
+		}
+	}
+	return baseline
+}
+
+// weightAlerts compares a weight against the IOM range and the previous reading
+func weightAlerts(pregnancy *models.Pregnancy, weight float64, at time.Time, previous *TimeValue, t map[string]models.VitalThreshold) []pendingAlert {
+	var alerts []pendingAlert
+	value := fmt.Sprintf("%.1f kg", weight)
+
+	if pregnancy.PrePregnancyWeightKg != nil && pregnancy.HeightCm != nil && *pregnancy.HeightCm > 0 {
+		week := int(at.Sub(pregnancy.StartDate).Hours() / (24 * 7))
+		r := IOMWeightGainRange(*pregnancy.PrePregnancyWeightKg, *pregnancy.HeightCm, week)
+		gain := weight - *pregnancy.PrePregnancyWeightKg
+		tolerance := t[ThresholdWeightGainTolerate].Value
+		switch {
+		case gain > r.MaxKg+tolerance:
+			alerts = append(alerts, pendingAlert{RuleWeightGainAbove, models.AlertSeverityWarning,
+				fmt.Sprintf("You have gained %.1f kg by week %d; the recommended range for your pre-pregnancy BMI is %.1f–%.1f kg. Discuss nutrition and activity with your care provider.",
+					gain, week, r.MinKg, r.MaxKg), value})
+		case week > iomFirstTrimesterEnd && gain < r.MinKg-tolerance:
+			alerts = append(alerts, pendingAlert{RuleWeightGainBelow, models.AlertSeverityWarning,
+				fmt.Sprintf("You have gained %.1f kg by week %d; the recommended range for your pre-pregnancy BMI is %.1f–%.1f kg. Discuss nutrition with your care provider.",
+					gain, week, r.MinKg, r.MaxKg), value})
+		}
+	}
+
+	if previous != nil {
+		days := at.Sub(previous.Time).Hours() / 24
+		if days >= 3 {
+			perWeek := (weight - previous.Value) / days * 7
+			if perWeek > t[ThresholdWeightRapidGain].Value {
+				alerts = append(alerts, pendingAlert{RuleWeightRapidGain, models.AlertSeverityWarning,
+					fmt.Sprintf("You gained %.1f kg in %.0f days. Sudden weight gain with swelling of the face or hands can be a sign of preeclampsia. Contact your care provider.",
+						weight-previous.Value, days), value})
+			}
+		}
+	}
+	return alerts
+}
+
+// pregnancyVitalHistory collects the BP and weight readings of a pregnancy up to a
+// time from clinic checkups and home readings, leaving out the reading being evaluated
+func pregnancyVitalHistory(pregnancy *models.Pregnancy, until time.Time, exclude uuid.UUID) ([]BloodPressurePoint, []TimeValue, error) {
+	var checkups []models.PregnancyCheckup
+	if err := config.DB.Where("user_id = ? AND id <> ? AND visit_date BETWEEN ? AND ?",
+		pregnancy.UserID, exclude, pregnancy.StartDate, until).Find(&checkups).Error; err != nil {
+		return nil, nil, err
+	}
+	var readings []models.VitalReading
+	if err := config.DB.Where("user_id = ? AND id <> ? AND type IN ? AND measured_at BETWEEN ? AND ?",
+		pregnancy.UserID, exclude, []string{models.VitalBloodPressure, models.VitalWeight}, pregnancy.StartDate, until).
+		Find(&readings).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var bps []BloodPressurePoint
+	var weights []TimeValue
+	for _, c := range checkups {
+		if sys, dia := parseBP(c.BloodPressure); sys != nil {
+			bps = append(bps, BloodPressurePoint{Time: c.VisitDate, Systolic: sys, Diastolic: dia})
+		}
+		if c.Weight > 0 {
+			weights = append(weights, TimeValue{Time: c.VisitDate, Value: c.Weight})
+		}
+	}
+	for _, r := range readings {
+		switch {
+		case r.Type == models.VitalBloodPressure && r.Systolic != nil && r.Diastolic != nil:
+			bps = append(bps, BloodPressurePoint{Time: r.MeasuredAt, Systolic: r.Systolic, Diastolic: r.Diastolic})
+		case r.Type == models.VitalWeight && r.Value != nil:
+			weights = append(weights, TimeValue{Time: r.MeasuredAt, Value: *r.Value})
+		}
+	}
+	return bps, weights, nil
+}
+
+// EvaluatePregnancyCheckup runs the prenatal rules against a checkup's BP and weight
+func EvaluatePregnancyCheckup(checkup *models.PregnancyCheckup) error {
+	pregnancy, err := PregnancyAt(checkup.UserID, checkup.VisitDate)
+	if err != nil || pregnancy == nil {
+		return err
+	}
+	bps, weights, err := pregnancyVitalHistory(pregnancy, checkup.VisitDate, checkup.ID)
+	if err != nil {
+		return err
+	}
+
+	var weight *float64
+	if checkup.Weight > 0 {
+		weight = &checkup.Weight
+	}
+	sys, dia := parseBP(checkup.BloodPressure)
+	return EvaluatePregnancyVitals(pregnancy, models.AlertSourcePregnancyCheckup, checkup.ID, checkup.VisitDate, sys, dia, weight, bps, weights)
+}
+
+// EvaluateVitalReading runs the prenatal rules against a home BP or weight reading
+func EvaluateVitalReading(reading *models.VitalReading) error {
+	if reading.Type != models.VitalBloodPressure && reading.Type != models.VitalWeight {
+		return nil
+	}
+	pregnancy, err := PregnancyAt(reading.UserID, reading.MeasuredAt)
+	if err != nil || pregnancy == nil {
+		return err
+	}
+	bps, weights, err := pregnancyVitalHistory(pregnancy, reading.MeasuredAt, reading.ID)
+	if err != nil {
+		return err
+	}
+
+	var weight *float64
+	if reading.Type == models.VitalWeight {
+		weight = reading.Value
+	}
+	return EvaluatePregnancyVitals(pregnancy, models.AlertSourceVitalReading, reading.ID, reading.MeasuredAt,
+		reading.Systolic, reading.Diastolic, weight, bps, weights)
+}
+
+// EvaluatePregnancyVitals applies the BP and weight rules to one reading, given
+// the earlier readings of the same pregnancy, and raises any new alerts.
+func EvaluatePregnancyVitals(pregnancy *models.Pregnancy, sourceType string, sourceID uuid.UUID, at time.Time,
+	sys, dia *int, weight *float64, earlierBP []BloodPressurePoint, earlierWeights []TimeValue) error {
+	thresholds, err := GetVitalThresholds()
+	if err != nil {
+		return err
+	}
+
+	var alerts []pendingAlert
+	if sys != nil && dia != nil {
+		alerts = append(alerts, bloodPressureAlerts(*sys, *dia, bpBaseline(pregnancy, earlierBP, at), thresholds)...)
+	}
+	if weight != nil {
+		sort.Slice(earlierWeights, func(i, j int) bool { return earlierWeights[i].Time.Before(earlierWeights[j].Time) })
+		var previous *TimeValue
+		for i := range earlierWeights {
+			if earlierWeights[i].Time.Before(at) {
+				previous = &earlierWeights[i]
+			}
+		}
+		alerts = append(alerts, weightAlerts(pregnancy, *weight, at, previous, thresholds)...)
+	}
+
+	for _, a := range alerts {
+		pregnancyID := pregnancy.ID
+		if err := raiseClinicalAlert(pregnancy.UserID, &pregnancyID, sourceType, sourceID, at, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raiseClinicalAlert stores an alert once per reading and rule, then notifies the
+// user and every doctor the user has consented to share alerts with
+func raiseClinicalAlert(userID uuid.UUID, pregnancyID *uuid.UUID, sourceType string, sourceID uuid.UUID, at time.Time, a pendingAlert) error {
+	alert := models.ClinicalAlert{
+		ID:          uuid.New(),
+		UserID:      userID,
+		PregnancyID: pregnancyID,
+		Rule:        a.Rule,
+		Severity:    a.Severity,
+		Message:     a.Message,
+		Value:       a.Value,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		ObservedAt:  at,
+		CreatedAt:   time.Now(),
+	}
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	title := "Health alert"
+	if a.Severity == models.AlertSeverityUrgent {
+		title = "Urgent health alert"
+	}
+	link := "/clinical-alerts/" + alert.ID.String()
+	if err := Notify(alert.UserID, models.NotificationTypeAlert, title, a.Message, link); err != nil {
+		log.Printf("❌ Failed to notify %s of clinical alert: %v", alert.UserID, err)
+	}
+
+	doctors, err := CareTeamDoctors(alert.UserID, true)
+	if err != nil {
+		return err
+	}
+	for _, doctorID := range doctors {
+		if err := Notify(doctorID, models.NotificationTypeAlert, title+" for a patient",
+			fmt.Sprintf("A patient in your care has a new %s alert (%s).", a.Severity, a.Value), link); err != nil {
+			log.Printf("❌ Failed to notify doctor %s of clinical alert: %v", doctorID, err)
+		}
+	}
+	return nil
+}
+
+// CareTeamDoctors returns the doctors with active consent for a user, optionally
+// only those who receive alerts
+func CareTeamDoctors(userID uuid.UUID, alertsOnly bool) ([]uuid.UUID, error) {
+	query := config.DB.Model(&models.CareTeamLink{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if alertsOnly {
+		query = query.Where("share_alerts = ?", true)
+	}
+	var doctors []uuid.UUID
+	err := query.Pluck("doctor_id", &doctors).Error
+	return doctors, err
+}
+
+// HasCareTeamConsent reports whether a user has an active consent for a doctor
+func HasCareTeamConsent(userID, doctorID uuid.UUID) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.CareTeamLink{}).
+		Where("user_id = ? AND doctor_id = ? AND revoked_at IS NULL", userID, doctorID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// careTeamConsent is the consent check used when a doctor acts on a patient's
+// record; tests replace it
+var careTeamConsent = HasCareTeamConsent
+
+var ErrNoCareTeamConsent = errors.New("the patient has not added this doctor to their care team")
+
+var ErrNotADoctor = errors.New("user is not a verified doctor")
+
+// GrantCareTeamConsent links a verified doctor to the user's care team, reactivating a revoked link
+func GrantCareTeamConsent(userID, doctorID uuid.UUID, shareAlerts bool) (*models.CareTeamLink, error) {
+	var doctor models.User
+	if err := config.DB.Where("id = ? AND role = ? AND verified = ?", doctorID, models.RoleDoctor, true).
+		First(&doctor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotADoctor
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	link := models.CareTeamLink{
+		ID:          uuid.New(),
+		UserID:      userID,
+		DoctorID:    doctorID,
+		ShareAlerts: shareAlerts,
+		ConsentedAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "doctor_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"share_alerts": shareAlerts,
+			"consented_at": now,
+			"revoked_at":   nil,
+			"updated_at":   now,
+		}),
+	}).Create(&link).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Where("user_id = ? AND doctor_id = ?", userID, doctorID).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+)
+
+// Cycle phases used by the calendar
+const (
+	PhaseMenstrual  = "menstrual"
+	PhaseFollicular = "follicular"
+	PhaseOvulation  = "ovulation"
+	PhaseLuteal     = "luteal"
+)
+
+// Fertility levels used by the calendar
+const (
+	FertilityLow    = "low"
+	FertilityMedium = "medium"
+	FertilityHigh   = "high"
+)
+
+// DefaultPeriodLength is the assumed number of bleeding days, since cycles only record a start date
+const DefaultPeriodLength = 5
+
+// lutealPhaseDays is the assumed distance between ovulation and the next period
+const lutealPhaseDays = 14
+
+// MaxCalendarDays caps the range a single calendar request may cover
+const MaxCalendarDays = 366
+
+const calendarDateLayout = "2006-01-02"
+
+// CalendarLog is the data a user logged for a given day, from a cycle entry
+// starting that day and/or the day's daily log
+type CalendarLog struct {
+	CycleID    uint       `json:"cycle_id,omitempty"`
+	DailyLogID *uuid.UUID `json:"daily_log_id,omitempty"`
+	Mood       string     `json:"mood,omitempty"`
+	Symptoms   []string   `json:"symptoms,omitempty"`
+	Flow       string     `json:"flow,omitempty"`
+}
+
+// CalendarDay is a single day in the cycle calendar
+type CalendarDay struct {
+	Date        string       `json:"date"` // YYYY-MM-DD
+	Phase       string       `json:"phase,omitempty"`
+	CycleDay    int          `json:"cycle_day,omitempty"`
+	IsPredicted bool         `json:"is_predicted"` // false when the day belongs to a logged cycle
+	Fertility   string       `json:"fertility,omitempty"`
+	Logged      *CalendarLog `json:"logged,omitempty"`
+}
+
+// CycleCalendar is the response for a calendar range
+type CycleCalendar struct {
+	From               string        `json:"from"`
+	To                 string        `json:"to"`
+	TrackingMode       string        `json:"tracking_mode"`
+	PredictionsPaused  bool          `json:"predictions_paused"`
+	AverageCycleLength int           `json:"average_cycle_length"`
+	Days               []CalendarDay `json:"days"`
+
+	// On hormonal contraception only (withdrawal) bleed days get a phase and fertility is omitted
+	OnHormonalContraception bool `json:"on_hormonal_contraception"`
+}
+
+// cycleSegment is one cycle, logged or predicted, used to place days in phases
+type cycleSegment struct {
+	Start     time.Time
+	Length    int
+	Predicted bool
+}
+
+// Ovulation returns the estimated ovulation date of the segment
+func (s cycleSegment) Ovulation() time.Time {
+	return s.Start.AddDate(0, 0, s.Length-lutealPhaseDays)
+}
+
+// FertileWindow returns the first and last fertile days of the segment
+func (s cycleSegment) FertileWindow() (time.Time, time.Time) {
+	ovulation := s.Ovulation()
+	return ovulation.AddDate(0, 0, -5), ovulation.AddDate(0, 0, 1)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// buildCycleSegments turns logged cycles into segments and, unless paused,
+// projects predicted cycles until the given end date.
+func buildCycleSegments(cycles []models.Cycle, until time.Time, predict bool) []cycleSegment {
+	if len(cycles) == 0 {
+		return nil
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].StartDate.Before(cycles[j].StartDate) })
+
+	avg := averageCycleLength(cycles)
+	var segments []cycleSegment
+	for i, c := range cycles {
+		length := avg
+		if i+1 < len(cycles) {
+			if gap := daysBetween(c.StartDate, cycles[i+1].StartDate); gap > 0 {
+				length = gap
+			}
+		}
+		segments = append(segments, cycleSegment{Start: truncateDay(c.StartDate), Length: length})
+	}
+
+	if !predict {
+		return segments
+	}
+
+	next := segments[len(segments)-1].Start.AddDate(0, 0, avg)
+	for !next.After(until) {
+		segments = append(segments, cycleSegment{Start: next, Length: avg, Predicted: true})
+		next = next.AddDate(0, 0, avg)
+	}
+	return segments
+}
+
+// dayPhase places a day within its cycle segment
+func dayPhase(seg cycleSegment, day time.Time) (phase string, cycleDay int, fertility string) {
+	cycleDay = daysBetween(seg.Start, day) + 1
+	ovulation := seg.Ovulation()
+	fertileStart, fertileEnd := seg.FertileWindow()
+	toOvulation := daysBetween(day, ovulation)
+
+	switch {
+	case cycleDay <= DefaultPeriodLength:
+		phase = PhaseMenstrual
+	case toOvulation >= -1 && toOvulation <= 1:
+		phase = PhaseOvulation
+	case day.Before(ovulation):
+		phase = PhaseFollicular
+	default:
+		phase = PhaseLuteal
+	}
+
+	switch {
+	case toOvulation >= 0 && toOvulation <= 2:
+		fertility = FertilityHigh
+	case !day.Before(fertileStart) && !day.After(fertileEnd):
+		fertility = FertilityMedium
+	default:
+		fertility = FertilityLow
+	}
+	return phase, cycleDay, fertility
+}
+
+func splitSymptoms(s string) []string {
+	var result []string
+	for _, part := range strings.Split(s, ",") {
+		if p := strings.TrimSpace(part); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// BuildCycleCalendar returns one entry per day between from and to (inclusive)
+// with the cycle phase, fertility level and any data logged that day.
+func BuildCycleCalendar(userID uuid.UUID, from, to time.Time) (*CycleCalendar, error) {
+	from, to = truncateDay(from), truncateDay(to)
+
+	paused, mode, err := CyclePredictionsPaused(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hormonal, err := OnHormonalContraception(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var cycles []models.Cycle
+	if err := config.DB.Where("user_id = ?", userID).Order("start_date asc").Find(&cycles).Error; err != nil {
+		return nil, err
+	}
+	// Every logged period is shown, but phases and predictions come only from
+	// the periods since the last pregnancy
+	baseline, since, err := BaselineCycles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if since != nil && len(baseline) < MinBaselineCycles {
+		paused = true
+	}
+
+	logged := map[string]*CalendarLog{}
+	for _, c := range cycles {
+		logged[truncateDay(c.StartDate).Format(calendarDateLayout)] = &CalendarLog{
+			CycleID:  c.ID,
+			Mood:     c.Mood,
+			Symptoms: splitSymptoms(c.Symptoms),
+		}
+	}
+
+	var dailyLogs []models.DailyLog
+	if err := config.DB.Where("user_id = ? AND date BETWEEN ? AND ?", userID, from, to).Find(&dailyLogs).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range dailyLogs {
+		key := truncateDay(l.Date).Format(calendarDateLayout)
+		entry := logged[key]
+		if entry == nil {
+			entry = &CalendarLog{}
+			logged[key] = entry
+		}
+		id := l.ID
+		entry.DailyLogID = &id
+		entry.Flow = l.Flow
+		if l.Mood != "" {
+			entry.Mood = l.Mood
+		}
+		entry.Symptoms = append(entry.Symptoms, splitSymptoms(l.Symptoms)...)
+	}
+
+	segments := buildCycleSegments(baseline, to, !paused)
+
+	calendar := &CycleCalendar{
+		From:               from.Format(calendarDateLayout),
+		To:                 to.Format(calendarDateLayout),
+		TrackingMode:       mode,
+		PredictionsPaused:  paused,
+		AverageCycleLength: averageCycleLength(baseline),
+		Days:               []CalendarDay{},
+
+		OnHormonalContraception: hormonal,
+	}
+
+	seg := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		entry := CalendarDay{Date: day.Format(calendarDateLayout), Logged: logged[day.Format(calendarDateLayout)]}
+
+		// Advance to the last segment starting on or before this day
+		for seg+1 < len(segments) && !segments[seg+1].Start.After(day) {
+			seg++
+		}
+		if len(segments) > 0 && !segments[seg].Start.After(day) {
+			current := segments[seg]
+			// Past the end of the final logged cycle with predictions paused
+			if paused && daysBetween(current.Start, day) >= current.Length {
+				calendar.Days = append(calendar.Days, entry)
+				continue
+			}
+			entry.Phase, entry.CycleDay, entry.Fertility = dayPhase(current, day)
+			entry.IsPredicted = current.Predicted
+			if hormonal {
+				if entry.Phase != PhaseMenstrual {
+					entry.Phase = ""
+				}
+				entry.Fertility = ""
+			}
+		}
+
+		calendar.Days = append(calendar.Days, entry)
+	}
+
+	return calendar, nil
+}
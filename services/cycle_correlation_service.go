@@ -0,0 +1,308 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+)
+
+// Correlation analysis splits the luteal phase so premenstrual patterns stand out
+const (
+	CorrelationPhaseMenstrual   = PhaseMenstrual
+	CorrelationPhaseFollicular  = PhaseFollicular
+	CorrelationPhaseOvulation   = PhaseOvulation
+	CorrelationPhaseEarlyLuteal = "early_luteal"
+	CorrelationPhaseLateLuteal  = "late_luteal"
+)
+
+// lateLutealDays is how many days before the next period count as late luteal
+const lateLutealDays = 5
+
+// Minimum data before correlations are reported
+const (
+	MinCorrelationCycles     = 2  // completed cycles containing daily logs
+	MinCorrelationLoggedDays = 30 // daily logs within completed cycles
+	MinFeatureOccurrences    = 3  // times a mood/symptom must appear to be analysed
+)
+
+// Significance thresholds
+const (
+	correlationMinLift   = 1.5
+	correlationMinZScore = 1.96 // ~95% two-sided
+	prePeriodWindowDays  = 3
+	prePeriodMaxDays     = 14
+)
+
+// PhaseFeatureStat is how often a mood or symptom was logged in a phase
+type PhaseFeatureStat struct {
+	Feature     string  `json:"feature"`
+	Occurrences int     `json:"occurrences"`
+	Rate        float64 `json:"rate"` // share of logged days in the phase
+}
+
+// PhaseStats summarises the logged days in one phase
+type PhaseStats struct {
+	Phase      string             `json:"phase"`
+	LoggedDays int                `json:"logged_days"`
+	Features   []PhaseFeatureStat `json:"features"`
+}
+
+// CorrelationFinding is a statistically notable pattern
+type CorrelationFinding struct {
+	Feature string `json:"feature"`
+	Kind    string `json:"kind"` // "phase" or "pre_period"
+	Phase   string `json:"phase,omitempty"`
+	// Days-before-period window, set for "pre_period" findings
+	WindowStart int     `json:"window_start,omitempty"`
+	WindowEnd   int     `json:"window_end,omitempty"`
+	Lift        float64 `json:"lift"`
+	ZScore      float64 `json:"z_score"`
+	Message     string  `json:"message"`
+}
+
+// CorrelationInsights is the result of the mood/symptom/phase analysis
+type CorrelationInsights struct {
+	Sufficient      bool                 `json:"sufficient"`
+	Message         string               `json:"message,omitempty"`
+	CompletedCycles int                  `json:"completed_cycles"`
+	LoggedDays      int                  `json:"logged_days"`
+	Requirements    map[string]int       `json:"requirements"`
+	Phases          []PhaseStats         `json:"phases"`
+	Findings        []CorrelationFinding `json:"findings"`
+}
+
+type phasedObservation struct {
+	Phase            string
+	DaysBeforePeriod int
+	Features         []string
+}
+
+// moodFeature prefixes moods so they do not collide with symptom names
+func moodFeature(mood string) string {
+	return "mood:" + mood
+}
+
+func featureLabel(feature string) string {
+	if strings.HasPrefix(feature, "mood:") {
+		return fmt.Sprintf("%q mood", strings.TrimPrefix(feature, "mood:"))
+	}
+	return feature
+}
+
+func phaseLabel(phase string) string {
+	return strings.ReplaceAll(phase, "_", " ")
+}
+
+// twoProportionZ returns the pooled two-proportion z statistic
+func twoProportionZ(a, n1, b, n2 int) float64 {
+	if n1 == 0 || n2 == 0 {
+		return 0
+	}
+	p1 := float64(a) / float64(n1)
+	p2 := float64(b) / float64(n2)
+	pooled := float64(a+b) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 0
+	}
+	return (p1 - p2) / se
+}
+
+// lift compares two rates, smoothing an empty comparison group
+func lift(a, n1, b, n2 int) float64 {
+	p1 := float64(a) / float64(n1)
+	p2 := (float64(b) + 0.5) / (float64(n2) + 1)
+	return p1 / p2
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// observationsFromLogs places each daily log in the phase of a completed cycle.
+// The current (open) cycle is skipped because its next period is not known yet.
+func observationsFromLogs(cycles []models.Cycle, logs []models.DailyLog) ([]phasedObservation, int) {
+	segments := buildCycleSegments(cycles, truncateDay(cycles[len(cycles)-1].StartDate), false)
+	completed := segments[:len(segments)-1]
+
+	var observations []phasedObservation
+	cyclesWithLogs := map[int]bool{}
+	seg := 0
+	for _, l := range logs {
+		day := truncateDay(l.Date)
+		for seg+1 < len(completed) && !completed[seg+1].Start.After(day) {
+			seg++
+		}
+		if len(completed) == 0 || completed[seg].Start.After(day) || daysBetween(completed[seg].Start, day) >= completed[seg].Length {
+			continue
+		}
+		current := completed[seg]
+		phase, cycleDay, _ := dayPhase(current, day)
+		daysBefore := current.Length - cycleDay + 1
+		if phase == PhaseLuteal {
+			phase = CorrelationPhaseEarlyLuteal
+			if daysBefore <= lateLutealDays {
+				phase = CorrelationPhaseLateLuteal
+			}
+		}
+
+		var features []string
+		for _, s := range splitSymptoms(l.Symptoms) {
+			features = append(features, strings.ToLower(s))
+		}
+		if mood := strings.ToLower(strings.TrimSpace(l.Mood)); mood != "" {
+			features = append(features, moodFeature(mood))
+		}
+
+		cyclesWithLogs[seg] = true
+		observations = append(observations, phasedObservation{Phase: phase, DaysBeforePeriod: daysBefore, Features: features})
+	}
+	return observations, len(cyclesWithLogs)
+}
+
+// BuildCorrelationInsights relates logged moods and symptoms to cycle phases and
+// to the days before a period, reporting only patterns that pass significance thresholds.
+func BuildCorrelationInsights(userID uuid.UUID) (*CorrelationInsights, error) {
+	result := &CorrelationInsights{
+		Requirements: map[string]int{
+			"completed_cycles": MinCorrelationCycles,
+			"logged_days":      MinCorrelationLoggedDays,
+		},
+		Phases:   []PhaseStats{},
+		Findings: []CorrelationFinding{},
+	}
+
+	// Phases before a pregnancy do not line up with the cycles since
+	cycles, _, err := BaselineCycles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cycles) < 2 {
+		result.Message = "Log at least two periods and daily moods or symptoms to see patterns."
+		return result, nil
+	}
+
+	var logs []models.DailyLog
+	if err := config.DB.Where("user_id = ? AND date >= ?", userID, cycles[0].StartDate).
+		Order("date asc").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	observations, completedCycles := observationsFromLogs(cycles, logs)
+	result.CompletedCycles = completedCycles
+	result.LoggedDays = len(observations)
+	if completedCycles < MinCorrelationCycles || len(observations) < MinCorrelationLoggedDays {
+		result.Message = fmt.Sprintf("Not enough data yet. Keep logging daily: we need %d logged days across %d complete cycles.",
+			MinCorrelationLoggedDays, MinCorrelationCycles)
+		return result, nil
+	}
+	result.Sufficient = true
+
+	phases := []string{
+		CorrelationPhaseMenstrual, CorrelationPhaseFollicular, CorrelationPhaseOvulation,
+		CorrelationPhaseEarlyLuteal, CorrelationPhaseLateLuteal,
+	}
+	phaseDays := map[string]int{}
+	counts := map[string]map[string]int{} // feature -> phase -> occurrences
+	offsetDays := map[int]int{}           // days before period -> logged days
+	offsetCounts := map[string]map[int]int{}
+	totals := map[string]int{}
+
+	for _, o := range observations {
+		phaseDays[o.Phase]++
+		offsetDays[o.DaysBeforePeriod]++
+		for _, f := range o.Features {
+			if counts[f] == nil {
+				counts[f] = map[string]int{}
+				offsetCounts[f] = map[int]int{}
+			}
+			counts[f][o.Phase]++
+			offsetCounts[f][o.DaysBeforePeriod]++
+			totals[f]++
+		}
+	}
+
+	var features []string
+	for f, n := range totals {
+		if n >= MinFeatureOccurrences {
+			features = append(features, f)
+		}
+	}
+	sort.Strings(features)
+
+	total := len(observations)
+	for _, phase := range phases {
+		stats := PhaseStats{Phase: phase, LoggedDays: phaseDays[phase], Features: []PhaseFeatureStat{}}
+		for _, f := range features {
+			a := counts[f][phase]
+			if a == 0 || phaseDays[phase] == 0 {
+				continue
+			}
+			stats.Features = append(stats.Features, PhaseFeatureStat{
+				Feature:     f,
+				Occurrences: a,
+				Rate:        float64(a) / float64(phaseDays[phase]),
+			})
+
+			n1 := phaseDays[phase]
+			b, n2 := totals[f]-a, total-n1
+			if a < MinFeatureOccurrences || n2 == 0 {
+				continue
+			}
+			z := twoProportionZ(a, n1, b, n2)
+			l := lift(a, n1, b, n2)
+			if z >= correlationMinZScore && l >= correlationMinLift {
+				result.Findings = append(result.Findings, CorrelationFinding{
+					Feature: f,
+					Kind:    "phase",
+					Phase:   phase,
+					Lift:    round1(l),
+					ZScore:  round1(z),
+					Message: fmt.Sprintf("%s is %.1fx more likely in your %s phase", featureLabel(f), l, phaseLabel(phase)),
+				})
+			}
+		}
+		sort.Slice(stats.Features, func(i, j int) bool { return stats.Features[i].Rate > stats.Features[j].Rate })
+		result.Phases = append(result.Phases, stats)
+	}
+
+	// Look for a short window before the period where a mood or symptom clusters
+	for _, f := range features {
+		bestStart, bestZ, bestLift := 0, 0.0, 0.0
+		for start := 1; start+prePeriodWindowDays-1 <= prePeriodMaxDays; start++ {
+			a, n1 := 0, 0
+			for d := start; d < start+prePeriodWindowDays; d++ {
+				a += offsetCounts[f][d]
+				n1 += offsetDays[d]
+			}
+			b, n2 := totals[f]-a, total-n1
+			if a < MinFeatureOccurrences || n1 == 0 || n2 == 0 {
+				continue
+			}
+			z := twoProportionZ(a, n1, b, n2)
+			if z > bestZ {
+				bestStart, bestZ, bestLift = start, z, lift(a, n1, b, n2)
+			}
+		}
+		if bestStart > 0 && bestZ >= correlationMinZScore && bestLift >= correlationMinLift {
+			end := bestStart + prePeriodWindowDays - 1
+			result.Findings = append(result.Findings, CorrelationFinding{
+				Feature:     f,
+				Kind:        "pre_period",
+				WindowStart: bestStart,
+				WindowEnd:   end,
+				Lift:        round1(bestLift),
+				ZScore:      round1(bestZ),
+				Message:     fmt.Sprintf("%s clusters %d–%d days before your period", featureLabel(f), bestStart, end),
+			})
+		}
+	}
+
+	sort.SliceStable(result.Findings, func(i, j int) bool { return result.Findings[i].Lift > result.Findings[j].Lift })
+	return result, nil
+}
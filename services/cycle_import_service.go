@@ -0,0 +1,558 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+)
+
+// Import formats and layouts
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+
+	// ImportLayoutCycles has one row per cycle (period start date, optional length/mood/symptoms)
+	ImportLayoutCycles = "cycles"
+	// ImportLayoutDaily has one row per day with a flow column, as exported by
+	// trackers that log daily; consecutive bleeding days are grouped into periods
+	ImportLayoutDaily = "daily"
+)
+
+// ImportAsyncThreshold is the number of rows above which imports run in the background
+const ImportAsyncThreshold = 500
+
+// importStaleAfter is how long a job may stay pending or processing before it
+// is assumed to have been interrupted and is run again
+const importStaleAfter = 15 * time.Minute
+
+// Imported cycle lengths outside this range are rejected as implausible
+const (
+	minImportCycleLength = 10
+	maxImportCycleLength = 90
+)
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported import format")
+	ErrMissingStartColumn      = errors.New("could not find a start date column; provide a column mapping")
+	ErrAmbiguousImportDate     = errors.New("cannot tell day from month in this file's dates; set date_format, e.g. \"01/02/2006\" for month first")
+)
+
+// ImportColumnMapping names the file columns holding each field. Matching is
+// case-insensitive. Empty fields fall back to common header names.
+type ImportColumnMapping struct {
+	StartDate  string `json:"start_date"` // cycles layout: period start; daily layout: the day
+	Length     string `json:"length"`
+	Flow       string `json:"flow"` // daily layout only
+	Mood       string `json:"mood"`
+	Symptoms   string `json:"symptoms"`
+	DateFormat string `json:"date_format"` // Go layout, e.g. "2006-01-02" or "01/02/2006"
+}
+
+// Header names commonly used by tracker exports, tried when no mapping is given
+var importColumnAliases = map[string][]string{
+	"start_date": {"start_date", "start date", "period start", "period_start", "period start date", "cycle start", "date", "day"},
+	"length":     {"length", "cycle length", "cycle_length", "cycle length (days)"},
+	"flow":       {"flow", "period", "menstruation", "bleeding", "period flow"},
+	"mood":       {"mood", "moods", "feelings", "emotions"},
+	"symptoms":   {"symptoms", "symptom", "notes"},
+}
+
+// Date layouts tried when no date format is configured. Numeric dates like
+// 03/04/2026 are handled by slashDateLayout.
+var importDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+}
+
+// Day-first and month-first numeric dates
+const (
+	slashLayoutDayFirst   = "2/1/2006"
+	slashLayoutMonthFirst = "1/2/2006"
+)
+
+var slashDatePattern = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/\d{4}$`)
+
+// slashDateLayout works out whether the file's numeric dates are day or month
+// first from values above 12. It returns "" when the file gives no answer or
+// contradicts itself, so those dates can be rejected rather than guessed.
+func slashDateLayout(values []string) string {
+	dayFirst, monthFirst := false, false
+	for _, v := range values {
+		m := slashDatePattern.FindStringSubmatch(strings.TrimSpace(v))
+		if m == nil {
+			continue
+		}
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		if first > 12 {
+			dayFirst = true
+		}
+		if second > 12 {
+			monthFirst = true
+		}
+	}
+	switch {
+	case dayFirst && !monthFirst:
+		return slashLayoutDayFirst
+	case monthFirst && !dayFirst:
+		return slashLayoutMonthFirst
+	}
+	return ""
+}
+
+// ImportRowError describes why a row was not imported
+type ImportRowError struct {
+	Row   int    `json:"row"` // 1-based data row number (header excluded)
+	Error string `json:"error"`
+}
+
+type importRow struct {
+	Row       int
+	StartDate time.Time
+	Length    int
+	Mood      string
+	Symptoms  string
+}
+
+// ImportRequest carries the parsed request for a cycle import
+type ImportRequest struct {
+	UserID   uuid.UUID
+	Format   string
+	Layout   string
+	FileName string
+	Mapping  ImportColumnMapping
+	Data     []byte
+}
+
+// StartCycleImport creates an import job and processes it, in the background
+// for large files. The returned job reflects the final state for synchronous imports.
+func StartCycleImport(req ImportRequest) (*models.ImportJob, bool, error) {
+	if req.Format == "" {
+		req.Format = ImportFormatCSV
+	}
+	if req.Layout == "" {
+		req.Layout = ImportLayoutCycles
+	}
+	if req.Format != ImportFormatCSV && req.Format != ImportFormatJSON {
+		return nil, false, ErrUnsupportedImportFormat
+	}
+	if req.Layout != ImportLayoutCycles && req.Layout != ImportLayoutDaily {
+		return nil, false, ErrUnsupportedImportFormat
+	}
+
+	mapping, err := json.Marshal(req.Mapping)
+	if err != nil {
+		return nil, false, err
+	}
+	job := models.ImportJob{
+		ID:        uuid.New(),
+		UserID:    req.UserID,
+		Format:    req.Format,
+		Layout:    req.Layout,
+		FileName:  req.FileName,
+		Status:    models.ImportStatusPending,
+		Data:      req.Data,
+		Mapping:   string(mapping),
+		CreatedAt: time.Now(),
+	}
+
+	records, err := readImportRecords(req.Format, req.Data)
+	if err != nil {
+		return nil, false, err
+	}
+	job.TotalRows = len(records)
+	if err := config.DB.Create(&job).Error; err != nil {
+		return nil, false, err
+	}
+
+	if len(records) > ImportAsyncThreshold {
+		// Work on a copy so the returned job is not mutated concurrently
+		background := job
+		go func() {
+			if err := runCycleImport(&background, records, req); err != nil {
+				log.Printf("❌ Cycle import %s failed: %v", job.ID, err)
+			}
+		}()
+		return &job, true, nil
+	}
+
+	if err := runCycleImport(&job, records, req); err != nil {
+		return &job, false, err
+	}
+	return &job, false, nil
+}
+
+// GetImportErrors decodes the per-row errors stored on a job
+func GetImportErrors(job models.ImportJob) []ImportRowError {
+	errs := []ImportRowError{}
+	if job.Errors != "" {
+		_ = json.Unmarshal([]byte(job.Errors), &errs)
+	}
+	return errs
+}
+
+// readImportRecords normalises CSV and JSON input into header-keyed records
+func readImportRecords(format string, data []byte) ([]map[string]string, error) {
+	switch format {
+	case ImportFormatJSON:
+		var raw []map[string]interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		records := make([]map[string]string, 0, len(raw))
+		for _, item := range raw {
+			rec := map[string]string{}
+			for k, v := range item {
+				if v != nil {
+					rec[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(fmt.Sprint(v))
+				}
+			}
+			records = append(records, rec)
+		}
+		return records, nil
+	default:
+		r := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		header, err := r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("file is empty")
+			}
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		}
+		var records []map[string]string
+		for {
+			row, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid CSV: %w", err)
+			}
+			rec := map[string]string{}
+			for i, v := range row {
+				if i < len(header) {
+					rec[header[i]] = strings.TrimSpace(v)
+				}
+			}
+			records = append(records, rec)
+		}
+		return records, nil
+	}
+}
+
+// resolveColumn picks the configured column or the first matching alias present in the data
+func resolveColumn(configured, field string, records []map[string]string) string {
+	if configured != "" {
+		return strings.ToLower(strings.TrimSpace(configured))
+	}
+	if len(records) == 0 {
+		return ""
+	}
+	for _, alias := range importColumnAliases[field] {
+		if _, ok := records[0][alias]; ok {
+			return alias
+		}
+	}
+	return ""
+}
+
+// parseImportDate parses a date with the configured layout, or else the
+// common layouts plus the slash layout detected for the file
+func parseImportDate(value, layout, slashLayout string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, value)
+	}
+	if slashDatePattern.MatchString(value) {
+		if slashLayout == "" {
+			return time.Time{}, fmt.Errorf("%w (%q)", ErrAmbiguousImportDate, value)
+		}
+		return time.Parse(slashLayout, value)
+	}
+	for _, l := range importDateLayouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// isFlowDay reports whether a daily flow value indicates bleeding
+func isFlowDay(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "0", "none", "no", "false", "n", "spotting":
+		return false
+	}
+	return true
+}
+
+func runCycleImport(job *models.ImportJob, records []map[string]string, req ImportRequest) error {
+	started := time.Now()
+	job.Status = models.ImportStatusProcessing
+	job.StartedAt = &started
+	if err := config.DB.Save(job).Error; err != nil {
+		return err
+	}
+
+	fail := func(reason error) error {
+		completed := time.Now()
+		job.Status = models.ImportStatusFailed
+		job.FailureReason = reason.Error()
+		job.CompletedAt = &completed
+		job.Data = nil
+		if err := config.DB.Save(job).Error; err != nil {
+			return err
+		}
+		return reason
+	}
+
+	mapping := req.Mapping
+	startCol := resolveColumn(mapping.StartDate, "start_date", records)
+	if startCol == "" {
+		return fail(ErrMissingStartColumn)
+	}
+
+	slashLayout := ""
+	if mapping.DateFormat == "" {
+		values := make([]string, 0, len(records))
+		for _, rec := range records {
+			values = append(values, rec[startCol])
+		}
+		slashLayout = slashDateLayout(values)
+	}
+
+	var rows []importRow
+	var rowErrors []ImportRowError
+	now := time.Now()
+
+	switch req.Layout {
+	case ImportLayoutDaily:
+		flowCol := resolveColumn(mapping.Flow, "flow", records)
+		if flowCol == "" {
+			return fail(errors.New("could not find a flow column; provide a column mapping"))
+		}
+		moodCol := resolveColumn(mapping.Mood, "mood", records)
+		symptomsCol := resolveColumn(mapping.Symptoms, "symptoms", records)
+
+		type flowDay struct {
+			row  int
+			date time.Time
+			rec  map[string]string
+		}
+		var days []flowDay
+		for i, rec := range records {
+			if !isFlowDay(rec[flowCol]) {
+				continue
+			}
+			d, err := parseImportDate(rec[startCol], mapping.DateFormat, slashLayout)
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Row: i + 1, Error: err.Error()})
+				continue
+			}
+			days = append(days, flowDay{row: i + 1, date: truncateDay(d), rec: rec})
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i].date.Before(days[j].date) })
+
+		// Consecutive bleeding days form one period; a gap starts a new one
+		for i, d := range days {
+			if i > 0 && daysBetween(days[i-1].date, d.date) <= 1 {
+				continue
+			}
+			rows = append(rows, importRow{
+				Row:       d.row,
+				StartDate: d.date,
+				Mood:      d.rec[moodCol],
+				Symptoms:  d.rec[symptomsCol],
+			})
+		}
+	default:
+		lengthCol := resolveColumn(mapping.Length, "length", records)
+		moodCol := resolveColumn(mapping.Mood, "mood", records)
+		symptomsCol := resolveColumn(mapping.Symptoms, "symptoms", records)
+
+		for i, rec := range records {
+			d, err := parseImportDate(rec[startCol], mapping.DateFormat, slashLayout)
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Row: i + 1, Error: err.Error()})
+				continue
+			}
+			row := importRow{Row: i + 1, StartDate: truncateDay(d), Mood: rec[moodCol], Symptoms: rec[symptomsCol]}
+			if v := rec[lengthCol]; lengthCol != "" && v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < minImportCycleLength || n > maxImportCycleLength {
+					rowErrors = append(rowErrors, ImportRowError{Row: i + 1, Error: fmt.Sprintf("invalid cycle length %q", v)})
+					continue
+				}
+				row.Length = n
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	// Reject future dates
+	valid := rows[:0]
+	for _, r := range rows {
+		if r.StartDate.After(now) {
+			rowErrors = append(rowErrors, ImportRowError{Row: r.Row, Error: "start date is in the future"})
+			continue
+		}
+		valid = append(valid, r)
+	}
+	rows = valid
+
+	// Deduplicate against existing cycles and within the file
+	var existing []models.Cycle
+	if err := config.DB.Where("user_id = ?", req.UserID).Find(&existing).Error; err != nil {
+		return fail(err)
+	}
+	seen := map[string]bool{}
+	for _, c := range existing {
+		seen[truncateDay(c.StartDate).Format(calendarDateLayout)] = true
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].StartDate.Before(rows[j].StartDate) })
+	var toInsert []importRow
+	for _, r := range rows {
+		key := r.StartDate.Format(calendarDateLayout)
+		if seen[key] {
+			job.DuplicateRows++
+			continue
+		}
+		seen[key] = true
+		toInsert = append(toInsert, r)
+	}
+
+	// Derive missing lengths from the gap to the next period (imported or existing)
+	starts := make([]time.Time, 0, len(existing)+len(toInsert))
+	for _, c := range existing {
+		starts = append(starts, truncateDay(c.StartDate))
+	}
+	for _, r := range toInsert {
+		starts = append(starts, r.StartDate)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	var gaps []models.Cycle
+	for i := range toInsert {
+		if toInsert[i].Length > 0 {
+			continue
+		}
+		idx := sort.Search(len(starts), func(k int) bool { return starts[k].After(toInsert[i].StartDate) })
+		if idx < len(starts) {
+			if gap := daysBetween(toInsert[i].StartDate, starts[idx]); gap >= minImportCycleLength && gap <= maxImportCycleLength {
+				toInsert[i].Length = gap
+				gaps = append(gaps, models.Cycle{Length: gap})
+			}
+		}
+	}
+	fallback := averageCycleLength(append(gaps, existing...))
+
+	cycles := make([]models.Cycle, 0, len(toInsert))
+	for _, r := range toInsert {
+		length := r.Length
+		if length == 0 {
+			length = fallback
+		}
+		cycles = append(cycles, models.Cycle{
+			UserID:    req.UserID,
+			StartDate: r.StartDate,
+			Length:    length,
+			Mood:      r.Mood,
+			Symptoms:  r.Symptoms,
+		})
+	}
+
+	if len(cycles) > 0 {
+		if err := config.DB.CreateInBatches(&cycles, 100).Error; err != nil {
+			return fail(err)
+		}
+		// An imported period after a pregnancy resumes cycle tracking, as a logged one does
+		if err := ResumeCyclesIfPeriodReturned(req.UserID, cycles[len(cycles)-1]); err != nil {
+			log.Printf("❌ Failed to update tracking mode after import %s: %v", job.ID, err)
+		}
+	}
+
+	sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	encoded, _ := json.Marshal(rowErrors)
+
+	completed := time.Now()
+	job.ImportedRows = len(cycles)
+	job.ErrorRows = len(rowErrors)
+	job.Errors = string(encoded)
+	job.Status = models.ImportStatusCompleted
+	job.CompletedAt = &completed
+	job.Data = nil
+	return config.DB.Save(job).Error
+}
+
+// ResumeInterruptedImports runs again the jobs left pending or processing by a
+// restart. Cycles already saved by the first attempt are skipped as duplicates.
+func ResumeInterruptedImports(now time.Time) error {
+	var jobs []models.ImportJob
+	if err := config.DB.Where("status IN ? AND COALESCE(started_at, created_at) < ?",
+		[]string{models.ImportStatusPending, models.ImportStatusProcessing}, now.Add(-importStaleAfter)).
+		Find(&jobs).Error; err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		// Claim the job so that only one instance picks it up
+		claim := config.DB.Model(&models.ImportJob{}).
+			Where("id = ? AND status = ? AND started_at IS NOT DISTINCT FROM ?", job.ID, job.Status, job.StartedAt).
+			Update("started_at", now)
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		req := ImportRequest{UserID: job.UserID, Format: job.Format, Layout: job.Layout, FileName: job.FileName, Data: job.Data}
+		if job.Mapping != "" {
+			_ = json.Unmarshal([]byte(job.Mapping), &req.Mapping)
+		}
+		var records []map[string]string
+		err := errors.New("the import was interrupted; please upload the file again")
+		if len(job.Data) > 0 {
+			records, err = readImportRecords(job.Format, job.Data)
+		}
+		if err != nil {
+			completed := time.Now()
+			job.Status = models.ImportStatusFailed
+			job.FailureReason = err.Error()
+			job.CompletedAt = &completed
+			job.Data = nil
+			if err := config.DB.Save(job).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		log.Printf("🔄 Resuming interrupted cycle import %s", job.ID)
+		if err := runCycleImport(job, records, req); err != nil {
+			log.Printf("❌ Cycle import %s failed: %v", job.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSlashDateLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"day first", []string{"03/04/2026", "25/04/2026"}, slashLayoutDayFirst},
+		{"month first", []string{"03/04/2026", "04/25/2026"}, slashLayoutMonthFirst},
+		{"all ambiguous", []string{"03/04/2026", "05/06/2026"}, ""},
+		{"contradictory", []string{"25/04/2026", "04/25/2026"}, ""},
+		{"no slash dates", []string{"2026-04-03"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slashDateLayout(tt.values); got != tt.want {
+				t.Fatalf("slashDateLayout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	// A US export: 04/25 proves month first, so 03/04 is 4 March
+	got, err := parseImportDate("03/04/2026", "", slashDateLayout([]string{"03/04/2026", "04/25/2026"}))
+	if err != nil || !got.Equal(date(2026, 3, 4)) {
+		t.Fatalf("month-first date: got %v, %v", got, err)
+	}
+	got, err = parseImportDate("3/4/2026", "", slashLayoutDayFirst)
+	if err != nil || !got.Equal(date(2026, 4, 3)) {
+		t.Fatalf("day-first date: got %v, %v", got, err)
+	}
+	if _, err := parseImportDate("03/04/2026", "", ""); !errors.Is(err, ErrAmbiguousImportDate) {
+		t.Fatalf("ambiguous date: err = %v, want ErrAmbiguousImportDate", err)
+	}
+	got, err = parseImportDate("03/04/2026", "01/02/2006", "")
+	if err != nil || !got.Equal(date(2026, 3, 4)) {
+		t.Fatalf("explicit format: got %v, %v", got, err)
+	}
+	if got, err := parseImportDate("2026-04-03", "", ""); err != nil || !got.Equal(date(2026, 4, 3)) {
+		t.Fatalf("ISO date: got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+// Booking rules
+const (
+	// DefaultAppointmentMinutes is used when a booking has no appointment type
+	DefaultAppointmentMinutes = 30
+	minAppointmentMinutes     = 5
+	maxAppointmentMinutes     = 8 * 60
+	// MaxSlotRangeDays caps how far a single slot search may span
+	MaxSlotRangeDays = 31
+)
+
+var (
+	ErrInvalidTimeZone         = errors.New("time_zone must be an IANA zone name such as Africa/Nairobi")
+	ErrInvalidAvailability     = errors.New("invalid availability")
+	ErrInvalidTimeOff          = errors.New("time off must end after it starts")
+	ErrInvalidAppointmentType  = errors.New("appointment types need a name and a duration between 5 and 480 minutes")
+	ErrAppointmentTypeNotFound = errors.New("appointment type not found for this doctor")
+	ErrSlotOutsideAvailability = errors.New("the requested time is outside the doctor's availability")
+	ErrSlotTaken               = errors.New("the requested time overlaps another appointment or the doctor's time off")
+	ErrSlotRangeTooLong        = fmt.Errorf("slot searches may span at most %d days", MaxSlotRangeDays)
+)
+
+// activeAppointmentStatuses occupy the doctor's calendar
+var activeAppointmentStatuses = []string{models.AppointmentRequested, models.AppointmentConfirmed}
+
+// parseClock reads "HH:MM" as minutes after midnight
+func parseClock(v string) (int, error) {
+	parts := strings.Split(v, ":")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("%w: times must be HH:MM", ErrInvalidAvailability)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%w: times must be HH:MM", ErrInvalidAvailability)
+	}
+	return h*60 + m, nil
+}
+
+// AvailabilityWindow is one weekly window in a schedule update
+type AvailabilityWindow struct {
+	Weekday   int    `json:"weekday"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// SetDoctorAvailability replaces a doctor's weekly schedule. Windows are local
+// to timeZone, so slots follow daylight-saving changes.
+func SetDoctorAvailability(doctorID uuid.UUID, timeZone string, windows []AvailabilityWindow) ([]models.DoctorAvailability, error) {
+	if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "" {
+		return nil, ErrInvalidTimeZone
+	}
+
+	type span struct{ start, end int }
+	byDay := map[int][]span{}
+	now := time.Now()
+	rows := make([]models.DoctorAvailability, 0, len(windows))
+	for _, w := range windows {
+		if w.Weekday < 0 || w.Weekday > 6 {
+			return nil, fmt.Errorf("%w: weekday must be 0 (Sunday) to 6 (Saturday)", ErrInvalidAvailability)
+		}
+		start, err := parseClock(w.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(w.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("%w: %s-%s ends before it starts", ErrInvalidAvailability, w.StartTime, w.EndTime)
+		}
+		for _, other := range byDay[w.Weekday] {
+			if start < other.end && other.start < end {
+				return nil, fmt.Errorf("%w: windows on weekday %d overlap", ErrInvalidAvailability, w.Weekday)
+			}
+		}
+		byDay[w.Weekday] = append(byDay[w.Weekday], span{start, end})
+		rows = append(rows, models.DoctorAvailability{
+			ID:        uuid.New(),
+			DoctorID:  doctorID,
+			Weekday:   w.Weekday,
+			StartTime: w.StartTime,
+			EndTime:   w.EndTime,
+			TimeZone:  timeZone,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Wait for bookings in flight so none is checked against the old schedule
+		if err := lockDoctorSchedule(tx, doctorID); err != nil {
+			return err
+		}
+		if err := tx.Where("doctor_id = ?", doctorID).Delete(&models.DoctorAvailability{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetDoctorAvailability returns a doctor's weekly windows
+func GetDoctorAvailability(db *gorm.DB, doctorID uuid.UUID) ([]models.DoctorAvailability, error) {
+	windows := []models.DoctorAvailability{}
+	err := db.Where("doctor_id = ?", doctorID).Order("weekday asc, start_time asc").Find(&windows).Error
+	return windows, err
+}
+
+// AddDoctorTimeOff blocks out a period and returns the active appointments it overlaps,
+// which the doctor still needs to reschedule or cancel
+func AddDoctorTimeOff(doctorID uuid.UUID, startsAt, endsAt time.Time, reason string) (*models.DoctorTimeOff, []models.Appointment, error) {
+	if !endsAt.After(startsAt) {
+		return nil, nil, ErrInvalidTimeOff
+	}
+	timeOff := models.DoctorTimeOff{
+		ID:        uuid.New(),
+		DoctorID:  doctorID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	affected := []models.Appointment{}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialised with bookings, so none lands in the period unreported
+		if err := lockDoctorSchedule(tx, doctorID); err != nil {
+			return err
+		}
+		if err := tx.Create(&timeOff).Error; err != nil {
+			return err
+		}
+		return tx.Where("doctor_id = ? AND status IN ? AND scheduled_at < ? AND ends_at > ?",
+			doctorID, activeAppointmentStatuses, endsAt, startsAt).Order("scheduled_at asc").Find(&affected).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &timeOff, affected, nil
+}
+
+// ValidateAppointmentType checks a doctor-supplied appointment type
+func ValidateAppointmentType(t *models.AppointmentType) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || t.DurationMinutes < minAppointmentMinutes || t.DurationMinutes > maxAppointmentMinutes {
+		return ErrInvalidAppointmentType
+	}
+	return nil
+}
+
+// GetActiveAppointmentType loads one of a doctor's bookable appointment types
+func GetActiveAppointmentType(doctorID, typeID uuid.UUID) (*models.AppointmentType, error) {
+	var t models.AppointmentType
+	err := config.DB.Where("id = ? AND doctor_id = ? AND active = ?", typeID, doctorID, true).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAppointmentTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Slot is a bookable interval
+type Slot struct {
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	LocalStart string    `json:"local_start"` // in the requested time zone
+}
+
+// windowOccurrences lists every window instance that overlaps [from, to).
+// Wall-clock times that do not exist on a day (a DST gap) are skipped.
+func windowOccurrences(windows []models.DoctorAvailability, from, to time.Time) ([][2]time.Time, error) {
+	var occurrences [][2]time.Time
+	for _, w := range windows {
+		loc, err := time.LoadLocation(w.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		startMin, err := parseClock(w.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		endMin, err := parseClock(w.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		first := from.In(loc)
+		day := time.Date(first.Year(), first.Month(), first.Day()-1, 0, 0, 0, 0, loc)
+		for ; day.Before(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+			if int(day.Weekday()) != w.Weekday {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), startMin/60, startMin%60, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), endMin/60, endMin%60, 0, 0, loc)
+			if start.Hour()*60+start.Minute() != startMin {
+				continue
+			}
+			if end.After(from) && start.Before(to) {
+				occurrences = append(occurrences, [2]time.Time{start, end})
+			}
+		}
+	}
+	return occurrences, nil
+}
+
+// busyIntervals returns the doctor's active appointments and time off overlapping [from, to)
+func busyIntervals(db *gorm.DB, doctorID uuid.UUID, from, to time.Time, excludeAppointment *uuid.UUID) ([][2]time.Time, error) {
+	query := db.Where("doctor_id = ? AND status IN ? AND scheduled_at < ? AND ends_at > ?",
+		doctorID, activeAppointmentStatuses, to, from)
+	if excludeAppointment != nil {
+		query = query.Where("id <> ?", *excludeAppointment)
+	}
+	var appts []models.Appointment
+	if err := query.Find(&appts).Error; err != nil {
+		return nil, err
+	}
+	var offs []models.DoctorTimeOff
+	if err := db.Where("doctor_id = ? AND starts_at < ? AND ends_at > ?", doctorID, to, from).Find(&offs).Error; err != nil {
+		return nil, err
+	}
+
+	busy := make([][2]time.Time, 0, len(appts)+len(offs))
+	for _, a := range appts {
+		busy = append(busy, [2]time.Time{a.ScheduledAt, a.EndsAt})
+	}
+	for _, o := range offs {
+		busy = append(busy, [2]time.Time{o.StartsAt, o.EndsAt})
+	}
+	return busy, nil
+}
+
+func overlapsAny(start, end time.Time, busy [][2]time.Time) bool {
+	for _, b := range busy {
+		if start.Before(b[1]) && b[0].Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableSlots lists free slots of a given length between from and to,
+// stepping through each availability window from its start
+func AvailableSlots(doctorID uuid.UUID, duration time.Duration, from, to time.Time, displayIn *time.Location, now time.Time) ([]Slot, error) {
+	if to.Sub(from) > MaxSlotRangeDays*24*time.Hour {
+		return nil, ErrSlotRangeTooLong
+	}
+	if from.Before(now) {
+		from = now
+	}
+	slots := []Slot{}
+	if !to.After(from) {
+		return slots, nil
+	}
+
+	windows, err := GetDoctorAvailability(config.DB, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	occurrences, err := windowOccurrences(windows, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := busyIntervals(config.DB, doctorID, from, to, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, occ := range occurrences {
+		for start := occ[0]; !start.Add(duration).After(occ[1]); start = start.Add(duration) {
+			end := start.Add(duration)
+			if start.Before(from) || end.After(to) || overlapsAny(start, end, busy) {
+				continue
+			}
+			slots = append(slots, Slot{
+				StartsAt:   start.UTC(),
+				EndsAt:     end.UTC(),
+				LocalStart: start.In(displayIn).Format(time.RFC3339),
+			})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].StartsAt.Before(slots[j].StartsAt) })
+	return slots, nil
+}
+
+// lockDoctorSchedule serialises bookings for one doctor until the transaction ends
+func lockDoctorSchedule(tx *gorm.DB, doctorID uuid.UUID) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "doctor-schedule:"+doctorID.String()).Error
+}
+
+// withinAvailability reports whether [start, end) fits inside one of the doctor's windows
+func withinAvailability(tx *gorm.DB, doctorID uuid.UUID, start, end time.Time) (bool, error) {
+	windows, err := GetDoctorAvailability(tx, doctorID)
+	if err != nil {
+		return false, err
+	}
+	occurrences, err := windowOccurrences(windows, start, end)
+	if err != nil {
+		return false, err
+	}
+	for _, occ := range occurrences {
+		if !start.Before(occ[0]) && !end.After(occ[1]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reserveSlot checks, under the doctor's schedule lock, that [start, end) is
+// free. Patients must also book inside published availability; doctors may
+// book outside it.
+func reserveSlot(tx *gorm.DB, doctorID uuid.UUID, start, end time.Time, byPatient bool, excludeAppointment *uuid.UUID) error {
+	if err := lockDoctorSchedule(tx, doctorID); err != nil {
+		return err
+	}
+	if byPatient {
+		ok, err := withinAvailability(tx, doctorID, start, end)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrSlotOutsideAvailability
+		}
+	}
+	busy, err := busyIntervals(tx, doctorID, start, end, excludeAppointment)
+	if err != nil {
+		return err
+	}
+	if overlapsAny(start, end, busy) {
+		return ErrSlotTaken
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestParseClock(t *testing.T) {
+	cases := []struct {
+		in      string
+		minutes int
+		ok      bool
+	}{
+		{"00:00", 0, true},
+		{"09:30", 570, true},
+		{"23:59", 1439, true},
+		{"24:00", 1440, true},
+		{"24:30", 0, false},
+		{"12:60", 0, false},
+		{"9:00", 0, false},
+		{"09:00:00", 0, false},
+		{"ab:cd", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		got, err := parseClock(tc.in)
+		if tc.ok && (err != nil || got != tc.minutes) {
+			t.Errorf("parseClock(%q) = %d, %v; want %d", tc.in, got, err, tc.minutes)
+		}
+		if !tc.ok && !errors.Is(err, ErrInvalidAvailability) {
+			t.Errorf("parseClock(%q) = %d, %v; want ErrInvalidAvailability", tc.in, got, err)
+		}
+	}
+}
+
+func TestWindowOccurrencesAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	utc := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	window := func(start, end string) []models.DoctorAvailability {
+		return []models.DoctorAvailability{{Weekday: int(time.Sunday), StartTime: start, EndTime: end, TimeZone: "America/New_York"}}
+	}
+
+	cases := []struct {
+		name     string
+		windows  []models.DoctorAvailability
+		from, to time.Time
+		want     [][2]time.Time
+	}{
+		{
+			// Clocks jump from 02:00 to 03:00 on March 8, so 02:30 does not exist that day
+			name:    "start in the spring-forward gap is skipped",
+			windows: window("02:30", "04:00"),
+			from:    utc(time.March, 1, 0),
+			to:      utc(time.March, 16, 0),
+			want: [][2]time.Time{
+				{time.Date(2026, 3, 1, 2, 30, 0, 0, newYork), time.Date(2026, 3, 1, 4, 0, 0, 0, newYork)},
+				{time.Date(2026, 3, 15, 2, 30, 0, 0, newYork), time.Date(2026, 3, 15, 4, 0, 0, 0, newYork)},
+			},
+		},
+		{
+			name:    "24:00 ends at the next local midnight",
+			windows: window("20:00", "24:00"),
+			from:    utc(time.March, 8, 12),
+			to:      utc(time.March, 9, 12),
+			want:    [][2]time.Time{{utc(time.March, 9, 0), utc(time.March, 9, 4)}},
+		},
+		{
+			// November 1 has 25 hours when clocks fall back
+			name:    "whole day on the fall-back day",
+			windows: window("00:00", "24:00"),
+			from:    utc(time.November, 1, 0),
+			to:      utc(time.November, 3, 0),
+			want:    [][2]time.Time{{utc(time.November, 1, 4), utc(time.November, 2, 5)}},
+		},
+	}
+	for _, tc := range cases {
+		got, err := windowOccurrences(tc.windows, tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %d occurrences %v, want %d", tc.name, len(got), got, len(tc.want))
+		}
+		for i := range tc.want {
+			if !got[i][0].Equal(tc.want[i][0]) || !got[i][1].Equal(tc.want[i][1]) {
+				t.Errorf("%s: occurrence %d = %v-%v, want %v-%v", tc.name, i,
+					got[i][0].UTC(), got[i][1].UTC(), tc.want[i][0].UTC(), tc.want[i][1].UTC())
+			}
+		}
+	}
+}
+
+func TestOverlapsAny(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2026, 6, 1, hour, minute, 0, 0, time.UTC) }
+	busy := [][2]time.Time{{at(9, 0), at(10, 0)}, {at(13, 0), at(14, 30)}}
+
+	cases := []struct {
+		name       string
+		start, end time.Time
+		want       bool
+	}{
+		{"before everything", at(8, 0), at(9, 0), false},
+		{"ends as the next begins", at(10, 0), at(10, 30), false},
+		{"between busy periods", at(11, 0), at(12, 0), false},
+		{"overlaps the start", at(8, 30), at(9, 30), true},
+		{"inside a busy period", at(13, 30), at(14, 0), true},
+		{"covers a busy period", at(12, 0), at(15, 0), true},
+		{"overlaps the end", at(14, 0), at(15, 0), true},
+	}
+	for _, tc := range cases {
+		if got := overlapsAny(tc.start, tc.end, busy); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if overlapsAny(at(9, 0), at(10, 0), nil) {
+		t.Error("nothing overlaps an empty schedule")
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+)
+
+// EPDSItem is a question on the Edinburgh Postnatal Depression Scale. Options are
+// served in the published order; Reverse items score 3 for the first option.
+type EPDSItem struct {
+	Number  int      `json:"number"`
+	Text    string   `json:"text"`
+	Options []string `json:"options"`
+	Reverse bool     `json:"-"`
+}
+
+// EPDSPrompt introduces the questionnaire
+const EPDSPrompt = "Please choose the answer that comes closest to how you have felt in the past 7 days, not just how you feel today."
+
+// EPDSItems lists the 10 EPDS questions in order
+var EPDSItems = []EPDSItem{
+	{1, "I have been able to laugh and see the funny side of things", []string{"As much as I always could", "Not quite so much now", "Definitely not so much now", "Not at all"}, false},
+	{2, "I have looked forward with enjoyment to things", []string{"As much as I ever did", "Rather less than I used to", "Definitely less than I used to", "Hardly at all"}, false},
+	{3, "I have blamed myself unnecessarily when things went wrong", []string{"Yes, most of the time", "Yes, some of the time", "Not very often", "No, never"}, true},
+	{4, "I have been anxious or worried for no good reason", []string{"No, not at all", "Hardly ever", "Yes, sometimes", "Yes, very often"}, false},
+	{5, "I have felt scared or panicky for no very good reason", []string{"Yes, quite a lot", "Yes, sometimes", "No, not much", "No, not at all"}, true},
+	{6, "Things have been getting on top of me", []string{"Yes, most of the time I haven't been able to cope at all", "Yes, sometimes I haven't been coping as well as usual", "No, most of the time I have coped quite well", "No, I have been coping as well as ever"}, true},
+	{7, "I have been so unhappy that I have had difficulty sleeping", []string{"Yes, most of the time", "Yes, sometimes", "Not very often", "No, not at all"}, true},
+	{8, "I have felt sad or miserable", []string{"Yes, most of the time", "Yes, quite often", "Not very often", "No, not at all"}, true},
+	{9, "I have been so unhappy that I have been crying", []string{"Yes, most of the time", "Yes, quite often", "Only occasionally", "No, never"}, true},
+	{10, "The thought of harming myself has occurred to me", []string{"Yes, quite often", "Sometimes", "Hardly ever", "Never"}, true},
+}
+
+// EPDS scoring thresholds
+const (
+	EPDSSelfHarmItem     = 10
+	EPDSEscalationScore  = 13 // probable depression
+	EPDSPossibleScore    = 10
+	EPDSAnxietyThreshold = 6 // EPDS-3A subscale, items 3–5
+)
+
+// EPDS clinical alert rules
+const (
+	RuleEPDSHighScore = "epds_high_score"
+	RuleEPDSSelfHarm  = "epds_self_harm"
+)
+
+// CrisisResource is a support line shown when a screening is escalated
+type CrisisResource struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact"`
+	Hours   string `json:"hours"`
+}
+
+// EPDSCrisisResources are returned with every escalated screening
+var EPDSCrisisResources = []CrisisResource{
+	{"Emergency services", "Call 911 if you are in immediate danger", "24/7"},
+	{"988 Suicide & Crisis Lifeline", "Call or text 988", "24/7"},
+	{"National Maternal Mental Health Hotline", "Call or text 1-833-852-6262", "24/7"},
+	{"Postpartum Support International HelpLine", "Call 1-800-944-4773 or text \"HELP\" to 800-944-4773", "Messages returned within 24 hours"},
+}
+
+var ErrInvalidEPDSAnswers = errors.New("answers must contain 10 values between 0 and 3")
+
+// EPDSResult is the scored questionnaire
+type EPDSResult struct {
+	Score         int
+	AnxietyScore  int
+	SelfHarmScore int
+	RiskLevel     string
+	Escalated     bool
+}
+
+// ScoreEPDS scores the option chosen for each item
+func ScoreEPDS(answers []int64) (EPDSResult, error) {
+	var result EPDSResult
+	if len(answers) != len(EPDSItems) {
+		return result, ErrInvalidEPDSAnswers
+	}
+	for i, a := range answers {
+		if a < 0 || a > 3 {
+			return result, ErrInvalidEPDSAnswers
+		}
+		item := EPDSItems[i]
+		score := int(a)
+		if item.Reverse {
+			score = 3 - score
+		}
+		result.Score += score
+		if item.Number >= 3 && item.Number <= 5 {
+			result.AnxietyScore += score
+		}
+		if item.Number == EPDSSelfHarmItem {
+			result.SelfHarmScore = score
+		}
+	}
+
+	switch {
+	case result.Score >= EPDSEscalationScore:
+		result.RiskLevel = models.EPDSRiskProbable
+	case result.Score >= EPDSPossibleScore:
+		result.RiskLevel = models.EPDSRiskPossible
+	default:
+		result.RiskLevel = models.EPDSRiskLow
+	}
+	result.Escalated = result.Score >= EPDSEscalationScore || result.SelfHarmScore > 0
+	return result, nil
+}
+
+// weeksSinceLastPregnancy returns the weeks since the user's most recent pregnancy ended
+func weeksSinceLastPregnancy(userID uuid.UUID, now time.Time) (*int, error) {
+	var pregnancies []models.Pregnancy
+	if err := config.DB.Where("user_id = ? AND end_date IS NOT NULL AND end_date <= ?", userID, now).
+		Order("end_date desc").Limit(1).Find(&pregnancies).Error; err != nil {
+		return nil, err
+	}
+	if len(pregnancies) == 0 {
+		return nil, nil
+	}
+	weeks := daysBetween(*pregnancies[0].EndDate, now) / 7
+	return &weeks, nil
+}
+
+// SubmitEPDS scores and stores a questionnaire and escalates it when the score is
+// 13 or more, or when the self-harm item is answered with anything but "Never"
+func SubmitEPDS(userID uuid.UUID, answers []int64, now time.Time) (*models.EPDSResponse, error) {
+	result, err := ScoreEPDS(answers)
+	if err != nil {
+		return nil, err
+	}
+	weeks, err := weeksSinceLastPregnancy(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	response := models.EPDSResponse{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Answers:         pq.Int64Array(answers),
+		Score:           result.Score,
+		AnxietyScore:    result.AnxietyScore,
+		SelfHarmScore:   result.SelfHarmScore,
+		RiskLevel:       result.RiskLevel,
+		Escalated:       result.Escalated,
+		WeeksPostpartum: weeks,
+		CompletedAt:     now,
+		CreatedAt:       now,
+	}
+	if err := config.DB.Create(&response).Error; err != nil {
+		return nil, err
+	}
+
+	if result.Escalated {
+		if err := escalateEPDS(&response); err != nil {
+			return &response, err
+		}
+	}
+	return &response, nil
+}
+
+// escalateEPDS raises clinical alerts, which notify the user and their consenting care team
+func escalateEPDS(response *models.EPDSResponse) error {
+	value := fmt.Sprintf("EPDS %d/30", response.Score)
+	if response.SelfHarmScore > 0 {
+		if err := raiseClinicalAlert(response.UserID, nil, models.AlertSourceEPDSResponse, response.ID, response.CompletedAt, pendingAlert{
+			Rule:     RuleEPDSSelfHarm,
+			Severity: models.AlertSeverityUrgent,
+			Message:  "You mentioned thoughts of harming yourself. You are not alone and help is available right now: call or text 988, or call 911 if you are in immediate danger.",
+			Value:    value,
+		}); err != nil {
+			return err
+		}
+	}
+	if response.Score >= EPDSEscalationScore {
+		if err := raiseClinicalAlert(response.UserID, nil, models.AlertSourceEPDSResponse, response.ID, response.CompletedAt, pendingAlert{
+			Rule:     RuleEPDSHighScore,
+			Severity: models.AlertSeverityWarning,
+			Message:  "Your answers suggest you may be going through postpartum depression. It is common and treatable. Please reach out to your care provider, or call or text the Maternal Mental Health Hotline at 1-833-852-6262.",
+			Value:    value,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
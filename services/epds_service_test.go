@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestScoreEPDS(t *testing.T) {
+	cases := []struct {
+		name      string
+		answers   []int64
+		score     int
+		anxiety   int
+		risk      string
+		escalated bool
+	}{
+		// The first option of every item: items 1, 2 and 4 score 0, reversed items score 3
+		{"first options", []int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 21, 6, models.EPDSRiskProbable, true},
+		// The least distressed answer on every item
+		{"no symptoms", []int64{0, 0, 3, 0, 3, 3, 3, 3, 3, 3}, 0, 0, models.EPDSRiskLow, false},
+		{"possible depression", []int64{1, 1, 1, 1, 2, 2, 2, 2, 2, 3}, 10, 4, models.EPDSRiskPossible, false},
+		{"probable depression", []int64{2, 2, 1, 1, 1, 2, 2, 2, 2, 3}, 13, 5, models.EPDSRiskProbable, true},
+		// Any self-harm answer other than "Never" escalates, whatever the total
+		{"self-harm thoughts", []int64{0, 0, 3, 0, 3, 3, 3, 3, 3, 2}, 1, 0, models.EPDSRiskLow, true},
+	}
+	for _, tc := range cases {
+		result, err := ScoreEPDS(tc.answers)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if result.Score != tc.score || result.AnxietyScore != tc.anxiety || result.RiskLevel != tc.risk || result.Escalated != tc.escalated {
+			t.Errorf("%s: got %+v, want score %d, anxiety %d, risk %s, escalated %v",
+				tc.name, result, tc.score, tc.anxiety, tc.risk, tc.escalated)
+		}
+	}
+
+	for _, answers := range [][]int64{{0, 0, 0}, {0, 0, 0, 0, 0, 0, 0, 0, 0, 4}, {0, 0, 0, 0, 0, 0, 0, 0, -1, 0}} {
+		if _, err := ScoreEPDS(answers); !errors.Is(err, ErrInvalidEPDSAnswers) {
+			t.Errorf("ScoreEPDS(%v): got %v, want ErrInvalidEPDSAnswers", answers, err)
+		}
+	}
+}
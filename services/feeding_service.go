@@ -0,0 +1,420 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrFeedingNotFound     = errors.New("feeding session not found")
+	ErrFeedingInProgress   = errors.New("a feeding or pumping timer is already running")
+	ErrFeedingEnded        = errors.New("feeding session has already ended")
+	ErrInvalidFeeding      = errors.New("invalid feeding session")
+	ErrFeedingBabyNotFound = errors.New("baby not found")
+)
+
+// DefaultFeedingIntervalMinutes is the reminder interval for users who have not chosen one
+const DefaultFeedingIntervalMinutes = 180
+
+// milkStorageLife is how long pumped milk keeps in each place (CDC guidance)
+var milkStorageLife = map[string]time.Duration{
+	models.MilkStorageRoom:    4 * time.Hour,
+	models.MilkStorageFridge:  4 * 24 * time.Hour,
+	models.MilkStorageFreezer: 180 * 24 * time.Hour,
+}
+
+// ValidMilkStorage reports whether a storage value is known
+func ValidMilkStorage(storage string) bool {
+	_, ok := milkStorageLife[storage]
+	return ok || storage == models.MilkStorageUsed || storage == models.MilkStorageDiscarded
+}
+
+// SetMilkStorage moves pumped milk to a storage place and resets its expiry
+func SetMilkStorage(session *models.FeedingSession, storage string, now time.Time) {
+	session.Storage = storage
+	session.StorageExpiresAt = nil
+	if life, ok := milkStorageLife[storage]; ok {
+		expires := now.Add(life)
+		session.StorageExpiresAt = &expires
+	}
+}
+
+// Thawed milk keeps for less time than fresh milk (CDC guidance)
+var thawedMilkLife = map[string]time.Duration{
+	models.MilkStorageRoom:   2 * time.Hour,
+	models.MilkStorageFridge: 24 * time.Hour,
+}
+
+// MoveMilkStorage moves already-stored pumped milk. Freezing restarts the clock,
+// thawing uses the shorter thawed-milk life, and any other move never extends it.
+func MoveMilkStorage(session *models.FeedingSession, storage string, now time.Time) {
+	previous, previousExpiry := session.Storage, session.StorageExpiresAt
+	SetMilkStorage(session, storage, now)
+	if session.StorageExpiresAt == nil || storage == models.MilkStorageFreezer {
+		return
+	}
+	if life, ok := thawedMilkLife[storage]; ok && previous == models.MilkStorageFreezer {
+		expires := now.Add(life)
+		session.StorageExpiresAt = &expires
+		return
+	}
+	if previousExpiry != nil && previousExpiry.Before(*session.StorageExpiresAt) {
+		session.StorageExpiresAt = previousExpiry
+	}
+}
+
+// ValidateFeedingSession checks the fields a session needs for its kind
+func ValidateFeedingSession(s *models.FeedingSession) error {
+	switch s.Kind {
+	case models.FeedingBreast, models.FeedingPump:
+		if s.Side != models.SideLeft && s.Side != models.SideRight && s.Side != models.SideBoth {
+			return fmt.Errorf("%w: side must be left, right or both", ErrInvalidFeeding)
+		}
+		if s.Kind == models.FeedingBreast && s.VolumeMl != 0 {
+			return fmt.Errorf("%w: volume is not recorded for breastfeeds", ErrInvalidFeeding)
+		}
+	case models.FeedingBottle:
+		if s.BottleContent != "breast_milk" && s.BottleContent != "formula" {
+			return fmt.Errorf("%w: bottle_content must be breast_milk or formula", ErrInvalidFeeding)
+		}
+		if s.VolumeMl <= 0 {
+			return fmt.Errorf("%w: volume_ml is required for bottle feeds", ErrInvalidFeeding)
+		}
+		s.Side = ""
+	default:
+		return fmt.Errorf("%w: kind must be breast, bottle or pump", ErrInvalidFeeding)
+	}
+	if s.VolumeMl < 0 || s.VolumeMl > 1000 {
+		return fmt.Errorf("%w: volume_ml must be between 0 and 1000", ErrInvalidFeeding)
+	}
+	if s.Kind != models.FeedingPump && s.Storage != "" {
+		return fmt.Errorf("%w: storage only applies to pumped milk", ErrInvalidFeeding)
+	}
+	if s.Storage != "" && !ValidMilkStorage(s.Storage) {
+		return fmt.Errorf("%w: storage must be room, fridge, freezer, used or discarded", ErrInvalidFeeding)
+	}
+	if s.EndedAt != nil && s.EndedAt.Before(s.StartedAt) {
+		return fmt.Errorf("%w: ended_at must be after started_at", ErrInvalidFeeding)
+	}
+	return nil
+}
+
+// checkBabyOwner makes sure an optional baby belongs to the user
+func checkBabyOwner(userID uuid.UUID, babyID *uuid.UUID) error {
+	if babyID == nil {
+		return nil
+	}
+	var count int64
+	if err := config.DB.Model(&models.Baby{}).Where("id = ? AND user_id = ?", *babyID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrFeedingBabyNotFound
+	}
+	return nil
+}
+
+// LogFeedingSession stores a session. Breast and pump sessions without an end
+// time start a live timer; only one timer may run at a time.
+func LogFeedingSession(s *models.FeedingSession, now time.Time) error {
+	if s.EndedAt == nil && s.DurationSeconds > 0 {
+		ended := s.StartedAt.Add(time.Duration(s.DurationSeconds) * time.Second)
+		s.EndedAt = &ended
+	}
+	if s.Kind == models.FeedingBottle && s.EndedAt == nil {
+		s.EndedAt = &s.StartedAt
+	}
+	if err := ValidateFeedingSession(s); err != nil {
+		return err
+	}
+	if err := checkBabyOwner(s.UserID, s.BabyID); err != nil {
+		return err
+	}
+	if s.EndedAt != nil {
+		s.DurationSeconds = int(s.EndedAt.Sub(s.StartedAt).Seconds())
+	}
+	if s.Kind == models.FeedingPump && s.Storage != "" {
+		SetMilkStorage(s, s.Storage, now)
+	}
+	s.ID = uuid.New()
+	s.CreatedAt, s.UpdatedAt = now, now
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if s.EndedAt == nil {
+			// Serialise timer starts per user so two taps cannot both start one
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "feeding-timer:"+s.UserID.String()).Error; err != nil {
+				return err
+			}
+			var open int64
+			if err := tx.Model(&models.FeedingSession{}).
+				Where("user_id = ? AND ended_at IS NULL", s.UserID).Count(&open).Error; err != nil {
+				return err
+			}
+			if open > 0 {
+				return ErrFeedingInProgress
+			}
+		}
+		return tx.Create(s).Error
+	})
+}
+
+// StopFeedingSession ends a running timer, optionally recording pumped volume and storage
+func StopFeedingSession(userID, sessionID uuid.UUID, volumeMl float64, storage string, now time.Time) (*models.FeedingSession, error) {
+	var session models.FeedingSession
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the timer so a double tap stops it only once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFeedingNotFound
+			}
+			return err
+		}
+		if session.EndedAt != nil {
+			return ErrFeedingEnded
+		}
+
+		session.EndedAt = &now
+		session.DurationSeconds = int(now.Sub(session.StartedAt).Seconds())
+		if volumeMl > 0 {
+			session.VolumeMl = volumeMl
+		}
+		if storage != "" {
+			session.Storage = storage
+		}
+		if err := ValidateFeedingSession(&session); err != nil {
+			return err
+		}
+		if session.Storage != "" {
+			SetMilkStorage(&session, session.Storage, now)
+		}
+		session.UpdatedAt = now
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FeedingDaySummary totals a day of feeding and pumping
+type FeedingDaySummary struct {
+	Date                time.Time  `json:"date"`
+	Feeds               int        `json:"feeds"` // breast and bottle
+	BreastFeeds         int        `json:"breast_feeds"`
+	BottleFeeds         int        `json:"bottle_feeds"`
+	PumpSessions        int        `json:"pump_sessions"`
+	BreastMinutesLeft   float64    `json:"breast_minutes_left"`
+	BreastMinutesRight  float64    `json:"breast_minutes_right"`
+	BreastMinutesBoth   float64    `json:"breast_minutes_both"`
+	BottleMl            float64    `json:"bottle_ml"`
+	FormulaMl           float64    `json:"formula_ml"`
+	PumpedMl            float64    `json:"pumped_ml"`
+	AverageIntervalMins *float64   `json:"average_interval_minutes,omitempty"` // start to start
+	LastFeedAt          *time.Time `json:"last_feed_at,omitempty"`
+}
+
+// BuildFeedingDaySummary totals the sessions started on a day
+func BuildFeedingDaySummary(day time.Time, sessions []models.FeedingSession) FeedingDaySummary {
+	summary := FeedingDaySummary{Date: truncateDay(day)}
+	var feedStarts []time.Time
+	for _, s := range sessions {
+		minutes := float64(s.DurationSeconds) / 60
+		switch s.Kind {
+		case models.FeedingBreast:
+			summary.BreastFeeds++
+			switch s.Side {
+			case models.SideLeft:
+				summary.BreastMinutesLeft += minutes
+			case models.SideRight:
+				summary.BreastMinutesRight += minutes
+			default:
+				summary.BreastMinutesBoth += minutes
+			}
+			feedStarts = append(feedStarts, s.StartedAt)
+		case models.FeedingBottle:
+			summary.BottleFeeds++
+			summary.BottleMl += s.VolumeMl
+			if s.BottleContent == "formula" {
+				summary.FormulaMl += s.VolumeMl
+			}
+			feedStarts = append(feedStarts, s.StartedAt)
+		case models.FeedingPump:
+			summary.PumpSessions++
+			summary.PumpedMl += s.VolumeMl
+		}
+	}
+	summary.Feeds = len(feedStarts)
+	summary.BreastMinutesLeft = round1(summary.BreastMinutesLeft)
+	summary.BreastMinutesRight = round1(summary.BreastMinutesRight)
+	summary.BreastMinutesBoth = round1(summary.BreastMinutesBoth)
+
+	if n := len(feedStarts); n > 0 {
+		last := feedStarts[n-1]
+		summary.LastFeedAt = &last
+		if n > 1 {
+			avg := round1(feedStarts[n-1].Sub(feedStarts[0]).Minutes() / float64(n-1))
+			summary.AverageIntervalMins = &avg
+		}
+	}
+	return summary
+}
+
+// GetFeedingDaySummary loads and totals the user's sessions for a calendar day
+func GetFeedingDaySummary(userID uuid.UUID, day time.Time) (*FeedingDaySummary, error) {
+	start := truncateDay(day)
+	var sessions []models.FeedingSession
+	if err := config.DB.Where("user_id = ? AND started_at >= ? AND started_at < ?", userID, start, start.AddDate(0, 0, 1)).
+		Order("started_at asc").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	summary := BuildFeedingDaySummary(start, sessions)
+	return &summary, nil
+}
+
+// NextSideSuggestion says which breast to offer first at the next feed
+type NextSideSuggestion struct {
+	Side       string     `json:"side"`
+	Reason     string     `json:"reason"`
+	LastSide   string     `json:"last_side,omitempty"`
+	LastFeedAt *time.Time `json:"last_feed_at,omitempty"`
+}
+
+// SuggestNextSide alternates from the last breastfeed. After a feed on both sides
+// it starts with whichever side was used less over the previous 24 hours.
+func SuggestNextSide(userID uuid.UUID, now time.Time) (*NextSideSuggestion, error) {
+	var recent []models.FeedingSession
+	if err := config.DB.Where("user_id = ? AND kind = ? AND started_at >= ?", userID, models.FeedingBreast, now.Add(-24*time.Hour)).
+		Order("started_at desc").Find(&recent).Error; err != nil {
+		return nil, err
+	}
+	return nextSideFrom(recent), nil
+}
+
+// nextSideFrom picks the side from the last 24 hours of breastfeeds, newest first
+func nextSideFrom(recent []models.FeedingSession) *NextSideSuggestion {
+	if len(recent) == 0 {
+		return &NextSideSuggestion{Side: models.SideLeft, Reason: "No breastfeeds in the last 24 hours"}
+	}
+
+	last := recent[0]
+	suggestion := &NextSideSuggestion{LastSide: last.Side, LastFeedAt: &last.StartedAt}
+	switch last.Side {
+	case models.SideLeft:
+		suggestion.Side, suggestion.Reason = models.SideRight, "Last feed was on the left"
+	case models.SideRight:
+		suggestion.Side, suggestion.Reason = models.SideLeft, "Last feed was on the right"
+	default:
+		var left, right int
+		for _, s := range recent {
+			switch s.Side {
+			case models.SideLeft:
+				left += s.DurationSeconds
+			case models.SideRight:
+				right += s.DurationSeconds
+			}
+		}
+		if right < left {
+			suggestion.Side, suggestion.Reason = models.SideRight, "Right side has been used less in the last 24 hours"
+		} else {
+			suggestion.Side, suggestion.Reason = models.SideLeft, "Left side has been used less in the last 24 hours"
+		}
+	}
+	return suggestion
+}
+
+// GetFeedingPreference returns the user's reminder settings, or the defaults
+func GetFeedingPreference(userID uuid.UUID) (models.FeedingPreference, error) {
+	pref := models.FeedingPreference{UserID: userID, IntervalMinutes: DefaultFeedingIntervalMinutes}
+	err := config.DB.Where("user_id = ?", userID).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return pref, nil
+	}
+	return pref, err
+}
+
+// SendFeedingReminders notifies users who opted in when the interval since the
+// start of their last feed has passed. Each feed triggers at most one reminder.
+// A failure for one user is logged and the others are still reminded.
+func SendFeedingReminders(now time.Time) error {
+	var prefs []models.FeedingPreference
+	if err := config.DB.Where("reminders_enabled = ?", true).Find(&prefs).Error; err != nil {
+		return err
+	}
+
+	for _, pref := range prefs {
+		// No feed prompts while someone is recovering from a pregnancy loss
+		sensitive, err := InPregnancyLossRecovery(pref.UserID)
+		if err != nil {
+			log.Printf("❌ Failed to check loss recovery for %s: %v", pref.UserID, err)
+			continue
+		}
+		if sensitive {
+			continue
+		}
+		if err := sendFeedingReminder(pref, now); err != nil {
+			log.Printf("❌ Failed to send feeding reminder to %s: %v", pref.UserID, err)
+		}
+	}
+	return nil
+}
+
+// sendFeedingReminder reminds the user about their last feed if it is due,
+// marking the feed and writing the notification in one transaction
+func sendFeedingReminder(pref models.FeedingPreference, now time.Time) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var last models.FeedingSession
+		err := tx.Where("user_id = ? AND kind IN ?", pref.UserID, []string{models.FeedingBreast, models.FeedingBottle}).
+			Order("started_at desc").First(&last).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Lock the feed so a concurrent run skips it, then re-read it
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ?", last.ID).First(&last).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		due := last.StartedAt.Add(time.Duration(pref.IntervalMinutes) * time.Minute)
+		// Skip while a feed is running, and don't nag about feeds long past
+		if last.EndedAt == nil || last.ReminderSentAt != nil || now.Before(due) || now.Sub(due) > 6*time.Hour {
+			return nil
+		}
+
+		message := fmt.Sprintf("It has been %s since the last feed started.", formatMinutes(int(now.Sub(last.StartedAt).Minutes())))
+		if last.Kind == models.FeedingBreast {
+			if next, err := SuggestNextSide(pref.UserID, now); err == nil {
+				message += " Try starting on the " + next.Side + " side."
+			}
+		}
+		if err := notifyTx(tx, pref.UserID, models.NotificationTypeReminder, "Time for a feed", message, "/feedings"); err != nil {
+			return err
+		}
+		return tx.Model(&models.FeedingSession{}).Where("id = ?", last.ID).Update("reminder_sent_at", now).Error
+	})
+}
+
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestSetMilkStorageExpiry(t *testing.T) {
+	pumped := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		storage string
+		want    *time.Time
+	}{
+		{models.MilkStorageRoom, ptrTime(pumped.Add(4 * time.Hour))},
+		{models.MilkStorageFridge, ptrTime(pumped.AddDate(0, 0, 4))},
+		{models.MilkStorageFreezer, ptrTime(pumped.AddDate(0, 0, 180))},
+		{models.MilkStorageUsed, nil},
+		{models.MilkStorageDiscarded, nil},
+	}
+	for _, c := range cases {
+		var s models.FeedingSession
+		SetMilkStorage(&s, c.storage, pumped)
+		if !sameTime(s.StorageExpiresAt, c.want) {
+			t.Errorf("%s: expires %v, want %v", c.storage, s.StorageExpiresAt, c.want)
+		}
+	}
+}
+
+func TestMoveMilkStorage(t *testing.T) {
+	pumped := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	moved := pumped.Add(3 * time.Hour)
+	cases := []struct {
+		name     string
+		from, to string
+		want     *time.Time
+	}{
+		// Thawed milk keeps for 24 hours in the fridge and 2 hours at room temperature
+		{"thawed in fridge", models.MilkStorageFreezer, models.MilkStorageFridge, ptrTime(moved.Add(24 * time.Hour))},
+		{"thawed at room", models.MilkStorageFreezer, models.MilkStorageRoom, ptrTime(moved.Add(2 * time.Hour))},
+		// Freezing restarts the clock
+		{"frozen from fridge", models.MilkStorageFridge, models.MilkStorageFreezer, ptrTime(moved.AddDate(0, 0, 180))},
+		// Moving fresh milk never extends its life
+		{"room to fridge", models.MilkStorageRoom, models.MilkStorageFridge, ptrTime(pumped.Add(4 * time.Hour))},
+		{"fridge to room", models.MilkStorageFridge, models.MilkStorageRoom, ptrTime(moved.Add(4 * time.Hour))},
+		{"used", models.MilkStorageFridge, models.MilkStorageUsed, nil},
+	}
+	for _, c := range cases {
+		var s models.FeedingSession
+		SetMilkStorage(&s, c.from, pumped)
+		MoveMilkStorage(&s, c.to, moved)
+		if s.Storage != c.to {
+			t.Errorf("%s: storage %q, want %q", c.name, s.Storage, c.to)
+		}
+		if !sameTime(s.StorageExpiresAt, c.want) {
+			t.Errorf("%s: expires %v, want %v", c.name, s.StorageExpiresAt, c.want)
+		}
+	}
+}
+
+func TestNextSideFrom(t *testing.T) {
+	at := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	feed := func(side string, minutes int, hoursAgo int) models.FeedingSession {
+		return models.FeedingSession{Kind: models.FeedingBreast, Side: side, DurationSeconds: minutes * 60,
+			StartedAt: at.Add(-time.Duration(hoursAgo) * time.Hour)}
+	}
+	cases := []struct {
+		name   string
+		recent []models.FeedingSession
+		want   string
+	}{
+		{"no feeds", nil, models.SideLeft},
+		{"after left", []models.FeedingSession{feed(models.SideLeft, 15, 1)}, models.SideRight},
+		{"after right", []models.FeedingSession{feed(models.SideRight, 15, 1), feed(models.SideLeft, 15, 4)}, models.SideLeft},
+		{"both, right used less", []models.FeedingSession{
+			feed(models.SideBoth, 20, 1), feed(models.SideLeft, 20, 4), feed(models.SideRight, 10, 7),
+		}, models.SideRight},
+		{"both, even use", []models.FeedingSession{
+			feed(models.SideBoth, 20, 1), feed(models.SideLeft, 15, 4), feed(models.SideRight, 15, 7),
+		}, models.SideLeft},
+	}
+	for _, c := range cases {
+		got := nextSideFrom(c.recent)
+		if got.Side != c.want {
+			t.Errorf("%s: side %q (%s), want %q", c.name, got.Side, got.Reason, c.want)
+		}
+		if len(c.recent) > 0 && (got.LastFeedAt == nil || !got.LastFeedAt.Equal(c.recent[0].StartedAt)) {
+			t.Errorf("%s: last feed %v, want %v", c.name, got.LastFeedAt, c.recent[0].StartedAt)
+		}
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
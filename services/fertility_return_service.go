@@ -0,0 +1,432 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Return-of-fertility rules
+const (
+	// MinBaselineCycles is how many periods since the last pregnancy are needed
+	// before predictions use the new cycles
+	MinBaselineCycles = 2
+	// Bleeding in the first 8 weeks after birth is not counted as a returned period
+	lochiaWindowDays = 56
+	// LAM is only reliable for the first 6 months
+	lamMaxDays = 183
+	// Longest gaps between breastfeeds, start to start, that still meet LAM
+	lamMaxDayGap   = 4 * time.Hour
+	lamMaxNightGap = 6 * time.Hour
+	// Feeds starting from 22:00 until 06:00 count as night feeds
+	lamNightStartHour = 22
+	lamNightEndHour   = 6
+	// LAM loss is still announced for a week after the 6 month limit passes
+	lamNoticeGraceDays = 7
+	// How far back feeding sessions are checked for LAM
+	lamFeedingLookback = 7 * 24 * time.Hour
+	lamGapLookback     = 48 * time.Hour
+	// Red lochia this long after birth may be a late bleed
+	lochiaRubraLateDay = 14
+	// Heavy flow after the first few days needs checking
+	lochiaHeavyLateDay = 3
+)
+
+const fertilityLink = "/postpartum/fertility"
+
+var (
+	ErrNoEndedPregnancy  = errors.New("no pregnancy has ended yet")
+	ErrInvalidLochiaLog  = errors.New("stage must be rubra, serosa, alba or stopped; flow must be heavy, moderate, light or spotting")
+	ErrLochiaDateInvalid = errors.New("date must be between the end of the pregnancy and today")
+)
+
+var lochiaStages = map[string]bool{models.LochiaRubra: true, models.LochiaSerosa: true, models.LochiaAlba: true, models.LochiaStopped: true}
+var lochiaFlows = map[string]bool{"": true, "heavy": true, "moderate": true, "light": true, "spotting": true}
+
+// LatestEndedPregnancy returns the user's most recently ended pregnancy
+func LatestEndedPregnancy(userID uuid.UUID) (*models.Pregnancy, error) {
+	var pregnancy models.Pregnancy
+	err := config.DB.Where("user_id = ? AND end_date IS NOT NULL", userID).
+		Order("end_date desc").First(&pregnancy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pregnancy, nil
+}
+
+// BaselineCycles returns the cycles predictions should be based on. After a
+// pregnancy only periods that started once it ended are used, so the old
+// pre-pregnancy pattern does not leak into new predictions; after a birth,
+// bleeding in the lochia window is skipped too. since is the cutoff, or nil
+// when there has been no pregnancy.
+func BaselineCycles(userID uuid.UUID) (cycles []models.Cycle, since *time.Time, err error) {
+	pregnancy, err := LatestEndedPregnancy(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	query := config.DB.Where("user_id = ?", userID)
+	if pregnancy != nil {
+		cutoff := *pregnancy.EndDate
+		query = query.Where("start_date > ?", cutoff)
+		if pregnancy.Status == models.PregnancyStatusDelivered {
+			cutoff = truncateDay(cutoff).AddDate(0, 0, lochiaWindowDays)
+			query = query.Where("start_date >= ?", cutoff)
+		}
+		since = &cutoff
+	}
+	err = query.Order("start_date asc").Find(&cycles).Error
+	return cycles, since, err
+}
+
+// LochiaWarning flags bleeding that should be checked by a care provider
+type LochiaWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// lochiaWarnings checks a day's lochia against the usual course of recovery
+func lochiaWarnings(entry models.LochiaLog, day int, previous []models.LochiaLog) []LochiaWarning {
+	var warnings []LochiaWarning
+	if entry.LargeClots {
+		warnings = append(warnings, LochiaWarning{"large_clots", "Passing clots larger than a plum can be a sign of heavy bleeding. Contact your care provider today."})
+	}
+	if entry.Flow == "heavy" && day > lochiaHeavyLateDay {
+		warnings = append(warnings, LochiaWarning{"heavy_flow", "Heavy bleeding this long after birth should be checked. If you soak a pad in an hour or less, get urgent care."})
+	}
+	if entry.FoulOdor {
+		warnings = append(warnings, LochiaWarning{"foul_odor", "A bad-smelling discharge can be a sign of infection. Contact your care provider, especially if you have a fever."})
+	}
+	if entry.Stage == models.LochiaRubra {
+		returned := false
+		for _, p := range previous {
+			if p.Stage == models.LochiaSerosa || p.Stage == models.LochiaAlba {
+				returned = true
+			}
+		}
+		if returned || day > lochiaRubraLateDay {
+			warnings = append(warnings, LochiaWarning{"red_bleeding_returned", "Bright red bleeding that returns or lasts beyond two weeks after birth should be checked by your care provider."})
+		}
+	}
+	return warnings
+}
+
+// SaveLochiaLog records a day's lochia for the latest ended pregnancy and alerts
+// the user to warning signs
+func SaveLochiaLog(userID uuid.UUID, entry models.LochiaLog, now time.Time) (*models.LochiaLog, []LochiaWarning, error) {
+	if !lochiaStages[entry.Stage] || !lochiaFlows[entry.Flow] {
+		return nil, nil, ErrInvalidLochiaLog
+	}
+	pregnancy, err := LatestEndedPregnancy(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pregnancy == nil {
+		return nil, nil, ErrNoEndedPregnancy
+	}
+	day := daysBetween(*pregnancy.EndDate, entry.Date)
+	if day < 0 || entry.Date.After(now) {
+		return nil, nil, ErrLochiaDateInvalid
+	}
+
+	entry.ID = uuid.New()
+	entry.UserID = userID
+	entry.PregnancyID = pregnancy.ID
+	entry.CreatedAt, entry.UpdatedAt = now, now
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pregnancy_id", "stage", "flow", "large_clots", "foul_odor", "notes", "updated_at"}),
+	}).Create(&entry).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := config.DB.Where("user_id = ? AND date = ?", userID, entry.Date).First(&entry).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var previous []models.LochiaLog
+	if err := config.DB.Where("user_id = ? AND pregnancy_id = ? AND date < ?", userID, pregnancy.ID, entry.Date).
+		Find(&previous).Error; err != nil {
+		return nil, nil, err
+	}
+	warnings := lochiaWarnings(entry, day, previous)
+	if len(warnings) > 0 {
+		link := "/postpartum/lochia/" + entry.Date.Format("2006-01-02")
+		sent, err := HasNotificationSince(userID, link, truncateDay(now))
+		if err != nil {
+			return nil, nil, err
+		}
+		if !sent {
+			if err := Notify(userID, models.NotificationTypeAlert, "Check your bleeding", warnings[0].Message, link); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return &entry, warnings, nil
+}
+
+// LAMCriterion is one condition of the lactational amenorrhea method
+type LAMCriterion struct {
+	Key    string `json:"key"`
+	Met    bool   `json:"met"`
+	Detail string `json:"detail"`
+}
+
+// LAMStatus reports whether breastfeeding can currently be relied on as contraception
+type LAMStatus struct {
+	Effective  bool           `json:"effective"`
+	ValidUntil time.Time      `json:"valid_until"`
+	Criteria   []LAMCriterion `json:"criteria"`
+	Message    string         `json:"message"`
+
+	feedingChecked bool // enough feeds were logged to judge the feeding condition
+}
+
+// LochiaStatus summarises post-birth bleeding so far
+type LochiaStatus struct {
+	LastStage    string     `json:"last_stage,omitempty"`
+	LastLoggedOn *time.Time `json:"last_logged_on,omitempty"`
+	StoppedOn    *time.Time `json:"stopped_on,omitempty"`
+}
+
+// FertilityStatus describes the return of fertility after a pregnancy
+type FertilityStatus struct {
+	TrackingMode       string       `json:"tracking_mode"`
+	PregnancyEndedOn   time.Time    `json:"pregnancy_ended_on"`
+	DaysPostpartum     int          `json:"days_postpartum"`
+	WeeksPostpartum    int          `json:"weeks_postpartum"`
+	Lochia             LochiaStatus `json:"lochia"`
+	PeriodReturned     bool         `json:"period_returned"`
+	FirstPeriodOn      *time.Time   `json:"first_period_on,omitempty"`
+	CyclesSinceReturn  int          `json:"cycles_since_return"`
+	PredictionsResumed bool         `json:"predictions_resumed"`
+	LAM                *LAMStatus   `json:"lam,omitempty"` // only after a birth
+	Notes              []string     `json:"notes"`
+}
+
+// lamGapLimit is the longest LAM allows before the next breastfeed after one
+// starting at t: 4 hours by day and 6 hours at night in the user's zone
+func lamGapLimit(t time.Time, loc *time.Location) time.Duration {
+	if h := t.In(loc).Hour(); h >= lamNightStartHour || h < lamNightEndHour {
+		return lamMaxNightGap
+	}
+	return lamMaxDayGap
+}
+
+// lamGapBreach finds the longest gap between consecutive breastfeeds that runs
+// over its day or night limit. Gaps are only measured between logged feeds, so
+// time since the last feed never counts.
+func lamGapBreach(starts []time.Time, loc *time.Location) (gap, limit time.Duration, breached bool) {
+	for i := 1; i < len(starts); i++ {
+		g := starts[i].Sub(starts[i-1])
+		if l := lamGapLimit(starts[i-1], loc); g > l && g > gap {
+			gap, limit, breached = g, l, true
+		}
+	}
+	return gap, limit, breached
+}
+
+// lamFeedingCriterion checks recent feeding sessions for full breastfeeding with
+// no long gaps between feeds. checked is false when too few feeds are logged to tell.
+func lamFeedingCriterion(userID uuid.UUID, now time.Time, loc *time.Location) (criterion LAMCriterion, checked bool, err error) {
+	criterion = LAMCriterion{Key: "fully_breastfeeding"}
+	var sessions []models.FeedingSession
+	if err := config.DB.Where("user_id = ? AND kind IN ? AND started_at >= ?", userID,
+		[]string{models.FeedingBreast, models.FeedingBottle}, now.Add(-lamFeedingLookback)).
+		Order("started_at asc").Find(&sessions).Error; err != nil {
+		return criterion, false, err
+	}
+	if len(sessions) == 0 {
+		criterion.Detail = "Log breastfeeds so feeding frequency can be checked"
+		return criterion, false, nil
+	}
+
+	var starts []time.Time
+	bottles := 0
+	for _, s := range sessions {
+		if s.Kind == models.FeedingBottle {
+			bottles++
+			continue
+		}
+		if !s.StartedAt.Before(now.Add(-lamGapLookback)) {
+			starts = append(starts, s.StartedAt)
+		}
+	}
+
+	if bottles > 0 {
+		criterion.Detail = fmt.Sprintf("%d bottle feed(s) in the last 7 days; LAM needs (nearly) exclusive breastfeeding", bottles)
+		return criterion, true, nil
+	}
+	if len(starts) < 2 {
+		criterion.Detail = "Log at least two breastfeeds in the last 48 hours so the gaps between feeds can be checked"
+		return criterion, false, nil
+	}
+	if gap, limit, breached := lamGapBreach(starts, loc); breached {
+		period := "by day"
+		if limit == lamMaxNightGap {
+			period = "at night"
+		}
+		criterion.Detail = fmt.Sprintf("A gap of %s between feeds %s; LAM needs feeds at least every 4 hours by day and 6 hours at night",
+			formatMinutes(int(gap.Minutes())), period)
+		return criterion, true, nil
+	}
+	criterion.Met = true
+	criterion.Detail = "Breastfeeding regularly with no bottle feeds"
+	return criterion, true, nil
+}
+
+// BuildFertilityStatus reports lochia, first-period return, LAM and whether
+// cycle predictions have resumed since the user's last pregnancy. loc decides
+// which feeds count as night feeds.
+func BuildFertilityStatus(userID uuid.UUID, now time.Time, loc *time.Location) (*FertilityStatus, error) {
+	pregnancy, err := LatestEndedPregnancy(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pregnancy == nil {
+		return nil, ErrNoEndedPregnancy
+	}
+	mode, err := GetTrackingMode(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ended := *pregnancy.EndDate
+	days := daysBetween(ended, now)
+	status := &FertilityStatus{
+		TrackingMode:     mode,
+		PregnancyEndedOn: ended,
+		DaysPostpartum:   days,
+		WeeksPostpartum:  days / 7,
+		Notes:            []string{},
+	}
+
+	var lochia []models.LochiaLog
+	if err := config.DB.Where("user_id = ? AND pregnancy_id = ?", userID, pregnancy.ID).Order("date asc").Find(&lochia).Error; err != nil {
+		return nil, err
+	}
+	if n := len(lochia); n > 0 {
+		last := lochia[n-1]
+		status.Lochia.LastStage = last.Stage
+		status.Lochia.LastLoggedOn = &last.Date
+		if last.Stage == models.LochiaStopped {
+			// The first day of the final run of "stopped" entries
+			stopped := last.Date
+			for i := n - 1; i >= 0 && lochia[i].Stage == models.LochiaStopped; i-- {
+				stopped = lochia[i].Date
+			}
+			status.Lochia.StoppedOn = &stopped
+		}
+	}
+
+	cycles, _, err := BaselineCycles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cycles) > 0 {
+		first := cycles[0].StartDate
+		status.FirstPeriodOn = &first
+	}
+	status.CyclesSinceReturn = len(cycles)
+	status.PeriodReturned = status.FirstPeriodOn != nil
+	if pregnancy.Status == models.PregnancyStatusDelivered && days < lochiaWindowDays {
+		status.Notes = append(status.Notes, "Bleeding in the first 8 weeks after birth is usually lochia, not a period, so it is not counted as your period returning.")
+	}
+	status.PredictionsResumed = mode == models.TrackingModeCycle && len(cycles) >= MinBaselineCycles
+	if status.PeriodReturned && !status.PredictionsResumed {
+		status.Notes = append(status.Notes, fmt.Sprintf("Predictions restart once %d periods have been logged since your pregnancy.", MinBaselineCycles))
+	}
+	if !status.PeriodReturned {
+		status.Notes = append(status.Notes, "You can ovulate before your first period returns, so pregnancy is possible before you see a period.")
+	}
+
+	if pregnancy.Status == models.PregnancyStatusDelivered {
+		lam, err := buildLAMStatus(userID, status, now, loc)
+		if err != nil {
+			return nil, err
+		}
+		status.LAM = lam
+	}
+	return status, nil
+}
+
+// buildLAMStatus checks the three LAM conditions
+func buildLAMStatus(userID uuid.UUID, status *FertilityStatus, now time.Time, loc *time.Location) (*LAMStatus, error) {
+	lam := &LAMStatus{ValidUntil: status.PregnancyEndedOn.AddDate(0, 0, lamMaxDays)}
+
+	underSix := LAMCriterion{Key: "under_6_months", Met: status.DaysPostpartum < lamMaxDays}
+	if underSix.Met {
+		underSix.Detail = "Baby is under 6 months old"
+	} else {
+		underSix.Detail = "Baby is 6 months or older"
+	}
+	noPeriod := LAMCriterion{Key: "no_period", Met: !status.PeriodReturned}
+	if noPeriod.Met {
+		noPeriod.Detail = "Periods have not returned"
+	} else {
+		noPeriod.Detail = "Periods have returned"
+	}
+	feeding, checked, err := lamFeedingCriterion(userID, now, loc)
+	if err != nil {
+		return nil, err
+	}
+	lam.feedingChecked = checked
+
+	lam.Criteria = []LAMCriterion{underSix, noPeriod, feeding}
+	lam.Effective = underSix.Met && noPeriod.Met && feeding.Met
+	if lam.Effective {
+		lam.Message = "All LAM conditions are met. Breastfeeding is about 98% effective at preventing pregnancy while they stay met."
+	} else {
+		lam.Message = "LAM conditions are not all met. Use another method of contraception if you want to avoid pregnancy."
+	}
+	return lam, nil
+}
+
+// NotifyLAMEnded warns breastfeeding users, once per pregnancy, when they stop
+// meeting the LAM conditions. Only users who logged breastfeeds recently are
+// checked, and only while their feeding could still be judged.
+func NotifyLAMEnded(now time.Time) error {
+	var userIDs []uuid.UUID
+	if err := config.DB.Model(&models.FeedingSession{}).
+		Where("kind = ? AND started_at >= ?", models.FeedingBreast, now.Add(-lamFeedingLookback)).
+		Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		loc, err := UserLocation(userID)
+		if err != nil {
+			return err
+		}
+		status, err := BuildFertilityStatus(userID, now, loc)
+		if errors.Is(err, ErrNoEndedPregnancy) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		lam := status.LAM
+		if lam == nil || lam.Effective || !lam.feedingChecked || status.DaysPostpartum > lamMaxDays+lamNoticeGraceDays {
+			continue
+		}
+		sent, err := HasNotificationSince(userID, fertilityLink, status.PregnancyEndedOn)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+		if err := Notify(userID, models.NotificationTypeReminder, "Breastfeeding may no longer prevent pregnancy",
+			lam.Message, fertilityLink); err != nil {
+			return err
+		}
+	}
+	return nil
+}
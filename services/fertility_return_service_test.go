@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLAMGapBreach(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name     string
+		starts   []time.Time
+		breached bool
+		gap      time.Duration
+	}{
+		{"regular day feeds", []time.Time{at(1, 8, 0), at(1, 11, 30), at(1, 15, 0)}, false, 0},
+		{"4.5 hours by day", []time.Time{at(1, 9, 0), at(1, 13, 30)}, true, 4*time.Hour + 30*time.Minute},
+		{"5.5 hours at night", []time.Time{at(1, 23, 0), at(2, 4, 30)}, false, 0},
+		{"7 hours at night", []time.Time{at(1, 22, 30), at(2, 5, 30)}, true, 7 * time.Hour},
+		{"longest breach wins", []time.Time{at(1, 8, 0), at(1, 12, 30), at(1, 18, 0)}, true, 5*time.Hour + 30*time.Minute},
+		{"single feed", []time.Time{at(1, 8, 0)}, false, 0},
+	}
+	for _, tc := range cases {
+		gap, _, breached := lamGapBreach(tc.starts, time.UTC)
+		if breached != tc.breached || gap != tc.gap {
+			t.Errorf("%s: got breached=%v gap=%s, want breached=%v gap=%s", tc.name, breached, gap, tc.breached, tc.gap)
+		}
+	}
+}
+
+func TestLAMGapBreachUsesLocalNight(t *testing.T) {
+	nairobi, err := time.LoadLocation("Africa/Nairobi") // UTC+3
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	losAngeles, err := time.LoadLocation("America/Los_Angeles") // UTC-7 in June
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// Each case is a 5 hour gap: over the day limit, within the night limit
+	cases := []struct {
+		name     string
+		start    time.Time
+		loc      *time.Location
+		breached bool
+	}{
+		{"19:30 UTC by day in UTC", time.Date(2026, 6, 1, 19, 30, 0, 0, time.UTC), time.UTC, true},
+		{"19:30 UTC is 22:30 in Nairobi", time.Date(2026, 6, 1, 19, 30, 0, 0, time.UTC), nairobi, false},
+		{"23:00 UTC at night in UTC", time.Date(2026, 6, 1, 23, 0, 0, 0, time.UTC), time.UTC, false},
+		{"23:00 UTC is 16:00 in Los Angeles", time.Date(2026, 6, 1, 23, 0, 0, 0, time.UTC), losAngeles, true},
+	}
+	for _, tc := range cases {
+		starts := []time.Time{tc.start, tc.start.Add(5 * time.Hour)}
+		if _, _, breached := lamGapBreach(starts, tc.loc); breached != tc.breached {
+			t.Errorf("%s: got breached=%v, want %v", tc.name, breached, tc.breached)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+// DefaultGlucoseTarget returns the usual gestational diabetes targets (mg/dL)
+// for users whose doctor has not set their own
+func DefaultGlucoseTarget(userID uuid.UUID) models.GlucoseTarget {
+	return models.GlucoseTarget{
+		UserID:        userID,
+		LowMin:        60,
+		FastingMax:    95,
+		PreMealMax:    95,
+		PostMeal1hMax: 140,
+		PostMeal2hMax: 120,
+		ChecksPerDay:  4, // fasting plus one after each main meal
+	}
+}
+
+// GetGlucoseTarget returns the user's doctor-set targets, or the defaults
+func GetGlucoseTarget(userID uuid.UUID) (models.GlucoseTarget, error) {
+	var target models.GlucoseTarget
+	err := config.DB.Where("user_id = ?", userID).First(&target).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultGlucoseTarget(userID), nil
+	}
+	return target, err
+}
+
+// GlucoseMaxFor returns the upper target for a meal context, or zero when the
+// context has no target
+func GlucoseMaxFor(target models.GlucoseTarget, mealContext string) float64 {
+	switch mealContext {
+	case models.MealContextFasting:
+		return target.FastingMax
+	case models.MealContextPreMeal:
+		return target.PreMealMax
+	case models.MealContextPostMeal1:
+		return target.PostMeal1hMax
+	case models.MealContextPostMeal2:
+		return target.PostMeal2hMax
+	}
+	return 0
+}
+
+// GlucoseReadingResult is a glucose reading checked against the user's targets
+type GlucoseReadingResult struct {
+	ID          uuid.UUID `json:"id"`
+	MeasuredAt  time.Time `json:"measured_at"`
+	Value       float64   `json:"value"`
+	MealContext string    `json:"meal_context"`
+	TargetMax   float64   `json:"target_max,omitempty"`
+	InRange     *bool     `json:"in_range,omitempty"` // nil when the context has no target
+	Low         bool      `json:"low"`
+}
+
+// DailyGlucoseSummary summarises one day of glucose logging
+type DailyGlucoseSummary struct {
+	Date          time.Time              `json:"date"`
+	ReadingCount  int                    `json:"reading_count"`
+	TargetedCount int                    `json:"targeted_count"` // readings with a target for their context
+	InRangeCount  int                    `json:"in_range_count"`
+	HighCount     int                    `json:"high_count"`
+	LowCount      int                    `json:"low_count"`
+	FastingLogged bool                   `json:"fasting_logged"`
+	Complete      bool                   `json:"complete"` // enough targeted checks logged
+	Readings      []GlucoseReadingResult `json:"readings"`
+}
+
+// WeeklyGlucoseSummary summarises a week of glucose logging
+type WeeklyGlucoseSummary struct {
+	WeekStart      time.Time `json:"week_start"`
+	DaysLogged     int       `json:"days_logged"`
+	CompleteDays   int       `json:"complete_days"`
+	ReadingCount   int       `json:"reading_count"`
+	InRangePercent float64   `json:"in_range_percent"`
+	FastingAverage *float64  `json:"fasting_average,omitempty"`
+	PostMealAvg    *float64  `json:"post_meal_average,omitempty"`
+	HighCount      int       `json:"high_count"`
+	LowCount       int       `json:"low_count"`
+}
+
+// GlucoseCompliance reports how well glucose stayed in range and how
+// consistently it was logged over a period
+type GlucoseCompliance struct {
+	From             time.Time              `json:"from"`
+	To               time.Time              `json:"to"`
+	Target           models.GlucoseTarget   `json:"target"`
+	ReadingCount     int                    `json:"reading_count"`
+	InRangePercent   float64                `json:"in_range_percent"`
+	AdherencePercent float64                `json:"adherence_percent"` // complete days out of days in the period
+	Daily            []DailyGlucoseSummary  `json:"daily"`
+	Weekly           []WeeklyGlucoseSummary `json:"weekly"`
+}
+
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round1(float64(part) / float64(whole) * 100)
+}
+
+// checkGlucoseReading compares one reading with the targets
+func checkGlucoseReading(target models.GlucoseTarget, r models.VitalReading) GlucoseReadingResult {
+	result := GlucoseReadingResult{ID: r.ID, MeasuredAt: r.MeasuredAt, Value: *r.Value, MealContext: r.MealContext}
+	result.Low = result.Value < target.LowMin
+	if max := GlucoseMaxFor(target, r.MealContext); max > 0 {
+		inRange := !result.Low && result.Value <= max
+		result.TargetMax = max
+		result.InRange = &inRange
+	}
+	return result
+}
+
+// BuildGlucoseCompliance checks every glucose reading in [from, to] against the
+// user's targets and rolls the results up by day and by week
+func BuildGlucoseCompliance(userID uuid.UUID, from, to time.Time) (*GlucoseCompliance, error) {
+	target, err := GetGlucoseTarget(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var readings []models.VitalReading
+	if err := config.DB.Where("user_id = ? AND type = ? AND value IS NOT NULL AND measured_at BETWEEN ? AND ?",
+		userID, models.VitalGlucose, from, to).Order("measured_at asc").Find(&readings).Error; err != nil {
+		return nil, err
+	}
+
+	report := &GlucoseCompliance{From: from, To: to, Target: target, ReadingCount: len(readings)}
+	days := map[time.Time]*DailyGlucoseSummary{}
+	var targeted, inRange int
+	for _, r := range readings {
+		day := truncateDay(r.MeasuredAt)
+		summary, ok := days[day]
+		if !ok {
+			summary = &DailyGlucoseSummary{Date: day}
+			days[day] = summary
+		}
+		result := checkGlucoseReading(target, r)
+		summary.Readings = append(summary.Readings, result)
+		summary.ReadingCount++
+		if r.MealContext == models.MealContextFasting {
+			summary.FastingLogged = true
+		}
+		if result.Low {
+			summary.LowCount++
+		}
+		if result.InRange != nil {
+			summary.TargetedCount++
+			targeted++
+			if *result.InRange {
+				summary.InRangeCount++
+				inRange++
+			} else if !result.Low {
+				summary.HighCount++
+			}
+		}
+	}
+	report.InRangePercent = percent(inRange, targeted)
+
+	completeDays := 0
+	for day := truncateDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		summary, ok := days[day]
+		if !ok {
+			continue
+		}
+		summary.Complete = summary.TargetedCount >= target.ChecksPerDay
+		if summary.Complete {
+			completeDays++
+		}
+		report.Daily = append(report.Daily, *summary)
+	}
+	report.AdherencePercent = percent(completeDays, daysBetween(truncateDay(from), truncateDay(to))+1)
+	report.Weekly = weeklyGlucoseSummaries(from, report.Daily)
+	return report, nil
+}
+
+// weeklyGlucoseSummaries groups daily summaries into 7-day blocks from the start of the period
+func weeklyGlucoseSummaries(from time.Time, daily []DailyGlucoseSummary) []WeeklyGlucoseSummary {
+	start := truncateDay(from)
+	var weeks []WeeklyGlucoseSummary
+	var fastingSum, postMealSum float64
+	var fastingN, postMealN, targeted, inRange int
+
+	flush := func() {
+		w := &weeks[len(weeks)-1]
+		w.InRangePercent = percent(inRange, targeted)
+		if fastingN > 0 {
+			avg := round1(fastingSum / float64(fastingN))
+			w.FastingAverage = &avg
+		}
+		if postMealN > 0 {
+			avg := round1(postMealSum / float64(postMealN))
+			w.PostMealAvg = &avg
+		}
+		fastingSum, postMealSum, fastingN, postMealN, targeted, inRange = 0, 0, 0, 0, 0, 0
+	}
+
+	for _, d := range daily {
+		weekStart := start.AddDate(0, 0, daysBetween(start, d.Date)/7*7)
+		if len(weeks) == 0 || !weeks[len(weeks)-1].WeekStart.Equal(weekStart) {
+			if len(weeks) > 0 {
+				flush()
+			}
+			weeks = append(weeks, WeeklyGlucoseSummary{WeekStart: weekStart})
+		}
+		w := &weeks[len(weeks)-1]
+		w.DaysLogged++
+		if d.Complete {
+			w.CompleteDays++
+		}
+		w.ReadingCount += d.ReadingCount
+		w.HighCount += d.HighCount
+		w.LowCount += d.LowCount
+		targeted += d.TargetedCount
+		inRange += d.InRangeCount
+		for _, r := range d.Readings {
+			switch r.MealContext {
+			case models.MealContextFasting:
+				fastingSum += r.Value
+				fastingN++
+			case models.MealContextPostMeal1, models.MealContextPostMeal2:
+				postMealSum += r.Value
+				postMealN++
+			}
+		}
+	}
+	if len(weeks) > 0 {
+		flush()
+	}
+	return weeks
+}
+
+// SetGlucoseTarget stores a doctor's targets for a patient
+func SetGlucoseTarget(target models.GlucoseTarget) (*models.GlucoseTarget, error) {
+	var existing models.GlucoseTarget
+	err := config.DB.Where("user_id = ?", target.UserID).First(&existing).Error
+	switch {
+	case err == nil:
+		target.ID = existing.ID
+		target.CreatedAt = existing.CreatedAt
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		target.ID = uuid.New()
+		target.CreatedAt = time.Now()
+	default:
+		return nil, err
+	}
+	target.UpdatedAt = time.Now()
+
+	if err := config.DB.Save(&target).Error; err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"math"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+// lmsPoint is one month of a WHO growth standard in Box-Cox (L, M, S) form
+type lmsPoint struct {
+	L, M, S float64
+}
+
+// WHO Child Growth Standards (2006), monthly values from birth to 12 months.
+// https://www.who.int/tools/child-growth-standards/standards
+var (
+	whoWeightForAgeBoys = []lmsPoint{
+		{0.3487, 3.3464, 0.14602}, {0.2297, 4.4709, 0.13395}, {0.1970, 5.5675, 0.12385},
+		{0.1738, 6.3762, 0.11727}, {0.1553, 7.0023, 0.11316}, {0.1395, 7.5105, 0.11080},
+		{0.1257, 7.9340, 0.10958}, {0.1134, 8.2970, 0.10902}, {0.1021, 8.6151, 0.10882},
+		{0.0917, 8.9014, 0.10881}, {0.0820, 9.1649, 0.10891}, {0.0730, 9.4122, 0.10906},
+		{0.0644, 9.6479, 0.10925},
+	}
+	whoWeightForAgeGirls = []lmsPoint{
+		{0.3809, 3.2322, 0.14171}, {0.1714, 4.1873, 0.13724}, {0.0962, 5.1282, 0.13000},
+		{0.0402, 5.8458, 0.12619}, {-0.0050, 6.4237, 0.12402}, {-0.0430, 6.8985, 0.12274},
+		{-0.0756, 7.2970, 0.12204}, {-0.1039, 7.6422, 0.12178}, {-0.1288, 7.9487, 0.12181},
+		{-0.1507, 8.2254, 0.12199}, {-0.1700, 8.4800, 0.12223}, {-0.1872, 8.7192, 0.12247},
+		{-0.2024, 8.9481, 0.12268},
+	}
+	whoLengthForAgeBoys = []lmsPoint{
+		{1, 49.8842, 0.03795}, {1, 54.7244, 0.03557}, {1, 58.4249, 0.03424},
+		{1, 61.4292, 0.03328}, {1, 63.8860, 0.03257}, {1, 65.9026, 0.03204},
+		{1, 67.6236, 0.03165}, {1, 69.1645, 0.03139}, {1, 70.5994, 0.03124},
+		{1, 71.9687, 0.03117}, {1, 73.2812, 0.03118}, {1, 74.5388, 0.03125},
+		{1, 75.7488, 0.03137},
+	}
+	whoLengthForAgeGirls = []lmsPoint{
+		{1, 49.1477, 0.03790}, {1, 53.6872, 0.03640}, {1, 57.0673, 0.03568},
+		{1, 59.8029, 0.03520}, {1, 62.0899, 0.03486}, {1, 64.0301, 0.03463},
+		{1, 65.7311, 0.03448}, {1, 67.2873, 0.03441}, {1, 68.7498, 0.03440},
+		{1, 70.1435, 0.03444}, {1, 71.4818, 0.03452}, {1, 72.7710, 0.03464},
+		{1, 74.0150, 0.03479},
+	}
+)
+
+const daysPerMonth = 30.4375
+
+// lmsAt interpolates the standard linearly between months. It returns false
+// outside the table's age range.
+func lmsAt(table []lmsPoint, ageDays int) (lmsPoint, bool) {
+	months := float64(ageDays) / daysPerMonth
+	if months < 0 || months > float64(len(table)-1) {
+		return lmsPoint{}, false
+	}
+	i := int(months)
+	if i == len(table)-1 {
+		return table[i], true
+	}
+	f := months - float64(i)
+	a, b := table[i], table[i+1]
+	return lmsPoint{
+		L: a.L + (b.L-a.L)*f,
+		M: a.M + (b.M-a.M)*f,
+		S: a.S + (b.S-a.S)*f,
+	}, true
+}
+
+// lmsPercentile converts a measurement to a percentile (0–100)
+func lmsPercentile(p lmsPoint, x float64) float64 {
+	var z float64
+	if p.L == 0 {
+		z = math.Log(x/p.M) / p.S
+	} else {
+		z = (math.Pow(x/p.M, p.L) - 1) / (p.L * p.S)
+	}
+	return round1(50 * (1 + math.Erf(z/math.Sqrt2)))
+}
+
+func growthTables(sex string) (weight, length []lmsPoint, ok bool) {
+	switch sex {
+	case models.BabySexMale:
+		return whoWeightForAgeBoys, whoLengthForAgeBoys, true
+	case models.BabySexFemale:
+		return whoWeightForAgeGirls, whoLengthForAgeGirls, true
+	}
+	return nil, nil, false
+}
+
+// ApplyGrowthPercentiles fills a measurement's WHO weight-for-age and
+// length-for-age percentiles. They are left empty when the baby's sex is
+// unknown or the measurement falls outside the 0–12 month tables.
+func ApplyGrowthPercentiles(baby models.Baby, m *models.GrowthMeasurement) {
+	weightTable, lengthTable, ok := growthTables(baby.Sex)
+	if !ok {
+		return
+	}
+	ageDays := daysBetween(truncateDay(baby.BirthDate), truncateDay(m.MeasuredAt))
+
+	if m.WeightKg != nil && *m.WeightKg > 0 {
+		if p, ok := lmsAt(weightTable, ageDays); ok {
+			pct := lmsPercentile(p, *m.WeightKg)
+			m.WeightPercentile = &pct
+		}
+	}
+	if m.LengthCm != nil && *m.LengthCm > 0 {
+		if p, ok := lmsAt(lengthTable, ageDays); ok {
+			pct := lmsPercentile(p, *m.LengthCm)
+			m.LengthPercentile = &pct
+		}
+	}
+}
+
+// GrowthCurvePoint is one age of a WHO reference curve
+type GrowthCurvePoint struct {
+	Month int     `json:"month"`
+	P3    float64 `json:"p3"`
+	P15   float64 `json:"p15"`
+	P50   float64 `json:"p50"`
+	P85   float64 `json:"p85"`
+	P97   float64 `json:"p97"`
+}
+
+// lmsValue returns the measurement at a given z-score
+func lmsValue(p lmsPoint, z float64) float64 {
+	if p.L == 0 {
+		return p.M * math.Exp(p.S*z)
+	}
+	return p.M * math.Pow(1+p.L*p.S*z, 1/p.L)
+}
+
+// GrowthReferenceCurves returns the 3rd–97th percentile WHO curves for plotting
+func GrowthReferenceCurves(sex string) (weight, length []GrowthCurvePoint) {
+	weightTable, lengthTable, ok := growthTables(sex)
+	if !ok {
+		return nil, nil
+	}
+	curve := func(table []lmsPoint) []GrowthCurvePoint {
+		var points []GrowthCurvePoint
+		for month, p := range table {
+			points = append(points, GrowthCurvePoint{
+				Month: month,
+				P3:    round2(lmsValue(p, -1.881)),
+				P15:   round2(lmsValue(p, -1.036)),
+				P50:   round2(p.M),
+				P85:   round2(lmsValue(p, 1.036)),
+				P97:   round2(lmsValue(p, 1.881)),
+			})
+		}
+		return points
+	}
+	return curve(weightTable), curve(lengthTable)
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Feed event titles are deliberately vague so a glance at a shared or
+// lock-screen calendar does not reveal health information.
+const (
+	icsPeriodTitle      = "🌸"
+	icsFertileTitle     = "🌱"
+	icsCheckupTitle     = "🩺"
+	icsAppointmentTitle = "📅"
+)
+
+// How far ahead the feed looks for predictions and events
+const icsHorizonDays = 180
+
+const feedTokenBytes = 24
+
+var ErrFeedTokenNotFound = errors.New("calendar feed token not found")
+
+// GetOrCreateFeedToken returns the user's feed token, creating one on first use
+func GetOrCreateFeedToken(userID uuid.UUID) (*models.CalendarFeedToken, error) {
+	var feed models.CalendarFeedToken
+	err := config.DB.Where("user_id = ?", userID).First(&feed).Error
+	if err == nil {
+		return &feed, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	token, err := utils.GenerateSecureToken(feedTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	feed = models.CalendarFeedToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Token:     token,
+		CreatedAt: time.Now(),
+	}
+	if err := config.DB.Create(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// RotateFeedToken replaces the user's feed token so previously shared URLs stop working
+func RotateFeedToken(userID uuid.UUID) (*models.CalendarFeedToken, error) {
+	feed, err := GetOrCreateFeedToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateSecureToken(feedTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	feed.Token = token
+	feed.RotatedAt = &now
+	if err := config.DB.Save(feed).Error; err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// FindUserByFeedToken resolves a feed token to its owner
+func FindUserByFeedToken(token string) (uuid.UUID, error) {
+	var feed models.CalendarFeedToken
+	if err := config.DB.Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, ErrFeedTokenNotFound
+		}
+		return uuid.Nil, err
+	}
+	return feed.UserID, nil
+}
+
+type icsEvent struct {
+	UID     string
+	Summary string
+	AllDay  bool
+	Start   time.Time
+	End     time.Time
+}
+
+// BuildICSFeed renders predicted periods, fertile windows, upcoming checkups
+// and appointments as an iCalendar document.
+func BuildICSFeed(userID uuid.UUID, now time.Time) (string, error) {
+	today := truncateDay(now)
+	horizon := today.AddDate(0, 0, icsHorizonDays)
+
+	paused, _, err := CyclePredictionsPaused(userID)
+	if err != nil {
+		return "", err
+	}
+
+	// Fertile windows do not apply on hormonal contraception
+	hormonal, err := OnHormonalContraception(userID, now)
+	if err != nil {
+		return "", err
+	}
+
+	// Only periods since the last pregnancy feed predictions
+	cycles, since, err := BaselineCycles(userID)
+	if err != nil {
+		return "", err
+	}
+	if since != nil && len(cycles) < MinBaselineCycles {
+		paused = true
+	}
+
+	var events []icsEvent
+	if !paused {
+		events = cyclePredictionEvents(userID, buildCycleSegments(cycles, horizon, true), today, hormonal)
+	}
+
+	var pregnancyCheckups []models.PregnancyCheckup
+	if err := config.DB.Where("user_id = ? AND next_checkup_at BETWEEN ? AND ?", userID, now, horizon).
+		Find(&pregnancyCheckups).Error; err != nil {
+		return "", err
+	}
+	for _, c := range pregnancyCheckups {
+		events = append(events, icsEvent{
+			UID:     icsUID("checkup", userID, c.ID.String()),
+			Summary: icsCheckupTitle,
+			Start:   c.NextCheckupAt,
+			End:     c.NextCheckupAt.Add(time.Hour),
+		})
+	}
+
+	var postpartumCheckups []models.PostpartumCheckup
+	if err := config.DB.Where("user_id = ? AND next_checkup_at BETWEEN ? AND ?", userID, now, horizon).
+		Find(&postpartumCheckups).Error; err != nil {
+		return "", err
+	}
+	for _, c := range postpartumCheckups {
+		events = append(events, icsEvent{
+			UID:     icsUID("checkup", userID, c.ID.String()),
+			Summary: icsCheckupTitle,
+			Start:   c.NextCheckupAt,
+			End:     c.NextCheckupAt.Add(time.Hour),
+		})
+	}
+
+	var appointments []models.Appointment
+	if err := config.DB.Where("user_id = ? AND status IN ? AND scheduled_at BETWEEN ? AND ?", userID,
+		[]string{models.AppointmentRequested, models.AppointmentConfirmed}, now, horizon).
+		Find(&appointments).Error; err != nil {
+		return "", err
+	}
+	for _, a := range appointments {
+		end := a.EndsAt
+		if !end.After(a.ScheduledAt) {
+			end = a.ScheduledAt.Add(time.Hour)
+		}
+		events = append(events, icsEvent{
+			UID:     icsUID("appointment", userID, a.ID.String()),
+			Summary: icsAppointmentTitle,
+			Start:   a.ScheduledAt,
+			End:     end,
+		})
+	}
+
+	return renderICS(events, now), nil
+}
+
+// cyclePredictionEvents lists predicted periods and fertile windows that have
+// not already ended by today. Logged periods are left out of the feed.
+func cyclePredictionEvents(userID uuid.UUID, segments []cycleSegment, today time.Time, hormonal bool) []icsEvent {
+	var events []icsEvent
+	for _, seg := range segments {
+		periodEnd := seg.Start.AddDate(0, 0, DefaultPeriodLength)
+		if seg.Predicted && periodEnd.After(today) {
+			events = append(events, icsEvent{
+				UID:     icsUID("period", userID, seg.Start.Format("20060102")),
+				Summary: icsPeriodTitle,
+				AllDay:  true,
+				Start:   seg.Start,
+				End:     periodEnd,
+			})
+		}
+		fertileStart, fertileEnd := seg.FertileWindow()
+		if hormonal || fertileEnd.Before(today) {
+			continue
+		}
+		events = append(events, icsEvent{
+			UID:     icsUID("fertile", userID, fertileStart.Format("20060102")),
+			Summary: icsFertileTitle,
+			AllDay:  true,
+			Start:   fertileStart,
+			End:     fertileEnd.AddDate(0, 0, 1),
+		})
+	}
+	return events
+}
+
+// icsUID builds an event UID that stays unique across every user's feed, e.g.
+// period-<user id>-20260613@cycle-backend
+func icsUID(kind string, userID uuid.UUID, key string) string {
+	return kind + "-" + userID.String() + "-" + key + "@cycle-backend"
+}
+
+func renderICS(events []icsEvent, now time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICSLine(s))
+		b.WriteString("\r\n")
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//cycle-backend//Cycle Calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICSText(icsPeriodTitle))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE:" + e.End.Format("20060102"))
+			line("TRANSP:TRANSPARENT")
+		} else {
+			line("DTSTART:" + e.Start.UTC().Format("20060102T150405Z"))
+			line("DTEND:" + e.End.UTC().Format("20060102T150405Z"))
+		}
+		line("SUMMARY:" + escapeICSText(e.Summary))
+		line("CLASS:PRIVATE")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+func escapeICSText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// foldICSLine splits content lines longer than 75 octets as required by RFC 5545,
+// taking care not to split multi-byte characters.
+func foldICSLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCyclePredictionEventsSkipsPastPredictions(t *testing.T) {
+	today := date(2026, 6, 15)
+	segments := []cycleSegment{
+		{Start: date(2026, 5, 20), Length: 28},                  // logged; its fertile window is over
+		{Start: date(2026, 6, 1), Length: 28, Predicted: true},  // period over, fertile window still open
+		{Start: date(2026, 6, 13), Length: 28, Predicted: true}, // period still under way
+		{Start: date(2026, 7, 11), Length: 28, Predicted: true},
+	}
+
+	userID := uuid.MustParse("6f1c2a9e-0b7d-4e15-9a3c-2d8e5f4b1a70")
+	var periods, fertile []string
+	for _, e := range cyclePredictionEvents(userID, segments, today, false) {
+		switch {
+		case strings.HasPrefix(e.UID, "period-"):
+			periods = append(periods, e.UID)
+		case strings.HasPrefix(e.UID, "fertile-"):
+			fertile = append(fertile, e.UID)
+		}
+	}
+	uid := func(kind, day string) string {
+		return kind + "-6f1c2a9e-0b7d-4e15-9a3c-2d8e5f4b1a70-" + day + "@cycle-backend"
+	}
+	if want := []string{uid("period", "20260613"), uid("period", "20260711")}; strings.Join(periods, ",") != strings.Join(want, ",") {
+		t.Fatalf("periods = %v, want %v", periods, want)
+	}
+	if want := []string{uid("fertile", "20260610"), uid("fertile", "20260622"), uid("fertile", "20260720")}; strings.Join(fertile, ",") != strings.Join(want, ",") {
+		t.Fatalf("fertile windows = %v, want %v", fertile, want)
+	}
+}
+
+func TestCyclePredictionEventsHormonalHidesFertileWindows(t *testing.T) {
+	segments := []cycleSegment{{Start: date(2026, 7, 11), Length: 28, Predicted: true}}
+	for _, e := range cyclePredictionEvents(uuid.New(), segments, date(2026, 6, 15), true) {
+		if strings.HasPrefix(e.UID, "fertile-") {
+			t.Fatalf("unexpected fertile window %s on hormonal contraception", e.UID)
+		}
+	}
+}
+
+func TestCyclePredictionEventsUIDsDifferAcrossUsers(t *testing.T) {
+	segments := []cycleSegment{{Start: date(2026, 7, 11), Length: 28, Predicted: true}}
+	first := cyclePredictionEvents(uuid.New(), segments, date(2026, 6, 15), false)
+	second := cyclePredictionEvents(uuid.New(), segments, date(2026, 6, 15), false)
+	if len(first) == 0 || len(first) != len(second) {
+		t.Fatalf("got %d and %d events", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].UID == second[i].UID {
+			t.Errorf("two users share the UID %s", first[i].UID)
+		}
+	}
+}
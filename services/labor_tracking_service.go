@@ -0,0 +1,473 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrSessionInProgress      = errors.New("a session is already in progress")
+	ErrSessionEnded           = errors.New("session has already ended")
+	ErrContractionOpen        = errors.New("a contraction is already being timed")
+	ErrNoContractionOpen      = errors.New("no contraction is being timed")
+	ErrInvalidEventTime       = errors.New("event time must be within the session and not in the future")
+	ErrTrackingNeedsPregnancy = errors.New("an active pregnancy is required")
+)
+
+// Kick counting thresholds
+const (
+	DefaultTargetKicks = 10
+	// Not feeling the target number of movements within this time warrants a call to the maternity unit
+	kickTargetWindow = 2 * time.Hour
+	// Sessions used to build the user's own baseline
+	kickBaselineSessions    = 10
+	kickBaselineMinSessions = 3
+	// A session is flagged when it takes this many times longer than usual to reach the target
+	kickSlowdownFactor = 2.0
+	// Sessions reaching the target faster than this are never flagged against the baseline
+	kickSlowdownFloorMinutes = 30.0
+)
+
+// 5-1-1 rule: contractions at most 5 minutes apart, lasting a minute, for an hour
+const (
+	rule511MaxInterval = 5 * time.Minute
+	rule511MinDuration = time.Minute
+	rule511Span        = time.Hour
+)
+
+const reducedMovementMessage = "Your baby's movements seem reduced compared with usual. Please contact your midwife or maternity unit now; do not wait until tomorrow."
+
+// KickBaseline summarises the user's usual time to reach the target kick count
+type KickBaseline struct {
+	Sessions              int      `json:"sessions"`
+	MedianMinutesToTarget *float64 `json:"median_minutes_to_target,omitempty"`
+	Sufficient            bool     `json:"sufficient"`
+}
+
+// GetKickBaseline computes the median time to target over recent completed sessions
+func GetKickBaseline(userID, pregnancyID uuid.UUID, excludeID uuid.UUID) (KickBaseline, error) {
+	var sessions []models.KickSession
+	if err := config.DB.Where("user_id = ? AND pregnancy_id = ? AND id <> ? AND ended_at IS NOT NULL AND minutes_to_target IS NOT NULL",
+		userID, pregnancyID, excludeID).
+		Order("started_at desc").Limit(kickBaselineSessions).Find(&sessions).Error; err != nil {
+		return KickBaseline{}, err
+	}
+
+	baseline := KickBaseline{Sessions: len(sessions)}
+	if len(sessions) == 0 {
+		return baseline, nil
+	}
+	var minutes []float64
+	for _, s := range sessions {
+		minutes = append(minutes, *s.MinutesToTarget)
+	}
+	sort.Float64s(minutes)
+	median := minutes[len(minutes)/2]
+	if len(minutes)%2 == 0 {
+		median = (minutes[len(minutes)/2-1] + minutes[len(minutes)/2]) / 2
+	}
+	median = round1(median)
+	baseline.MedianMinutesToTarget = &median
+	baseline.Sufficient = len(sessions) >= kickBaselineMinSessions
+	return baseline, nil
+}
+
+// StartKickSession opens a kick counting session for the user's active pregnancy
+func StartKickSession(userID uuid.UUID, now time.Time) (*models.KickSession, error) {
+	pregnancy, err := GetActivePregnancy(userID)
+	if errors.Is(err, ErrNoActivePregnancy) {
+		return nil, ErrTrackingNeedsPregnancy
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.KickSession{
+		ID:          uuid.New(),
+		UserID:      userID,
+		PregnancyID: pregnancy.ID,
+		StartedAt:   now,
+		TargetKicks: DefaultTargetKicks,
+		CreatedAt:   now,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureNoOpenSession(tx, &models.KickSession{}, "kick-session", userID); err != nil {
+			return err
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ensureNoOpenSession serialises starting sessions of one kind per user and
+// refuses a second open session
+func ensureNoOpenSession(tx *gorm.DB, model interface{}, kind string, userID uuid.UUID) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", kind+":"+userID.String()).Error; err != nil {
+		return err
+	}
+	var open int64
+	if err := tx.Model(model).Where("user_id = ? AND ended_at IS NULL", userID).Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrSessionInProgress
+	}
+	return nil
+}
+
+func findKickSession(userID, sessionID uuid.UUID) (*models.KickSession, error) {
+	var session models.KickSession
+	if err := config.DB.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RecordKick adds a movement to an open session. The time to reach the target
+// is captured when the target count is hit.
+func RecordKick(userID, sessionID uuid.UUID, at time.Time) (*models.KickSession, error) {
+	session, err := findKickSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, ErrSessionEnded
+	}
+	if at.Before(session.StartedAt) || at.After(time.Now().Add(time.Minute)) {
+		return nil, ErrInvalidEventTime
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the session so taps arriving together each add to the latest count
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", session.ID).First(session).Error; err != nil {
+			return err
+		}
+		if session.EndedAt != nil {
+			return ErrSessionEnded
+		}
+		if err := tx.Create(&models.KickEvent{ID: uuid.New(), SessionID: session.ID, OccurredAt: at}).Error; err != nil {
+			return err
+		}
+		session.KickCount++
+		if session.KickCount == session.TargetKicks && session.MinutesToTarget == nil {
+			minutes := round1(at.Sub(session.StartedAt).Minutes())
+			session.MinutesToTarget = &minutes
+		}
+		return tx.Model(session).Updates(map[string]interface{}{
+			"kick_count":        session.KickCount,
+			"minutes_to_target": session.MinutesToTarget,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// EndKickSession closes a session and compares it with the user's baseline.
+// Movement is flagged as reduced when the target was not reached within two
+// hours, or when it took much longer than the user's usual time.
+func EndKickSession(userID, sessionID uuid.UUID, notes string, now time.Time) (*models.KickSession, *KickBaseline, error) {
+	session, err := findKickSession(userID, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, nil, ErrSessionEnded
+	}
+
+	baseline, err := GetKickBaseline(userID, session.PregnancyID, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the session so a kick or a second end arriving together is not lost
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", session.ID).First(session).Error; err != nil {
+			return err
+		}
+		if session.EndedAt != nil {
+			return ErrSessionEnded
+		}
+
+		elapsed := now.Sub(session.StartedAt)
+		switch {
+		case session.MinutesToTarget == nil && elapsed >= kickTargetWindow:
+			session.ReducedMovement = true
+		case session.MinutesToTarget != nil && *session.MinutesToTarget > kickTargetWindow.Minutes():
+			session.ReducedMovement = true
+		case session.MinutesToTarget != nil && baseline.Sufficient &&
+			*session.MinutesToTarget > math.Max(*baseline.MedianMinutesToTarget*kickSlowdownFactor, kickSlowdownFloorMinutes):
+			session.ReducedMovement = true
+		}
+
+		session.EndedAt = &now
+		session.Notes = notes
+		return tx.Model(session).Updates(map[string]interface{}{
+			"ended_at":         session.EndedAt,
+			"notes":            session.Notes,
+			"reduced_movement": session.ReducedMovement,
+		}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.ReducedMovement {
+		if err := Notify(userID, models.NotificationTypeAlert, "Reduced baby movements",
+			reducedMovementMessage, "/kicks/sessions/"+session.ID.String()); err != nil {
+			log.Printf("❌ Failed to send reduced movement alert to %s: %v", userID, err)
+		}
+	}
+	return session, &baseline, nil
+}
+
+// ContractionStats summarises the contractions of a session
+type ContractionStats struct {
+	Count              int      `json:"count"`
+	AverageDurationSec *float64 `json:"average_duration_sec,omitempty"`
+	AverageIntervalSec *float64 `json:"average_interval_sec,omitempty"` // start to start
+	LastHourCount      int      `json:"last_hour_count"`
+	LastHourDuration   *float64 `json:"last_hour_average_duration_sec,omitempty"`
+	LastHourInterval   *float64 `json:"last_hour_average_interval_sec,omitempty"`
+	Rule511            bool     `json:"rule_511"`
+}
+
+func averageSeconds(ds []time.Duration) *float64 {
+	if len(ds) == 0 {
+		return nil
+	}
+	var total time.Duration
+	for _, d := range ds {
+		total += d
+	}
+	avg := round1(total.Seconds() / float64(len(ds)))
+	return &avg
+}
+
+// ComputeContractionStats returns frequency and duration figures and whether the
+// most recent hour of contractions meets the 5-1-1 rule. Contractions must be
+// ordered by start time; ones still being timed are ignored.
+func ComputeContractionStats(contractions []models.Contraction) ContractionStats {
+	var done []models.Contraction
+	for _, c := range contractions {
+		if c.EndedAt != nil {
+			done = append(done, c)
+		}
+	}
+	stats := ContractionStats{Count: len(done)}
+	if len(done) == 0 {
+		return stats
+	}
+
+	durationsAndIntervals := func(cs []models.Contraction) (durations, intervals []time.Duration) {
+		for i, c := range cs {
+			durations = append(durations, c.EndedAt.Sub(c.StartedAt))
+			if i > 0 {
+				intervals = append(intervals, c.StartedAt.Sub(cs[i-1].StartedAt))
+			}
+		}
+		return
+	}
+
+	durations, intervals := durationsAndIntervals(done)
+	stats.AverageDurationSec = averageSeconds(durations)
+	stats.AverageIntervalSec = averageSeconds(intervals)
+
+	last := done[len(done)-1]
+	windowStart := last.EndedAt.Add(-rule511Span)
+	first := sort.Search(len(done), func(i int) bool { return !done[i].StartedAt.Before(windowStart) })
+	hour := done[first:]
+	hourDurations, hourIntervals := durationsAndIntervals(hour)
+	stats.LastHourCount = len(hour)
+	stats.LastHourDuration = averageSeconds(hourDurations)
+	stats.LastHourInterval = averageSeconds(hourIntervals)
+
+	// The pattern must cover the whole hour: it starts within one interval of the
+	// window opening, every gap is at most 5 minutes and contractions last a minute
+	if len(hour) < 2 || hour[0].StartedAt.Sub(windowStart) > rule511MaxInterval {
+		return stats
+	}
+	for _, iv := range hourIntervals {
+		if iv > rule511MaxInterval {
+			return stats
+		}
+	}
+	stats.Rule511 = *stats.LastHourDuration >= rule511MinDuration.Seconds()
+	return stats
+}
+
+// StartContractionSession opens a contraction timing session
+func StartContractionSession(userID uuid.UUID, now time.Time) (*models.ContractionSession, error) {
+	pregnancy, err := GetActivePregnancy(userID)
+	if errors.Is(err, ErrNoActivePregnancy) {
+		return nil, ErrTrackingNeedsPregnancy
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.ContractionSession{
+		ID:          uuid.New(),
+		UserID:      userID,
+		PregnancyID: pregnancy.ID,
+		StartedAt:   now,
+		CreatedAt:   now,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureNoOpenSession(tx, &models.ContractionSession{}, "contraction-session", userID); err != nil {
+			return err
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// LoadContractionSession loads a session with its contractions in order
+func LoadContractionSession(userID, sessionID uuid.UUID) (*models.ContractionSession, error) {
+	var session models.ContractionSession
+	err := config.DB.Preload("Contractions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("started_at asc")
+	}).Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// StartContraction begins timing a contraction
+func StartContraction(userID, sessionID uuid.UUID, at time.Time) (*models.Contraction, error) {
+	session, err := LoadContractionSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, ErrSessionEnded
+	}
+	if at.Before(session.StartedAt) || at.After(time.Now().Add(time.Minute)) {
+		return nil, ErrInvalidEventTime
+	}
+	if n := len(session.Contractions); n > 0 {
+		latest := session.Contractions[n-1]
+		if latest.EndedAt == nil {
+			return nil, ErrContractionOpen
+		}
+		if at.Before(*latest.EndedAt) {
+			return nil, ErrInvalidEventTime
+		}
+	}
+
+	contraction := models.Contraction{ID: uuid.New(), SessionID: session.ID, StartedAt: at}
+	if err := config.DB.Create(&contraction).Error; err != nil {
+		return nil, err
+	}
+	return &contraction, nil
+}
+
+// StopContraction ends the contraction being timed, recomputes the session
+// statistics and raises an alert the first time the 5-1-1 rule is met.
+func StopContraction(userID, sessionID uuid.UUID, at time.Time) (*models.ContractionSession, ContractionStats, error) {
+	var session models.ContractionSession
+	var stats ContractionStats
+	firstRule511 := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the session so a double tap stops the contraction and alerts only once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionNotFound
+			}
+			return err
+		}
+		if err := tx.Where("session_id = ?", session.ID).Order("started_at asc").Find(&session.Contractions).Error; err != nil {
+			return err
+		}
+		n := len(session.Contractions)
+		if n == 0 || session.Contractions[n-1].EndedAt != nil {
+			return ErrNoContractionOpen
+		}
+		current := &session.Contractions[n-1]
+		if at.Before(current.StartedAt) || at.After(time.Now().Add(time.Minute)) {
+			return ErrInvalidEventTime
+		}
+
+		current.EndedAt = &at
+		if err := tx.Model(current).Update("ended_at", at).Error; err != nil {
+			return err
+		}
+
+		stats = ComputeContractionStats(session.Contractions)
+		if !stats.Rule511 || session.Rule511MetAt != nil {
+			return nil
+		}
+		firstRule511 = true
+		session.Rule511MetAt = &at
+		return tx.Model(&session).Update("rule511_met_at", at).Error
+	})
+	if err != nil {
+		return nil, ContractionStats{}, err
+	}
+
+	if firstRule511 {
+		message := fmt.Sprintf("Your contractions have been about %s apart and lasting about %s for an hour (5-1-1). Contact your midwife or maternity unit, or follow your birth plan.",
+			formatSeconds(*stats.LastHourInterval), formatSeconds(*stats.LastHourDuration))
+		if err := Notify(userID, models.NotificationTypeAlert, "Time to call your provider", message,
+			"/contractions/sessions/"+session.ID.String()); err != nil {
+			log.Printf("❌ Failed to send 5-1-1 alert to %s: %v", userID, err)
+		}
+	}
+	return &session, stats, nil
+}
+
+// EndContractionSession closes a session, discarding a contraction left running
+func EndContractionSession(userID, sessionID uuid.UUID, notes string, now time.Time) (*models.ContractionSession, error) {
+	session, err := LoadContractionSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, ErrSessionEnded
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ? AND ended_at IS NULL", session.ID).Delete(&models.Contraction{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(session).Updates(map[string]interface{}{"ended_at": now, "notes": notes}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return LoadContractionSession(userID, sessionID)
+}
+
+func formatSeconds(sec float64) string {
+	d := time.Duration(sec) * time.Second
+	if d < time.Minute {
+		return fmt.Sprintf("%d sec", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%d min %02d sec", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// A scheduled dose becomes "missed" once it is this late without a log
+const missedDoseGracePeriod = 2 * time.Hour
+
+// Missed-dose checks only look this far back so old schedules are not backfilled
+const missedDoseLookback = 48 * time.Hour
+
+var ErrInvalidDoseTime = errors.New("dose_time must be HH:MM")
+
+// AdherenceStats summarises dose logs against the schedule for a period
+type AdherenceStats struct {
+	MedicationID  uuid.UUID `json:"medication_id"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	ExpectedDoses int       `json:"expected_doses"`
+	Taken         int       `json:"taken"`
+	Missed        int       `json:"missed"`
+	Skipped       int       `json:"skipped"`
+	Unlogged      int       `json:"unlogged"`
+	AdherenceRate float64   `json:"adherence_rate"` // taken / expected, 0–1
+	CurrentStreak int       `json:"current_streak"` // consecutive taken doses up to now
+}
+
+// ParseDoseTime validates an "HH:MM" dose time and returns hours and minutes
+func ParseDoseTime(doseTime string) (int, int, error) {
+	if doseTime == "" {
+		return 0, 0, nil
+	}
+	t, err := time.Parse("15:04", doseTime)
+	if err != nil {
+		return 0, 0, ErrInvalidDoseTime
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// Combined pills taken for 21 days followed by a 7-day pill-free break
+const (
+	pillActiveDays = 21
+	pillCycleDays  = 28
+)
+
+// ScheduledDoses lists the dose times for a medication within [from, to].
+// Every occurrence is counted from the start date, so monthly doses started on
+// the 31st fall on the last day of shorter months and return to the 31st after.
+func ScheduledDoses(med models.Medication, from, to time.Time) []time.Time {
+	hour, minute, err := ParseDoseTime(med.DoseTime)
+	if err != nil {
+		return nil
+	}
+	start := med.StartDate.UTC()
+	first := time.Date(start.Year(), start.Month(), start.Day(), hour, minute, 0, 0, time.UTC)
+
+	var occurrence func(n int) (time.Time, bool)
+	switch med.Frequency {
+	case models.DoseFrequencyDaily:
+		occurrence = func(n int) (time.Time, bool) { return first.AddDate(0, 0, n), true }
+	case models.DoseFrequencyDaily21Of7:
+		// Break days are not doses, so they are never expected or flagged as missed
+		occurrence = func(n int) (time.Time, bool) { return first.AddDate(0, 0, n), n%pillCycleDays < pillActiveDays }
+	case models.DoseFrequencyWeekly:
+		occurrence = func(n int) (time.Time, bool) { return first.AddDate(0, 0, 7*n), true }
+	case models.DoseFrequencyMonthly:
+		occurrence = func(n int) (time.Time, bool) { return addMonthsClamped(first, n), true }
+	default:
+		return nil
+	}
+
+	if med.EndDate != nil && med.EndDate.Before(to) {
+		to = *med.EndDate
+	}
+
+	var doses []time.Time
+	for n := 0; ; n++ {
+		dose, scheduled := occurrence(n)
+		if dose.After(to) {
+			break
+		}
+		if scheduled && !dose.Before(from) && !dose.Before(med.StartDate) {
+			doses = append(doses, dose)
+		}
+	}
+	return doses
+}
+
+// addMonthsClamped moves t forward n calendar months, keeping the day of the
+// month where it exists and using the month's last day where it does not
+func addMonthsClamped(t time.Time, n int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// NearestScheduledDose returns the dose slot closest to at, so a dose logged
+// without an explicit slot still counts against the schedule. ok is false for
+// medications without scheduled doses or when no slot is within a month.
+func NearestScheduledDose(med models.Medication, at time.Time) (time.Time, bool) {
+	var nearest time.Time
+	var best time.Duration
+	for _, d := range ScheduledDoses(med, at.AddDate(0, 0, -32), at.AddDate(0, 0, 32)) {
+		diff := d.Sub(at)
+		if diff < 0 {
+			diff = -diff
+		}
+		if nearest.IsZero() || diff < best {
+			nearest, best = d, diff
+		}
+	}
+	return nearest, !nearest.IsZero()
+}
+
+// CalculateAdherence compares scheduled doses with what the user logged
+func CalculateAdherence(med models.Medication, logs []models.DoseLog, from, to time.Time) AdherenceStats {
+	stats := AdherenceStats{MedicationID: med.ID, From: from, To: to}
+
+	byTime := map[int64]models.DoseLog{}
+	for _, l := range logs {
+		byTime[l.ScheduledFor.UTC().Unix()] = l
+	}
+
+	doses := ScheduledDoses(med, from, to)
+	stats.ExpectedDoses = len(doses)
+	for _, d := range doses {
+		l, ok := byTime[d.Unix()]
+		switch {
+		case !ok:
+			stats.Unlogged++
+		case l.Status == models.DoseStatusTaken:
+			stats.Taken++
+		case l.Status == models.DoseStatusSkipped:
+			stats.Skipped++
+		default:
+			stats.Missed++
+		}
+	}
+
+	for i := len(doses) - 1; i >= 0; i-- {
+		if l, ok := byTime[doses[i].Unix()]; ok && l.Status == models.DoseStatusTaken {
+			stats.CurrentStreak++
+			continue
+		}
+		break
+	}
+
+	if stats.ExpectedDoses > 0 {
+		stats.AdherenceRate = float64(stats.Taken) / float64(stats.ExpectedDoses)
+	}
+	return stats
+}
+
+// OnHormonalContraception reports whether the user is using an active hormonal
+// contraceptive at the given time. Bleeds during this time are withdrawal bleeds.
+func OnHormonalContraception(userID uuid.UUID, at time.Time) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.Medication{}).
+		Where("user_id = ? AND active = ? AND is_contraceptive = ? AND is_hormonal = ?", userID, true, true, true).
+		Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", at, at).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CheckMissedDoses records scheduled doses that passed the grace period without
+// a log as missed and notifies the user. Safe to run repeatedly.
+func CheckMissedDoses(now time.Time) error {
+	var meds []models.Medication
+	if err := config.DB.Where("active = ? AND reminders_enabled = ? AND frequency IN ?", true, true,
+		[]string{models.DoseFrequencyDaily, models.DoseFrequencyDaily21Of7, models.DoseFrequencyWeekly, models.DoseFrequencyMonthly}).
+		Find(&meds).Error; err != nil {
+		return err
+	}
+
+	for _, med := range meds {
+		for _, dose := range ScheduledDoses(med, now.Add(-missedDoseLookback), now.Add(-missedDoseGracePeriod)) {
+			err := config.DB.Transaction(func(tx *gorm.DB) error {
+				log := models.DoseLog{
+					ID:           uuid.New(),
+					MedicationID: med.ID,
+					UserID:       med.UserID,
+					ScheduledFor: dose,
+					Status:       models.DoseStatusMissed,
+					CreatedAt:    now,
+				}
+				// The unique index on (medication_id, scheduled_for) makes this a no-op for logged doses
+				result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&log)
+				if result.Error != nil || result.RowsAffected == 0 {
+					return result.Error
+				}
+				// Written with the log, so a failed run neither loses nor repeats the notice
+				return notifyTx(tx, med.UserID, models.NotificationTypeReminder,
+					"Missed dose",
+					"It looks like you missed your "+med.Name+" dose scheduled for "+dose.Format("Jan 2 15:04")+" UTC.",
+					"/medications/"+med.ID.String())
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestNearestScheduledDose(t *testing.T) {
+	med := models.Medication{Frequency: models.DoseFrequencyDaily, DoseTime: "08:00", StartDate: date(2026, 6, 1)}
+
+	// Taken a little late: counts for this morning's dose
+	got, ok := NearestScheduledDose(med, time.Date(2026, 6, 10, 9, 40, 0, 0, time.UTC))
+	if want := time.Date(2026, 6, 10, 8, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Fatalf("late dose: got %v (ok=%v), want %v", got, ok, want)
+	}
+	// Taken late in the evening: closer to tomorrow's dose
+	got, ok = NearestScheduledDose(med, time.Date(2026, 6, 10, 21, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 6, 11, 8, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Fatalf("evening dose: got %v (ok=%v), want %v", got, ok, want)
+	}
+}
+
+func TestNearestScheduledDoseWithoutSchedule(t *testing.T) {
+	med := models.Medication{Frequency: models.DoseFrequencyAsNeeded, StartDate: date(2026, 6, 1)}
+	if _, ok := NearestScheduledDose(med, date(2026, 6, 10)); ok {
+		t.Fatal("as-needed medication should have no scheduled slot")
+	}
+	ended := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	med = models.Medication{Frequency: models.DoseFrequencyDaily, DoseTime: "08:00", StartDate: date(2025, 6, 1), EndDate: &ended}
+	if _, ok := NearestScheduledDose(med, date(2026, 6, 10)); ok {
+		t.Fatal("no slot expected months after the medication ended")
+	}
+}
+
+func TestScheduledDosesMonthlyClampsToMonthEnd(t *testing.T) {
+	med := models.Medication{Frequency: models.DoseFrequencyMonthly, DoseTime: "09:00", StartDate: date(2026, 1, 31)}
+	got := ScheduledDoses(med, date(2026, 1, 1), date(2026, 6, 1))
+	want := []time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31), date(2026, 4, 30), date(2026, 5, 31)}
+	if len(got) != len(want) {
+		t.Fatalf("got %d doses %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if w := want[i].Add(9 * time.Hour); !got[i].Equal(w) {
+			t.Errorf("dose %d = %v, want %v", i, got[i], w)
+		}
+	}
+}
+
+func TestScheduledDosesPillFreeBreak(t *testing.T) {
+	med := models.Medication{Frequency: models.DoseFrequencyDaily21Of7, DoseTime: "08:00", StartDate: date(2026, 3, 1)}
+
+	// Two full packs: 21 pill days each, none during the 7-day breaks
+	doses := ScheduledDoses(med, date(2026, 3, 1), date(2026, 4, 26))
+	if len(doses) != 42 {
+		t.Fatalf("got %d doses over two packs, want 42", len(doses))
+	}
+	taken := map[time.Time]bool{}
+	for _, d := range doses {
+		taken[d] = true
+	}
+	at8 := func(d time.Time) time.Time { return d.Add(8 * time.Hour) }
+	cases := []struct {
+		day  time.Time
+		dose bool
+	}{
+		{date(2026, 3, 21), true},  // last pill of the first pack
+		{date(2026, 3, 22), false}, // break starts
+		{date(2026, 3, 28), false}, // break ends
+		{date(2026, 3, 29), true},  // second pack starts
+		{date(2026, 4, 19), false}, // second break
+	}
+	for _, c := range cases {
+		if taken[at8(c.day)] != c.dose {
+			t.Errorf("%s: dose scheduled = %v, want %v", c.day.Format("Jan 2"), taken[at8(c.day)], c.dose)
+		}
+	}
+}
@@ -0,0 +1,580 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"github.com/shem958/cycle-backend/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownMonitoringType    = errors.New("no monitoring template exists for this type")
+	ErrInvalidMonitoringFields  = errors.New("invalid template fields")
+	ErrInvalidMonitoringData    = errors.New("data does not match the monitoring template")
+	ErrInvalidMonitoringFilter  = errors.New("filters must look like key:op:value with op one of eq, ne, gt, gte, lt, lte")
+	ErrMonitoringRecordNotFound = errors.New("monitoring record not found")
+	ErrBuiltInMonitoringType    = errors.New("built-in monitoring types cannot be deleted, only reset")
+)
+
+var monitoringKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+func float64Ptr(v float64) *float64 { return &v }
+
+var severityOptions = []string{"none", "mild", "moderate", "severe"}
+
+// DefaultMonitoringTemplates are used for the built-in types until an admin saves a template
+var DefaultMonitoringTemplates = map[string]models.MonitoringTemplate{
+	models.MonitoringTypePregnancy: {
+		Type: models.MonitoringTypePregnancy, Name: "Pregnancy monitoring", Version: 1,
+		Fields: models.MonitoringFields{
+			{Key: "fetal_movements", Label: "Fetal movements counted", Type: models.MonitoringFieldInteger, Unit: "kicks", Min: float64Ptr(0), Max: float64Ptr(200)},
+			{Key: "contractions_per_hour", Label: "Contractions per hour", Type: models.MonitoringFieldInteger, Min: float64Ptr(0), Max: float64Ptr(30)},
+			{Key: "swelling", Label: "Swelling", Type: models.MonitoringFieldEnum, Options: severityOptions},
+			{Key: "nausea", Label: "Nausea", Type: models.MonitoringFieldEnum, Options: severityOptions},
+			{Key: "headache", Label: "Headache", Type: models.MonitoringFieldBoolean},
+			{Key: "vision_changes", Label: "Vision changes", Type: models.MonitoringFieldBoolean},
+			{Key: "energy", Label: "Energy level", Type: models.MonitoringFieldInteger, Min: float64Ptr(1), Max: float64Ptr(10)},
+		},
+	},
+	models.MonitoringTypePostpartum: {
+		Type: models.MonitoringTypePostpartum, Name: "Postpartum monitoring", Version: 1,
+		Fields: models.MonitoringFields{
+			{Key: "bleeding", Label: "Bleeding", Type: models.MonitoringFieldEnum, Options: []string{"none", "spotting", "light", "moderate", "heavy"}},
+			{Key: "pain_level", Label: "Pain level", Type: models.MonitoringFieldInteger, Min: float64Ptr(0), Max: float64Ptr(10)},
+			{Key: "incision_healing", Label: "Incision healing", Type: models.MonitoringFieldEnum, Options: []string{"not_applicable", "good", "redness", "discharge"}},
+			{Key: "breast_pain", Label: "Breast pain", Type: models.MonitoringFieldBoolean},
+			{Key: "temperature_c", Label: "Temperature", Type: models.MonitoringFieldNumber, Unit: "°C", Min: float64Ptr(34), Max: float64Ptr(43)},
+			{Key: "sleep_hours", Label: "Sleep", Type: models.MonitoringFieldNumber, Unit: "h", Min: float64Ptr(0), Max: float64Ptr(24)},
+			{Key: "mood", Label: "Mood", Type: models.MonitoringFieldInteger, Min: float64Ptr(1), Max: float64Ptr(10)},
+		},
+	},
+}
+
+// ListMonitoringTemplates returns the saved templates plus any built-in defaults not yet overridden
+func ListMonitoringTemplates() ([]models.MonitoringTemplate, error) {
+	var saved []models.MonitoringTemplate
+	if err := config.DB.Find(&saved).Error; err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, t := range saved {
+		seen[t.Type] = true
+	}
+	for key, t := range DefaultMonitoringTemplates {
+		if !seen[key] {
+			saved = append(saved, t)
+		}
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Type < saved[j].Type })
+	return saved, nil
+}
+
+// GetMonitoringTemplate returns the template for a monitoring type
+func GetMonitoringTemplate(monitoringType string) (*models.MonitoringTemplate, error) {
+	var template models.MonitoringTemplate
+	err := config.DB.Where("type = ?", monitoringType).First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		def, ok := DefaultMonitoringTemplates[monitoringType]
+		if !ok {
+			return nil, ErrUnknownMonitoringType
+		}
+		return &def, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ValidateMonitoringFields checks an admin-supplied field list
+func ValidateMonitoringFields(fields models.MonitoringFields) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: at least one field is required", ErrInvalidMonitoringFields)
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if !monitoringKeyPattern.MatchString(f.Key) {
+			return fmt.Errorf("%w: key %q must be lowercase letters, digits and underscores", ErrInvalidMonitoringFields, f.Key)
+		}
+		if seen[f.Key] {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidMonitoringFields, f.Key)
+		}
+		seen[f.Key] = true
+		switch f.Type {
+		case models.MonitoringFieldNumber, models.MonitoringFieldInteger:
+			if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+				return fmt.Errorf("%w: %s has min above max", ErrInvalidMonitoringFields, f.Key)
+			}
+		case models.MonitoringFieldEnum:
+			if len(f.Options) == 0 {
+				return fmt.Errorf("%w: enum %s needs options", ErrInvalidMonitoringFields, f.Key)
+			}
+		case models.MonitoringFieldBoolean, models.MonitoringFieldText, models.MonitoringFieldDate:
+		default:
+			return fmt.Errorf("%w: %s has unknown type %q", ErrInvalidMonitoringFields, f.Key, f.Type)
+		}
+	}
+	return nil
+}
+
+// SaveMonitoringTemplate creates or replaces the template for a type, bumping its version
+func SaveMonitoringTemplate(monitoringType, name, description string, fields models.MonitoringFields, adminID uuid.UUID) (*models.MonitoringTemplate, error) {
+	if !monitoringKeyPattern.MatchString(monitoringType) {
+		return nil, fmt.Errorf("%w: type must be lowercase letters, digits and underscores", ErrInvalidMonitoringFields)
+	}
+	if err := ValidateMonitoringFields(fields); err != nil {
+		return nil, err
+	}
+	current, err := GetMonitoringTemplate(monitoringType)
+	if err != nil && !errors.Is(err, ErrUnknownMonitoringType) {
+		return nil, err
+	}
+
+	template := models.MonitoringTemplate{Type: monitoringType, Version: 1}
+	if current != nil {
+		template = *current
+		template.Version++
+	}
+	template.Name = name
+	template.Description = description
+	template.Fields = fields
+	template.UpdatedBy = &adminID
+	template.UpdatedAt = time.Now()
+
+	if template.ID == uuid.Nil {
+		template.ID = uuid.New()
+		template.CreatedAt = template.UpdatedAt
+		err = config.DB.Create(&template).Error
+	} else {
+		err = config.DB.Save(&template).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// DeleteMonitoringTemplate removes a custom template, or resets a built-in one to its default
+func DeleteMonitoringTemplate(monitoringType string) error {
+	result := config.DB.Where("type = ?", monitoringType).Delete(&models.MonitoringTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, ok := DefaultMonitoringTemplates[monitoringType]; ok {
+			return ErrBuiltInMonitoringType
+		}
+		return ErrUnknownMonitoringType
+	}
+	return nil
+}
+
+// ValidateMonitoringData checks field values against a template and returns them
+// normalised, or a message per offending field
+func ValidateMonitoringData(template *models.MonitoringTemplate, data map[string]interface{}) (map[string]interface{}, map[string]string) {
+	clean := map[string]interface{}{}
+	problems := map[string]string{}
+	known := map[string]bool{}
+
+	for _, f := range template.Fields {
+		known[f.Key] = true
+		raw, ok := data[f.Key]
+		if !ok || raw == nil {
+			if f.Required {
+				problems[f.Key] = "is required"
+			}
+			continue
+		}
+		value, problem := normaliseMonitoringValue(f, raw)
+		if problem != "" {
+			problems[f.Key] = problem
+			continue
+		}
+		clean[f.Key] = value
+	}
+	for key := range data {
+		if !known[key] {
+			problems[key] = "is not a field of the " + template.Type + " template"
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return clean, nil
+}
+
+func normaliseMonitoringValue(f models.MonitoringField, raw interface{}) (interface{}, string) {
+	switch f.Type {
+	case models.MonitoringFieldNumber, models.MonitoringFieldInteger:
+		n, ok := raw.(float64)
+		if !ok {
+			return nil, "must be a number"
+		}
+		if f.Type == models.MonitoringFieldInteger && n != math.Trunc(n) {
+			return nil, "must be a whole number"
+		}
+		if f.Min != nil && n < *f.Min {
+			return nil, fmt.Sprintf("must be at least %g", *f.Min)
+		}
+		if f.Max != nil && n > *f.Max {
+			return nil, fmt.Sprintf("must be at most %g", *f.Max)
+		}
+		return n, ""
+	case models.MonitoringFieldBoolean:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, "must be true or false"
+		}
+		return b, ""
+	case models.MonitoringFieldText:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, "must be text"
+		}
+		s = strings.TrimSpace(s)
+		if f.MaxLength > 0 && len(s) > f.MaxLength {
+			return nil, fmt.Sprintf("must be at most %d characters", f.MaxLength)
+		}
+		if f.Required && s == "" {
+			return nil, "is required"
+		}
+		return s, ""
+	case models.MonitoringFieldEnum:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, "must be one of " + strings.Join(f.Options, ", ")
+		}
+		for _, o := range f.Options {
+			if s == o {
+				return s, ""
+			}
+		}
+		return nil, "must be one of " + strings.Join(f.Options, ", ")
+	case models.MonitoringFieldDate:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, "must be a date (YYYY-MM-DD)"
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, "must be a date (YYYY-MM-DD)"
+		}
+		return s, ""
+	}
+	return nil, "has an unknown type"
+}
+
+// MonitoringRecordView is a monitoring record with its data decrypted
+type MonitoringRecordView struct {
+	models.MonitoringRecord
+	Data  map[string]interface{} `json:"data"`
+	Notes string                 `json:"notes,omitempty"`
+}
+
+// DecryptMonitoringRecord decrypts a record's data and notes
+func DecryptMonitoringRecord(record models.MonitoringRecord) (MonitoringRecordView, error) {
+	view := MonitoringRecordView{MonitoringRecord: record, Data: map[string]interface{}{}}
+	if record.Data != "" {
+		plain, err := utils.Decrypt(record.Data)
+		if err != nil {
+			return view, err
+		}
+		// Records saved before templates existed may hold free text
+		if plain != "" && json.Unmarshal([]byte(plain), &view.Data) != nil {
+			view.Data = map[string]interface{}{"legacy_data": plain}
+		}
+	}
+	if record.Notes != "" {
+		notes, err := utils.Decrypt(record.Notes)
+		if err != nil {
+			return view, err
+		}
+		view.Notes = notes
+	}
+	return view, nil
+}
+
+// MonitoringInput is the user-supplied content of a monitoring record
+type MonitoringInput struct {
+	Type      string
+	StartDate time.Time
+	EndDate   *time.Time
+	Data      map[string]interface{}
+	Notes     string
+}
+
+// MonitoringValidationError lists the fields that failed template validation
+type MonitoringValidationError struct {
+	Fields map[string]string
+}
+
+func (e *MonitoringValidationError) Error() string { return ErrInvalidMonitoringData.Error() }
+
+func (e *MonitoringValidationError) Unwrap() error { return ErrInvalidMonitoringData }
+
+// fillMonitoringRecord validates input and writes it, encrypted, onto a record
+func fillMonitoringRecord(userID uuid.UUID, record *models.MonitoringRecord, input MonitoringInput) error {
+	if input.EndDate != nil && input.EndDate.Before(input.StartDate) {
+		return fmt.Errorf("%w: end date is before start date", ErrInvalidMonitoringData)
+	}
+	template, err := GetMonitoringTemplate(input.Type)
+	if err != nil {
+		return err
+	}
+	clean, problems := ValidateMonitoringData(template, input.Data)
+	if problems != nil {
+		return &MonitoringValidationError{Fields: problems}
+	}
+
+	// Tie pregnancy and postpartum records to the pregnancy they describe
+	var pregnancy *models.Pregnancy
+	switch input.Type {
+	case models.MonitoringTypePregnancy:
+		pregnancy, err = PregnancyAt(userID, input.StartDate)
+	case models.MonitoringTypePostpartum:
+		pregnancy, err = LatestEndedPregnancy(userID)
+	}
+	if err != nil {
+		return err
+	}
+	record.PregnancyID = nil
+	if pregnancy != nil {
+		record.PregnancyID = &pregnancy.ID
+	}
+
+	encoded, err := json.Marshal(clean)
+	if err != nil {
+		return err
+	}
+	if record.Data, err = utils.Encrypt(string(encoded)); err != nil {
+		return err
+	}
+	if record.Notes, err = utils.Encrypt(input.Notes); err != nil {
+		return err
+	}
+	record.UserID = userID
+	record.Type = input.Type
+	record.TemplateVersion = template.Version
+	record.StartDate = input.StartDate
+	record.EndDate = input.EndDate
+	return nil
+}
+
+// CreateMonitoringRecord validates and stores a new record
+func CreateMonitoringRecord(userID uuid.UUID, input MonitoringInput) (*MonitoringRecordView, error) {
+	record := models.MonitoringRecord{ID: uuid.New()}
+	if err := fillMonitoringRecord(userID, &record, input); err != nil {
+		return nil, err
+	}
+	if err := config.DB.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	InvalidateAnalyticsCacheForUser(userID)
+	view, err := DecryptMonitoringRecord(record)
+	return &view, err
+}
+
+// UpdateMonitoringRecord revalidates and replaces one of the user's records
+func UpdateMonitoringRecord(userID, recordID uuid.UUID, input MonitoringInput) (*MonitoringRecordView, error) {
+	var record models.MonitoringRecord
+	if err := config.DB.Where("id = ? AND user_id = ?", recordID, userID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMonitoringRecordNotFound
+		}
+		return nil, err
+	}
+	if err := fillMonitoringRecord(userID, &record, input); err != nil {
+		return nil, err
+	}
+	if err := config.DB.Save(&record).Error; err != nil {
+		return nil, err
+	}
+	InvalidateAnalyticsCacheForUser(userID)
+	view, err := DecryptMonitoringRecord(record)
+	return &view, err
+}
+
+// MonitoringFilter compares one decrypted field against a value
+type MonitoringFilter struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+var monitoringFilterOps = map[string]bool{"eq": true, "ne": true, "gt": true, "gte": true, "lt": true, "lte": true}
+
+// ParseMonitoringFilter reads a filter written as key:op:value
+func ParseMonitoringFilter(raw string) (MonitoringFilter, error) {
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) != 3 || !monitoringFilterOps[parts[1]] || parts[0] == "" {
+		return MonitoringFilter{}, ErrInvalidMonitoringFilter
+	}
+	return MonitoringFilter{Key: parts[0], Op: parts[1], Value: parts[2]}, nil
+}
+
+// matches compares numbers numerically and everything else as text
+func (f MonitoringFilter) matches(data map[string]interface{}) bool {
+	raw, ok := data[f.Key]
+	if !ok {
+		return false
+	}
+	var cmp int
+	if n, isNum := raw.(float64); isNum {
+		want, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case n < want:
+			cmp = -1
+		case n > want:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(fmt.Sprint(raw), f.Value)
+	}
+	switch f.Op {
+	case "eq":
+		return cmp == 0
+	case "ne":
+		return cmp != 0
+	case "gt":
+		return cmp > 0
+	case "gte":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "lte":
+		return cmp <= 0
+	}
+	return false
+}
+
+// MonitoringQuery narrows a user's monitoring records. Data is encrypted at
+// rest, so field filters are applied after decryption.
+type MonitoringQuery struct {
+	Type    string
+	From    *time.Time
+	To      *time.Time
+	Filters []MonitoringFilter
+}
+
+// QueryMonitoringRecords returns the user's decrypted records matching a query, newest first
+func QueryMonitoringRecords(userID uuid.UUID, q MonitoringQuery) ([]MonitoringRecordView, error) {
+	db := config.DB.Where("user_id = ?", userID)
+	if q.Type != "" {
+		db = db.Where("type = ?", q.Type)
+	}
+	if q.From != nil {
+		db = db.Where("start_date >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where("start_date <= ?", *q.To)
+	}
+	var records []models.MonitoringRecord
+	if err := db.Order("start_date desc").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	views := []MonitoringRecordView{}
+	for _, r := range records {
+		view, err := DecryptMonitoringRecord(r)
+		if err != nil {
+			log.Printf("❌ Failed to decrypt monitoring record %s: %v", r.ID, err)
+			continue
+		}
+		keep := true
+		for _, f := range q.Filters {
+			keep = keep && f.matches(view.Data)
+		}
+		if keep {
+			views = append(views, view)
+		}
+	}
+	return views, nil
+}
+
+// MonitoringSeries is the trend of one numeric or yes/no monitoring field
+type MonitoringSeries struct {
+	Type   string      `json:"type"`
+	Key    string      `json:"key"`
+	Label  string      `json:"label"`
+	Unit   string      `json:"unit,omitempty"`
+	Points []TimeValue `json:"points"`
+}
+
+// TrendSourceMonitoring marks points that come from monitoring records
+const TrendSourceMonitoring = "monitoring"
+
+// BuildMonitoringSeries turns decrypted records into per-field trends. Booleans plot as 1 or 0.
+func BuildMonitoringSeries(records []MonitoringRecordView, templates map[string]*models.MonitoringTemplate) []MonitoringSeries {
+	series := map[string]*MonitoringSeries{}
+	var order []string
+	for _, r := range records {
+		template := templates[r.Type]
+		if template == nil {
+			continue
+		}
+		for _, f := range template.Fields {
+			var value float64
+			switch v := r.Data[f.Key].(type) {
+			case float64:
+				value = v
+			case bool:
+				if v {
+					value = 1
+				}
+			default:
+				continue
+			}
+			id := r.Type + "." + f.Key
+			s, ok := series[id]
+			if !ok {
+				s = &MonitoringSeries{Type: r.Type, Key: f.Key, Label: f.Label, Unit: f.Unit}
+				series[id] = s
+				order = append(order, id)
+			}
+			s.Points = append(s.Points, TimeValue{Time: r.StartDate, Value: value, Source: TrendSourceMonitoring})
+		}
+	}
+
+	sort.Strings(order)
+	result := []MonitoringSeries{}
+	for _, id := range order {
+		s := series[id]
+		sort.Slice(s.Points, func(i, j int) bool { return s.Points[i].Time.Before(s.Points[j].Time) })
+		result = append(result, *s)
+	}
+	return result
+}
+
+// monitoringTrends loads the user's records in range and builds their series for analytics
+func monitoringTrends(userID uuid.UUID, from, to *time.Time) ([]MonitoringSeries, error) {
+	records, err := QueryMonitoringRecords(userID, MonitoringQuery{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	templates := map[string]*models.MonitoringTemplate{}
+	for _, r := range records {
+		if _, ok := templates[r.Type]; ok {
+			continue
+		}
+		template, err := GetMonitoringTemplate(r.Type)
+		if err != nil && !errors.Is(err, ErrUnknownMonitoringType) {
+			return nil, err
+		}
+		templates[r.Type] = template
+	}
+	return BuildMonitoringSeries(records, templates), nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+// Notify stores a notification for a user. Used by flows that raise
+// notifications on the user's behalf rather than through the admin endpoint.
+func Notify(userID uuid.UUID, notificationType models.NotificationType, title, message, link string) error {
+	return notifyTx(config.DB, userID, notificationType, title, message, link)
+}
+
+// notifyTx stores a notification inside a caller's transaction
+func notifyTx(tx *gorm.DB, userID uuid.UUID, notificationType models.NotificationType, title, message, link string) error {
+	notification := models.Notification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      notificationType,
+		Title:     title,
+		Message:   message,
+		Link:      link,
+		CreatedAt: time.Now(),
+	}
+	return tx.Create(&notification).Error
+}
+
+// HasNotificationSince reports whether the user already received a notification
+// with the given link after the given time. Used to avoid repeating reminders.
+func HasNotificationSince(userID uuid.UUID, link string, since time.Time) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND link = ? AND created_at >= ?", userID, link, since).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+// DRSPItem is a question on the Daily Record of Severity of Problems
+type DRSPItem struct {
+	Number     int    `json:"number"`
+	Text       string `json:"text"`
+	Domain     string `json:"domain"`
+	Impairment bool   `json:"impairment,omitempty"`
+}
+
+// DRSP rating scale
+const (
+	DRSPMinRating = 1 // not at all
+	DRSPMaxRating = 6 // extreme
+)
+
+// DRSPItems lists the 21 symptom items and 3 functional impairment items in order
+var DRSPItems = []DRSPItem{
+	{1, "Felt depressed, sad, \"down\", or \"blue\"", "depressed_mood", false},
+	{2, "Felt hopeless", "depressed_mood", false},
+	{3, "Felt worthless or guilty", "depressed_mood", false},
+	{4, "Felt anxious, tense, \"keyed up\" or \"on edge\"", "anxiety", false},
+	{5, "Had mood swings (e.g. suddenly felt sad or tearful)", "affective_lability", false},
+	{6, "Was more sensitive to rejection or my feelings were easily hurt", "affective_lability", false},
+	{7, "Felt angry, irritable", "anger_irritability", false},
+	{8, "Had conflicts or problems with people", "anger_irritability", false},
+	{9, "Had less interest in usual activities (e.g. work, school, friends, hobbies)", "decreased_interest", false},
+	{10, "Had difficulty concentrating", "concentration", false},
+	{11, "Felt lethargic, tired, or fatigued; or had a lack of energy", "fatigue", false},
+	{12, "Had increased appetite or overate", "appetite", false},
+	{13, "Had cravings for specific foods", "appetite", false},
+	{14, "Slept more, took naps, found it hard to get up when intended", "sleep", false},
+	{15, "Had trouble getting to sleep or staying asleep", "sleep", false},
+	{16, "Felt overwhelmed or unable to cope", "overwhelmed", false},
+	{17, "Felt out of control", "overwhelmed", false},
+	{18, "Had breast tenderness", "physical", false},
+	{19, "Had breast swelling, felt \"bloated\", or had weight gain", "physical", false},
+	{20, "Had headache", "physical", false},
+	{21, "Had joint or muscle pain", "physical", false},
+	{22, "At work, school, home, or in daily routine, at least one of the problems above caused reduced productivity or inefficiency", "impairment", true},
+	{23, "At least one of the problems above interfered with hobbies or social activities", "impairment", true},
+	{24, "At least one of the problems above interfered with relationships with others", "impairment", true},
+}
+
+// Core mood domains; PMDD requires at least one of these
+var drspCoreDomains = map[string]bool{
+	"depressed_mood":     true,
+	"anxiety":            true,
+	"affective_lability": true,
+	"anger_irritability": true,
+}
+
+// Screening outcomes
+const (
+	ScreeningInsufficientData = "insufficient_data"
+	ScreeningNotIndicated     = "not_indicated"
+	ScreeningPMSLikely        = "pms_likely"
+	ScreeningPMDDLikely       = "pmdd_likely"
+)
+
+// Scoring thresholds
+const (
+	MinScreeningCycles     = 2
+	drspMinDaysPerWindow   = 4   // ratings needed in each comparison window
+	drspElevatedMean       = 3.0 // "mild" or worse on the 1–6 scale
+	drspMinIncrease        = 0.30
+	drspMinPMDDDomains     = 5
+	drspPremenstrualDays   = 7 // days -7..-1 before the next period
+	drspPostmenstrualFirst = 4 // cycle days 4..10
+	drspPostmenstrualLast  = 10
+)
+
+const screeningRecommendationSource = "DRSP screening"
+
+var ErrInvalidDRSPRatings = errors.New("ratings must contain 24 values between 1 and 6")
+
+// ValidateDRSPRatings checks that a day's ratings cover every item within the scale
+func ValidateDRSPRatings(ratings []int64) error {
+	if len(ratings) != len(DRSPItems) {
+		return ErrInvalidDRSPRatings
+	}
+	for _, r := range ratings {
+		if r < DRSPMinRating || r > DRSPMaxRating {
+			return ErrInvalidDRSPRatings
+		}
+	}
+	return nil
+}
+
+// CycleDayFor returns the day of the cycle a date falls on, or 0 if no earlier period is logged
+func CycleDayFor(userID uuid.UUID, date time.Time) (int, error) {
+	var cycle models.Cycle
+	err := config.DB.Where("user_id = ? AND start_date <= ?", userID, date.AddDate(0, 0, 1)).
+		Order("start_date desc").First(&cycle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	day := daysBetween(cycle.StartDate, date) + 1
+	if day < 1 {
+		return 0, nil
+	}
+	return day, nil
+}
+
+// DRSPItemComparison compares an item's premenstrual and postmenstrual means
+type DRSPItemComparison struct {
+	Number            int     `json:"number"`
+	Domain            string  `json:"domain"`
+	PremenstrualMean  float64 `json:"premenstrual_mean"`
+	PostmenstrualMean float64 `json:"postmenstrual_mean"`
+	PercentIncrease   float64 `json:"percent_increase"`
+	Elevated          bool    `json:"elevated"`
+}
+
+// DRSPCycleScore is the scoring of one complete cycle
+type DRSPCycleScore struct {
+	CycleStart         time.Time            `json:"cycle_start"`
+	NextPeriodStart    time.Time            `json:"next_period_start"`
+	PremenstrualDays   int                  `json:"premenstrual_days"`
+	PostmenstrualDays  int                  `json:"postmenstrual_days"`
+	PremenstrualTotal  float64              `json:"premenstrual_total"`  // mean daily sum of items 1–21
+	PostmenstrualTotal float64              `json:"postmenstrual_total"` // mean daily sum of items 1–21
+	PercentIncrease    float64              `json:"percent_increase"`
+	ElevatedDomains    []string             `json:"elevated_domains"`
+	CoreMoodElevated   bool                 `json:"core_mood_elevated"`
+	Impairment         bool                 `json:"impairment"`
+	Classification     string               `json:"classification"`
+	Items              []DRSPItemComparison `json:"items"`
+}
+
+// DRSPScreening is the overall PMS/PMDD screening result
+type DRSPScreening struct {
+	Result       string           `json:"result"`
+	ScoredCycles int              `json:"scored_cycles"`
+	Required     int              `json:"required_cycles"`
+	Cycles       []DRSPCycleScore `json:"cycles"`
+	Message      string           `json:"message"`
+	Disclaimer   string           `json:"disclaimer"`
+}
+
+const drspDisclaimer = "This is a screening summary based on your daily ratings, not a diagnosis. Please review it with a healthcare professional."
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// scoreDRSPCycle compares the premenstrual week with cycle days 4–10 of one cycle
+func scoreDRSPCycle(seg cycleSegment, entries []models.DRSPEntry) (DRSPCycleScore, bool) {
+	next := seg.Start.AddDate(0, 0, seg.Length)
+	score := DRSPCycleScore{CycleStart: seg.Start, NextPeriodStart: next, ElevatedDomains: []string{}}
+
+	pre := make([]float64, len(DRSPItems))
+	post := make([]float64, len(DRSPItems))
+	for _, e := range entries {
+		if len(e.Ratings) != len(DRSPItems) {
+			continue
+		}
+		day := truncateDay(e.Date)
+		cycleDay := daysBetween(seg.Start, day) + 1
+		daysBefore := daysBetween(day, next)
+		switch {
+		case daysBefore >= 1 && daysBefore <= drspPremenstrualDays:
+			score.PremenstrualDays++
+			for i, r := range e.Ratings {
+				pre[i] += float64(r)
+			}
+		case cycleDay >= drspPostmenstrualFirst && cycleDay <= drspPostmenstrualLast:
+			score.PostmenstrualDays++
+			for i, r := range e.Ratings {
+				post[i] += float64(r)
+			}
+		}
+	}
+	if score.PremenstrualDays < drspMinDaysPerWindow || score.PostmenstrualDays < drspMinDaysPerWindow {
+		return score, false
+	}
+
+	domains := map[string]bool{}
+	for i, item := range DRSPItems {
+		preMean := pre[i] / float64(score.PremenstrualDays)
+		postMean := post[i] / float64(score.PostmenstrualDays)
+		increase := (preMean - postMean) / postMean
+		elevated := preMean >= drspElevatedMean && increase >= drspMinIncrease
+
+		score.Items = append(score.Items, DRSPItemComparison{
+			Number:            item.Number,
+			Domain:            item.Domain,
+			PremenstrualMean:  round2(preMean),
+			PostmenstrualMean: round2(postMean),
+			PercentIncrease:   round2(increase * 100),
+			Elevated:          elevated,
+		})
+
+		if item.Impairment {
+			if elevated {
+				score.Impairment = true
+			}
+			continue
+		}
+		score.PremenstrualTotal += preMean
+		score.PostmenstrualTotal += postMean
+		if elevated && !domains[item.Domain] {
+			domains[item.Domain] = true
+			score.ElevatedDomains = append(score.ElevatedDomains, item.Domain)
+			if drspCoreDomains[item.Domain] {
+				score.CoreMoodElevated = true
+			}
+		}
+	}
+
+	totalIncrease := (score.PremenstrualTotal - score.PostmenstrualTotal) / score.PostmenstrualTotal
+	score.PercentIncrease = round2(totalIncrease * 100)
+	score.PremenstrualTotal = round2(score.PremenstrualTotal)
+	score.PostmenstrualTotal = round2(score.PostmenstrualTotal)
+
+	switch {
+	case totalIncrease >= drspMinIncrease && len(score.ElevatedDomains) >= drspMinPMDDDomains &&
+		score.CoreMoodElevated && score.Impairment:
+		score.Classification = ScreeningPMDDLikely
+	case totalIncrease >= drspMinIncrease && len(score.ElevatedDomains) > 0:
+		score.Classification = ScreeningPMSLikely
+	default:
+		score.Classification = ScreeningNotIndicated
+	}
+	return score, true
+}
+
+// ScoreDRSP scores every complete cycle that has enough ratings and combines them
+// into a screening result. A positive result needs the pattern in at least two cycles.
+func ScoreDRSP(cycles []models.Cycle, entries []models.DRSPEntry) DRSPScreening {
+	screening := DRSPScreening{
+		Result:     ScreeningInsufficientData,
+		Required:   MinScreeningCycles,
+		Cycles:     []DRSPCycleScore{},
+		Disclaimer: drspDisclaimer,
+	}
+
+	if len(cycles) >= 2 {
+		segments := buildCycleSegments(cycles, truncateDay(cycles[len(cycles)-1].StartDate), false)
+		for _, seg := range segments[:len(segments)-1] {
+			if score, ok := scoreDRSPCycle(seg, entries); ok {
+				screening.Cycles = append(screening.Cycles, score)
+			}
+		}
+	}
+	screening.ScoredCycles = len(screening.Cycles)
+
+	if screening.ScoredCycles < MinScreeningCycles {
+		screening.Message = "Rate your symptoms every day for at least two full cycles to get a screening result."
+		return screening
+	}
+
+	pmdd, pms := 0, 0
+	for _, s := range screening.Cycles {
+		switch s.Classification {
+		case ScreeningPMDDLikely:
+			pmdd++
+			pms++
+		case ScreeningPMSLikely:
+			pms++
+		}
+	}
+
+	switch {
+	case pmdd >= MinScreeningCycles:
+		screening.Result = ScreeningPMDDLikely
+		screening.Message = "Your ratings show a premenstrual pattern consistent with PMDD in at least two cycles."
+	case pms >= MinScreeningCycles:
+		screening.Result = ScreeningPMSLikely
+		screening.Message = "Your ratings show a premenstrual pattern consistent with PMS in at least two cycles."
+	default:
+		screening.Result = ScreeningNotIndicated
+		screening.Message = "Your ratings do not show a consistent premenstrual pattern."
+	}
+	return screening
+}
+
+// BuildDRSPScreening loads the user's cycles and ratings and scores them.
+// Only cycles since the last pregnancy are used.
+func BuildDRSPScreening(userID uuid.UUID) (*DRSPScreening, error) {
+	cycles, _, err := BaselineCycles(userID)
+	if err != nil {
+		return nil, err
+	}
+	var entries []models.DRSPEntry
+	if err := config.DB.Where("user_id = ?", userID).Order("date asc").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	screening := ScoreDRSP(cycles, entries)
+	return &screening, nil
+}
+
+// SyncScreeningRecommendation keeps a single active recommendation reflecting the
+// latest positive screening result, and retires it when the result is negative.
+func SyncScreeningRecommendation(userID uuid.UUID, screening *DRSPScreening) error {
+	if screening.Result == ScreeningInsufficientData {
+		return nil
+	}
+
+	var advice string
+	priority := 3
+	switch screening.Result {
+	case ScreeningPMDDLikely:
+		advice = "Your daily symptom ratings suggest premenstrual dysphoric disorder (PMDD). Bring your printable summary to a doctor; effective treatments are available."
+		priority = 5
+	case ScreeningPMSLikely:
+		advice = "Your daily symptom ratings suggest premenstrual syndrome (PMS). Regular exercise, sleep and reduced caffeine may help; talk to a doctor if symptoms affect your daily life."
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Recommendation
+		err := tx.Where("user_id = ? AND source = ? AND active = ?", userID, screeningRecommendationSource, true).
+			First(&current).Error
+		if err == nil && current.Advice == advice {
+			return nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Recommendation{}).
+			Where("user_id = ? AND source = ? AND active = ?", userID, screeningRecommendationSource, true).
+			Updates(map[string]interface{}{"active": false, "valid_until": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if advice == "" {
+			return nil
+		}
+
+		return tx.Create(&models.Recommendation{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Category:  "Mental Health",
+			Advice:    advice,
+			Source:    screeningRecommendationSource,
+			Priority:  priority,
+			Active:    true,
+			ValidFrom: now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).Error
+	})
+}
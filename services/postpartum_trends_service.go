@@ -0,0 +1,336 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+)
+
+// WeeklyAverage is the mean of a metric over one week postpartum
+type WeeklyAverage struct {
+	Week    int     `json:"week"`
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// MoodWeek counts the moods logged in one week postpartum
+type MoodWeek struct {
+	Week     int            `json:"week"`
+	Counts   map[string]int `json:"counts"`
+	Dominant string         `json:"dominant"`
+	LowShare float64        `json:"low_share"` // fraction of entries with a low mood
+}
+
+// BreastfeedingStreaks summarises consecutive days of breastfeeding
+type BreastfeedingStreaks struct {
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	DaysLogged    int `json:"days_logged"`
+	DaysBreastfed int `json:"days_breastfed"`
+}
+
+// TrendWarning flags a recovery pattern worth raising with a care provider
+type TrendWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PostpartumTrends are recovery metrics computed from postpartum logs
+type PostpartumTrends struct {
+	DeliveryDate       *time.Time            `json:"delivery_date,omitempty"`
+	CurrentWeek        int                   `json:"current_week"`
+	PainTrend          []TimeValue           `json:"pain_trend"`
+	PainByWeek         []WeeklyAverage       `json:"pain_by_week"`
+	SleepTrend         []TimeValue           `json:"sleep_trend"`
+	SleepMovingAverage []TimeValue           `json:"sleep_moving_average"` // trailing 7 days
+	MoodByWeek         []MoodWeek            `json:"mood_by_week"`
+	Breastfeeding      *BreastfeedingStreaks `json:"breastfeeding,omitempty"`
+	Warnings           []TrendWarning        `json:"warnings"`
+}
+
+// Worsening pattern codes
+const (
+	TrendPainRising       = "pain_rising"
+	TrendSleepDeclining   = "sleep_declining"
+	TrendLowMoodPersisted = "low_mood_persisting"
+)
+
+// Trend tuning
+const (
+	sleepWindowDays     = 7
+	painRiseAfterWeek   = 2   // some pain in the first two weeks is expected
+	painRiseMin         = 2.0 // points above the lowest weekly average so far
+	lowSleepHours       = 5.0
+	sleepDropHours      = 1.0
+	lowMoodShare        = 0.5
+	babyBluesLastWeek   = 2 // low mood past this week is no longer typical baby blues
+	lowMoodWeeksInARow  = 2
+	moodUnspecifiedName = "unspecified"
+)
+
+// lowMoodWords mark a free-text mood as low when one of them appears as a whole word
+var lowMoodWords = map[string]bool{
+	"sad": true, "down": true, "low": true, "depressed": true, "depression": true, "anxious": true,
+	"anxiety": true, "worried": true, "worry": true, "overwhelmed": true, "cry": true, "crying": true,
+	"cried": true, "tearful": true, "hopeless": true, "irritable": true, "angry": true, "numb": true,
+	"exhausted": true,
+}
+
+func isLowMood(mood string) bool {
+	words := strings.FieldsFunc(strings.ToLower(mood), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, w := range words {
+		if lowMoodWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// postpartumWeek numbers weeks from delivery, starting at 1
+func postpartumWeek(delivery, date time.Time) int {
+	days := daysBetween(delivery, date)
+	if days < 0 {
+		return 1
+	}
+	return days/7 + 1
+}
+
+// lastDeliveryBefore returns the end date of the user's latest pregnancy that ended by a date
+func lastDeliveryBefore(userID uuid.UUID, before time.Time) (*time.Time, error) {
+	var pregnancies []models.Pregnancy
+	if err := config.DB.Where("user_id = ? AND end_date IS NOT NULL AND end_date <= ?", userID, before).
+		Order("end_date desc").Limit(1).Find(&pregnancies).Error; err != nil {
+		return nil, err
+	}
+	if len(pregnancies) == 0 {
+		return nil, nil
+	}
+	return pregnancies[0].EndDate, nil
+}
+
+// GetPostpartumTrends loads the user's postpartum logs in an optional range and computes trends
+func GetPostpartumTrends(userID uuid.UUID, from, to *time.Time) (*PostpartumTrends, error) {
+	query := config.DB.Where("user_id = ?", userID)
+	if from != nil {
+		query = query.Where("date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("date <= ?", *to)
+	}
+	var logs []models.PostpartumLog
+	if err := query.Order("date asc").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, nil
+	}
+
+	delivery, err := lastDeliveryBefore(userID, logs[len(logs)-1].Date)
+	if err != nil {
+		return nil, err
+	}
+	sensitive, err := InPregnancyLossRecovery(userID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildPostpartumTrends(logs, delivery, !sensitive), nil
+}
+
+// BuildPostpartumTrends computes recovery trends from logs sorted by date. Weeks
+// count from the delivery date, or from the first log when it is unknown.
+func BuildPostpartumTrends(logs []models.PostpartumLog, delivery *time.Time, includeBreastfeeding bool) *PostpartumTrends {
+	trends := &PostpartumTrends{DeliveryDate: delivery}
+	start := truncateDay(logs[0].Date)
+	if delivery != nil {
+		start = truncateDay(*delivery)
+	}
+	trends.CurrentWeek = postpartumWeek(start, logs[len(logs)-1].Date)
+
+	type dayTotals struct {
+		sleep      float64
+		sleepCount int
+		breastfed  bool
+	}
+	days := map[time.Time]*dayTotals{}
+	painWeeks := map[int]*WeeklyAverage{}
+	moodWeeks := map[int]*MoodWeek{}
+	moodTotals := map[int]int{}
+	moodLow := map[int]int{}
+
+	for _, l := range logs {
+		day := truncateDay(l.Date)
+		week := postpartumWeek(start, l.Date)
+
+		trends.PainTrend = append(trends.PainTrend, TimeValue{Time: l.Date, Value: float64(l.PainLevel)})
+		pw, ok := painWeeks[week]
+		if !ok {
+			pw = &WeeklyAverage{Week: week}
+			painWeeks[week] = pw
+		}
+		pw.Average += float64(l.PainLevel)
+		pw.Count++
+
+		d, ok := days[day]
+		if !ok {
+			d = &dayTotals{}
+			days[day] = d
+		}
+		if l.SleepHours > 0 {
+			d.sleep += l.SleepHours
+			d.sleepCount++
+		}
+		d.breastfed = d.breastfed || l.Breastfeeding
+
+		mood := strings.ToLower(strings.TrimSpace(l.Mood))
+		if mood == "" {
+			mood = moodUnspecifiedName
+		}
+		mw, ok := moodWeeks[week]
+		if !ok {
+			mw = &MoodWeek{Week: week, Counts: map[string]int{}}
+			moodWeeks[week] = mw
+		}
+		mw.Counts[mood]++
+		moodTotals[week]++
+		if isLowMood(mood) {
+			moodLow[week]++
+		}
+	}
+
+	for _, pw := range painWeeks {
+		pw.Average = round1(pw.Average / float64(pw.Count))
+		trends.PainByWeek = append(trends.PainByWeek, *pw)
+	}
+	sort.Slice(trends.PainByWeek, func(i, j int) bool { return trends.PainByWeek[i].Week < trends.PainByWeek[j].Week })
+
+	for week, mw := range moodWeeks {
+		best := 0
+		for mood, n := range mw.Counts {
+			if n > best || (n == best && mood < mw.Dominant) {
+				mw.Dominant, best = mood, n
+			}
+		}
+		mw.LowShare = round2(float64(moodLow[week]) / float64(moodTotals[week]))
+		trends.MoodByWeek = append(trends.MoodByWeek, *mw)
+	}
+	sort.Slice(trends.MoodByWeek, func(i, j int) bool { return trends.MoodByWeek[i].Week < trends.MoodByWeek[j].Week })
+
+	var dates []time.Time
+	for day := range days {
+		dates = append(dates, day)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	for _, day := range dates {
+		d := days[day]
+		if d.sleepCount > 0 {
+			trends.SleepTrend = append(trends.SleepTrend, TimeValue{Time: day, Value: round1(d.sleep / float64(d.sleepCount))})
+		}
+	}
+	trends.SleepMovingAverage = sleepMovingAverage(trends.SleepTrend)
+
+	if includeBreastfeeding {
+		trends.Breastfeeding = breastfeedingStreaks(dates, func(day time.Time) bool { return days[day].breastfed })
+	}
+
+	trends.Warnings = detectWorseningPatterns(trends)
+	return trends
+}
+
+// sleepMovingAverage averages each day's sleep with the logged days in the trailing week
+func sleepMovingAverage(daily []TimeValue) []TimeValue {
+	var averages []TimeValue
+	for i, point := range daily {
+		windowStart := point.Time.AddDate(0, 0, -(sleepWindowDays - 1))
+		var sum float64
+		var n int
+		for j := i; j >= 0 && !daily[j].Time.Before(windowStart); j-- {
+			sum += daily[j].Value
+			n++
+		}
+		averages = append(averages, TimeValue{Time: point.Time, Value: round1(sum / float64(n))})
+	}
+	return averages
+}
+
+// breastfeedingStreaks counts consecutive calendar days with breastfeeding logged.
+// A day without any log breaks the streak.
+func breastfeedingStreaks(dates []time.Time, breastfed func(time.Time) bool) *BreastfeedingStreaks {
+	streaks := &BreastfeedingStreaks{DaysLogged: len(dates)}
+	run := 0
+	var previous time.Time
+	for _, day := range dates {
+		if !breastfed(day) {
+			run = 0
+			previous = day
+			continue
+		}
+		streaks.DaysBreastfed++
+		if run > 0 && daysBetween(previous, day) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > streaks.LongestStreak {
+			streaks.LongestStreak = run
+		}
+		previous = day
+	}
+	if len(dates) > 0 && daysBetween(dates[len(dates)-1], time.Now()) <= 1 {
+		streaks.CurrentStreak = run
+	}
+	return streaks
+}
+
+// detectWorseningPatterns looks for recovery going the wrong way
+func detectWorseningPatterns(t *PostpartumTrends) []TrendWarning {
+	warnings := []TrendWarning{}
+
+	// Pain should ease after the first couple of weeks
+	if n := len(t.PainByWeek); n >= 2 {
+		latest := t.PainByWeek[n-1]
+		if latest.Week > painRiseAfterWeek {
+			lowest := t.PainByWeek[0].Average
+			for _, w := range t.PainByWeek[:n-1] {
+				if w.Average < lowest {
+					lowest = w.Average
+				}
+			}
+			if latest.Average-lowest >= painRiseMin {
+				warnings = append(warnings, TrendWarning{TrendPainRising,
+					fmt.Sprintf("Your pain has risen to an average of %.1f in week %d after being as low as %.1f. Increasing pain after the first two weeks should be checked by your care provider.",
+						latest.Average, latest.Week, lowest)})
+			}
+		}
+	}
+
+	// Sleep dropping to a low level
+	if n := len(t.SleepMovingAverage); n > sleepWindowDays {
+		latest := t.SleepMovingAverage[n-1]
+		earlier := t.SleepMovingAverage[n-1-sleepWindowDays]
+		if latest.Value < lowSleepHours && earlier.Value-latest.Value >= sleepDropHours {
+			warnings = append(warnings, TrendWarning{TrendSleepDeclining,
+				fmt.Sprintf("Your average sleep has dropped from %.1f to %.1f hours a night. Ask your support network or care provider for help getting rest.",
+					earlier.Value, latest.Value)})
+		}
+	}
+
+	// Low mood lasting beyond the baby-blues window
+	if n := len(t.MoodByWeek); n >= lowMoodWeeksInARow {
+		recent := t.MoodByWeek[n-lowMoodWeeksInARow:]
+		persisting := recent[0].Week > babyBluesLastWeek && recent[len(recent)-1].Week-recent[0].Week == lowMoodWeeksInARow-1
+		for _, w := range recent {
+			persisting = persisting && w.LowShare >= lowMoodShare
+		}
+		if persisting {
+			warnings = append(warnings, TrendWarning{TrendLowMoodPersisted,
+				"You have logged low moods for most of the last two weeks. This can be a sign of postpartum depression; consider taking the EPDS screening and talking to your care provider."})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,22 @@
+package services
+
+import "testing"
+
+func TestIsLowMood(t *testing.T) {
+	cases := map[string]bool{
+		"sad":                true,
+		"Feeling down today": true,
+		"anxious, tearful":   true,
+		"crying a lot":       true,
+		"glowing":            false,
+		"mellow":             false,
+		"slow morning":       false,
+		"happy":              false,
+		moodUnspecifiedName:  false,
+	}
+	for mood, want := range cases {
+		if got := isLowMood(mood); got != want {
+			t.Errorf("isLowMood(%q) = %v, want %v", mood, got, want)
+		}
+	}
+}
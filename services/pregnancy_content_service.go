@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoActivePregnancy = errors.New("no active pregnancy")
+
+// GetActivePregnancy returns the user's active pregnancy with its gestational age
+func GetActivePregnancy(userID uuid.UUID) (*models.Pregnancy, error) {
+	var pregnancy models.Pregnancy
+	err := config.DB.Where("user_id = ? AND status = ?", userID, models.PregnancyStatusActive).
+		Order("start_date desc").First(&pregnancy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoActivePregnancy
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pregnancy, nil
+}
+
+// GetPublishedWeekContent returns the published content for a gestational week, or nil if none exists
+func GetPublishedWeekContent(week int) (*models.PregnancyWeekContent, error) {
+	var content models.PregnancyWeekContent
+	err := config.DB.Where("week = ? AND published = ?", week, true).First(&content).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// WeekContentRecommendations turns a week's content into recommendations for
+// GetRecommendations. They are built on read and not stored.
+func WeekContentRecommendations(userID uuid.UUID, content *models.PregnancyWeekContent, now time.Time) []models.Recommendation {
+	source := fmt.Sprintf("Pregnancy guide, week %d", content.Week)
+	var recs []models.Recommendation
+	add := func(category, advice string, priority int) {
+		if strings.TrimSpace(advice) == "" {
+			return
+		}
+		recs = append(recs, models.Recommendation{
+			UserID:    userID,
+			Category:  category,
+			Advice:    advice,
+			Source:    source,
+			Priority:  priority,
+			Active:    true,
+			ValidFrom: now,
+			CreatedAt: content.UpdatedAt,
+			UpdatedAt: content.UpdatedAt,
+		})
+	}
+
+	if tests := strings.TrimSpace(content.RecommendedTests); tests != "" {
+		add("Prenatal Care", "Tests to discuss with your provider this week: "+tests+".", 5)
+	}
+	add("What to Expect", content.WhatToExpect, 4)
+	add("Tips", content.Tips, 3)
+	add("Baby Development", content.BabyDevelopment, 2)
+	return recs
+}
+
+// DeliverWeeklyPregnancyContent sends each active pregnancy the content for the
+// week it has just reached. Each week is delivered at most once per pregnancy;
+// weeks missed while no content was published are not backfilled. A failure
+// for one pregnancy is logged and the rest still get their content.
+func DeliverWeeklyPregnancyContent(now time.Time) error {
+	var pregnancies []models.Pregnancy
+	if err := config.DB.Where("status = ?", models.PregnancyStatusActive).Find(&pregnancies).Error; err != nil {
+		return err
+	}
+
+	for _, p := range pregnancies {
+		p.ComputeGestationalAge(now)
+		if p.CurrentWeek < models.MinContentWeek || p.CurrentWeek > models.MaxContentWeek {
+			continue
+		}
+		if err := deliverWeekContent(p, now); err != nil {
+			log.Printf("❌ Failed to deliver week %d content for pregnancy %s: %v", p.CurrentWeek, p.ID, err)
+		}
+	}
+	return nil
+}
+
+// deliverWeekContent records the week's delivery and notifies the user in one
+// transaction, so the notice is neither lost nor sent twice
+func deliverWeekContent(p models.Pregnancy, now time.Time) error {
+	content, err := GetPublishedWeekContent(p.CurrentWeek)
+	if err != nil || content == nil {
+		return err
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		delivery := models.PregnancyContentDelivery{
+			ID:          uuid.New(),
+			PregnancyID: p.ID,
+			Week:        p.CurrentWeek,
+			UserID:      p.UserID,
+			ContentID:   content.ID,
+			DeliveredAt: now,
+		}
+		// The unique index on (pregnancy_id, week) makes this a no-op for delivered weeks
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return notifyTx(tx, p.UserID, models.NotificationTypeSystem,
+			fmt.Sprintf("Week %d: %s", content.Week, content.Title),
+			firstSentence(content.BabyDevelopment, content.WhatToExpect),
+			fmt.Sprintf("/pregnancy-content/week/%d", content.Week))
+	})
+}
+
+// firstSentence returns the first sentence of the first non-empty text
+func firstSentence(texts ...string) string {
+	for _, t := range texts {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if i := strings.IndexAny(t, ".!?"); i >= 0 {
+			return t[:i+1]
+		}
+		return t
+	}
+	return "Your weekly pregnancy update is ready."
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPregnancyOutcome = errors.New("invalid pregnancy outcome")
+	ErrInvalidDeliveryMode     = errors.New("invalid delivery mode")
+	ErrInvalidOutcomeDate      = errors.New("outcome date must be between the start of the pregnancy and today")
+)
+
+// pregnancyTransitions lists the statuses each status may move to
+var pregnancyTransitions = map[string][]string{
+	models.PregnancyStatusActive: {
+		models.PregnancyStatusDelivered,
+		models.PregnancyStatusMiscarried,
+		models.PregnancyStatusEctopic,
+		models.PregnancyStatusTerminated,
+		models.PregnancyStatusStillbirth,
+	},
+}
+
+var validDeliveryModes = map[string]bool{
+	models.DeliveryModeVaginal:         true,
+	models.DeliveryModeAssistedVaginal: true,
+	models.DeliveryModeCesarean:        true,
+}
+
+// How long after a loss content stays in sensitive mode if the period has not returned
+const lossSensitiveDays = 365
+
+// PregnancyOutcome describes how a pregnancy ended
+type PregnancyOutcome struct {
+	Status       string    `json:"status" binding:"required"`
+	Date         time.Time `json:"date"`          // defaults to now
+	DeliveryMode string    `json:"delivery_mode"` // required for deliveries
+	Notes        string    `json:"notes"`
+}
+
+// CanTransitionPregnancy reports whether a pregnancy may move from one status to another
+func CanTransitionPregnancy(from, to string) bool {
+	for _, s := range pregnancyTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPregnancyOutcome moves an active pregnancy to its outcome, records the
+// gestational age at that point and starts postpartum tracking. Losses get a
+// gentle notification instead of baby-related follow-up.
+func RecordPregnancyOutcome(userID, pregnancyID uuid.UUID, outcome PregnancyOutcome) (*models.Pregnancy, error) {
+	if !CanTransitionPregnancy(models.PregnancyStatusActive, outcome.Status) {
+		return nil, ErrInvalidPregnancyOutcome
+	}
+	if outcome.DeliveryMode != "" && !validDeliveryModes[outcome.DeliveryMode] {
+		return nil, ErrInvalidDeliveryMode
+	}
+	if outcome.Status == models.PregnancyStatusDelivered && outcome.DeliveryMode == "" {
+		return nil, ErrInvalidDeliveryMode
+	}
+
+	now := time.Now()
+	var pregnancy models.Pregnancy
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the pregnancy so two outcomes for it cannot both be recorded
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", pregnancyID, userID).First(&pregnancy).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPregnancyNotFound
+			}
+			return err
+		}
+		if !CanTransitionPregnancy(pregnancy.Status, outcome.Status) {
+			return ErrPregnancyNotActive
+		}
+
+		date := outcome.Date
+		if date.IsZero() {
+			date = now
+		}
+		if date.Before(pregnancy.StartDate) || date.After(now) {
+			return ErrInvalidOutcomeDate
+		}
+
+		pregnancy.Status = outcome.Status
+		pregnancy.EndDate = &date
+		pregnancy.ComputeGestationalAge(now)
+		pregnancy.GestationalWeeksAtOutcome = pregnancy.CurrentWeek
+		pregnancy.GestationalDaysAtOutcome = pregnancy.GestationalDays
+		pregnancy.DeliveryMode = outcome.DeliveryMode
+		pregnancy.OutcomeNotes = outcome.Notes
+		pregnancy.UpdatedAt = now
+
+		if err := tx.Save(&pregnancy).Error; err != nil {
+			return err
+		}
+		return setTrackingMode(tx, userID, models.TrackingModePostpartum)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	title, message := "Congratulations!",
+		"Postpartum tracking has started. Log how you are feeling and we will help you plan your checkups."
+	if models.IsPregnancyLoss(outcome.Status) {
+		title, message = "We're here for you",
+			"We're so sorry for your loss. Take the time you need. Recovery tracking is available whenever you're ready, and your cycle predictions will resume once your period returns."
+	}
+	if err := Notify(userID, models.NotificationTypeSystem, title, message, "/pregnancy/"+pregnancy.ID.String()); err != nil {
+		log.Printf("❌ Failed to send pregnancy outcome notification to %s: %v", userID, err)
+	}
+
+	return &pregnancy, nil
+}
+
+// InPregnancyLossRecovery reports whether the user's most recent pregnancy ended
+// in a loss and they have not returned to cycle tracking, so baby-related
+// content should be suppressed.
+func InPregnancyLossRecovery(userID uuid.UUID) (bool, error) {
+	mode, err := GetTrackingMode(userID)
+	if err != nil || mode == models.TrackingModePregnancy {
+		return false, err
+	}
+
+	var pregnancy models.Pregnancy
+	err = config.DB.Where("user_id = ? AND end_date IS NOT NULL", userID).
+		Order("end_date desc").First(&pregnancy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !models.IsPregnancyLoss(pregnancy.Status) {
+		return false, nil
+	}
+
+	return mode == models.TrackingModePostpartum ||
+		time.Since(*pregnancy.EndDate) < lossSensitiveDays*24*time.Hour, nil
+}
+
+// lossSensitiveCategories are recommendation categories about pregnancy or a
+// baby, which are left out during pregnancy loss recovery
+var lossSensitiveCategories = map[string]bool{
+	"prenatal care":    true,
+	"what to expect":   true,
+	"baby":             true,
+	"breastfeeding":    true,
+	"pregnancy":        true,
+	"baby development": true,
+}
+
+// WithoutBabyContent drops pregnancy and baby recommendations for a user in
+// pregnancy loss recovery
+func WithoutBabyContent(recs []models.Recommendation) []models.Recommendation {
+	kept := make([]models.Recommendation, 0, len(recs))
+	for _, r := range recs {
+		if lossSensitiveCategories[strings.ToLower(strings.TrimSpace(r.Category))] {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestWithoutBabyContent(t *testing.T) {
+	recs := []models.Recommendation{
+		{Category: "Prenatal Care", Advice: "Book your anatomy scan."},
+		{Category: "Mental Health", Advice: "Reach out for support."},
+		{Category: "Baby Development", Advice: "Your baby can hear you now."},
+		{Category: "breastfeeding", Advice: "Feed on demand."},
+		{Category: "Diet", Advice: "Eat plenty of iron."},
+	}
+
+	kept := WithoutBabyContent(recs)
+	if len(kept) != 2 {
+		t.Fatalf("kept %d recommendations, want 2: %+v", len(kept), kept)
+	}
+	for _, r := range kept {
+		if r.Category != "Mental Health" && r.Category != "Diet" {
+			t.Errorf("kept %q recommendation", r.Category)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+// Days from conception (or fertilisation) to the due date
+const conceptionToDueDays = 266
+
+var ErrInvalidDating = errors.New("invalid pregnancy dating")
+
+// DatingInput describes how a due date should be calculated
+type DatingInput struct {
+	Method string    `json:"method" binding:"required"` // "lmp", "conception", "ivf", "ultrasound"
+	Date   time.Time `json:"date" binding:"required"`   // LMP, conception, transfer or scan date
+	// IVF: embryo age at transfer in days (3 or 5)
+	EmbryoAgeDays int `json:"embryo_age_days"`
+	// Ultrasound: gestational age measured at the scan
+	GestationalWeeks int `json:"gestational_weeks"`
+	GestationalDays  int `json:"gestational_days"`
+}
+
+// ageDays returns the method-specific age stored alongside the dating date
+func (d DatingInput) ageDays() int {
+	switch d.Method {
+	case models.DatingMethodIVF:
+		return d.EmbryoAgeDays
+	case models.DatingMethodUltrasound:
+		return d.GestationalWeeks*7 + d.GestationalDays
+	}
+	return 0
+}
+
+// CalculateDueDate returns the estimated due date for the given dating method
+func CalculateDueDate(input DatingInput) (time.Time, error) {
+	date := truncateDay(input.Date)
+	switch input.Method {
+	case models.DatingMethodLMP:
+		// Naegele's rule (LMP + 280 days), counted in days so month ends do not shift it
+		return date.AddDate(0, 0, models.PregnancyLengthDays), nil
+	case models.DatingMethodConception:
+		return date.AddDate(0, 0, conceptionToDueDays), nil
+	case models.DatingMethodIVF:
+		if input.EmbryoAgeDays != 3 && input.EmbryoAgeDays != 5 {
+			return time.Time{}, ErrInvalidDating
+		}
+		return date.AddDate(0, 0, conceptionToDueDays-input.EmbryoAgeDays), nil
+	case models.DatingMethodUltrasound:
+		age := input.ageDays()
+		if input.GestationalDays < 0 || input.GestationalDays > 6 || age <= 0 || age >= models.PregnancyLengthDays {
+			return time.Time{}, ErrInvalidDating
+		}
+		return date.AddDate(0, 0, models.PregnancyLengthDays-age), nil
+	}
+	return time.Time{}, ErrInvalidDating
+}
+
+// applyDating sets the due date and LMP-equivalent start date on a pregnancy
+func applyDating(pregnancy *models.Pregnancy, input DatingInput) error {
+	if input.Date.After(time.Now()) {
+		return ErrInvalidDating
+	}
+	due, err := CalculateDueDate(input)
+	if err != nil {
+		return err
+	}
+
+	date := truncateDay(input.Date)
+	pregnancy.DueDate = due
+	pregnancy.StartDate = due.AddDate(0, 0, -models.PregnancyLengthDays)
+	if input.Method == models.DatingMethodLMP {
+		pregnancy.StartDate = date
+	}
+	pregnancy.DatingMethod = input.Method
+	pregnancy.DatingDate = &date
+	pregnancy.DatingAgeDays = input.ageDays()
+	pregnancy.ComputeGestationalAge(time.Now())
+	return nil
+}
+
+// recordDueDate appends the pregnancy's current due date to its history
+func recordDueDate(tx *gorm.DB, pregnancy *models.Pregnancy, previous *time.Time, reason string) error {
+	return tx.Create(&models.DueDateHistory{
+		ID:              uuid.New(),
+		PregnancyID:     pregnancy.ID,
+		UserID:          pregnancy.UserID,
+		PreviousDueDate: previous,
+		DueDate:         pregnancy.DueDate,
+		DatingMethod:    pregnancy.DatingMethod,
+		DatingDate:      pregnancy.DatingDate,
+		DatingAgeDays:   pregnancy.DatingAgeDays,
+		Reason:          reason,
+		CreatedAt:       time.Now(),
+	}).Error
+}
+
+// CreateDatedPregnancy creates a pregnancy dated with the given method and
+// records its initial due date.
+func CreateDatedPregnancy(userID uuid.UUID, input DatingInput, notes string) (*models.Pregnancy, error) {
+	now := time.Now()
+	pregnancy := models.Pregnancy{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.PregnancyStatusActive,
+		Notes:     notes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyDating(&pregnancy, input); err != nil {
+		return nil, err
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureNoActivePregnancy(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Create(&pregnancy).Error; err != nil {
+			return err
+		}
+		if err := recordDueDate(tx, &pregnancy, nil, "initial dating"); err != nil {
+			return err
+		}
+		return setTrackingMode(tx, userID, models.TrackingModePregnancy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pregnancy, nil
+}
+
+// UpdatePregnancyDating re-dates an active pregnancy (e.g. after a dating scan)
+// and keeps the previous due date in the history.
+func UpdatePregnancyDating(userID, pregnancyID uuid.UUID, input DatingInput, reason string) (*models.Pregnancy, error) {
+	var pregnancy models.Pregnancy
+	if err := config.DB.Where("id = ? AND user_id = ?", pregnancyID, userID).First(&pregnancy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPregnancyNotFound
+		}
+		return nil, err
+	}
+	if pregnancy.Status != models.PregnancyStatusActive {
+		return nil, ErrPregnancyNotActive
+	}
+
+	var previous *time.Time
+	if !pregnancy.DueDate.IsZero() {
+		prev := pregnancy.DueDate
+		previous = &prev
+	}
+	if err := applyDating(&pregnancy, input); err != nil {
+		return nil, err
+	}
+	pregnancy.UpdatedAt = time.Now()
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&pregnancy).Error; err != nil {
+			return err
+		}
+		return recordDueDate(tx, &pregnancy, previous, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pregnancy, nil
+}
+
+// GetDueDateHistory lists a pregnancy's due date changes, oldest first
+func GetDueDateHistory(userID, pregnancyID uuid.UUID) ([]models.DueDateHistory, error) {
+	var count int64
+	if err := config.DB.Model(&models.Pregnancy{}).
+		Where("id = ? AND user_id = ?", pregnancyID, userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrPregnancyNotFound
+	}
+
+	var history []models.DueDateHistory
+	if err := config.DB.Where("pregnancy_id = ?", pregnancyID).Order("created_at asc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// PregnancyMilestone is a notable point on the pregnancy timeline
+type PregnancyMilestone struct {
+	Name    string    `json:"name"`
+	Week    int       `json:"week"`
+	Date    time.Time `json:"date"`
+	Reached bool      `json:"reached"`
+}
+
+// PregnancyTimeline is the gestational age and key dates of a pregnancy
+type PregnancyTimeline struct {
+	Pregnancy  *models.Pregnancy    `json:"pregnancy"`
+	Milestones []PregnancyMilestone `json:"milestones"`
+}
+
+// BuildPregnancyTimeline returns the milestones of a pregnancy relative to its start date
+func BuildPregnancyTimeline(pregnancy *models.Pregnancy, now time.Time) PregnancyTimeline {
+	milestones := []struct {
+		name string
+		week int
+	}{
+		{"Second trimester", 14},
+		{"Anatomy scan window", 18},
+		{"Viability", 24},
+		{"Third trimester", 28},
+		{"Full term", 37},
+		{"Due date", 40},
+	}
+
+	timeline := PregnancyTimeline{Pregnancy: pregnancy}
+	for _, m := range milestones {
+		date := pregnancy.StartDate.AddDate(0, 0, m.week*7)
+		if m.week == 40 {
+			date = pregnancy.DueDate
+		}
+		timeline.Milestones = append(timeline.Milestones, PregnancyMilestone{
+			Name:    m.name,
+			Week:    m.week,
+			Date:    date,
+			Reached: !date.After(now),
+		})
+	}
+	return timeline
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalculateDueDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input DatingInput
+		want  time.Time
+	}{
+		{"lmp", DatingInput{Method: models.DatingMethodLMP, Date: date(2026, 1, 10)}, date(2026, 10, 17)},
+		// Month-end LMPs must not be shifted by month normalisation
+		{"lmp month end", DatingInput{Method: models.DatingMethodLMP, Date: date(2026, 5, 31)}, date(2027, 3, 7)},
+		{"lmp leap year", DatingInput{Method: models.DatingMethodLMP, Date: date(2027, 11, 30)}, date(2028, 9, 5)},
+		{"conception", DatingInput{Method: models.DatingMethodConception, Date: date(2026, 1, 24)}, date(2026, 10, 17)},
+		{"ivf day 5", DatingInput{Method: models.DatingMethodIVF, Date: date(2026, 1, 29), EmbryoAgeDays: 5}, date(2026, 10, 17)},
+		{"ultrasound", DatingInput{Method: models.DatingMethodUltrasound, Date: date(2026, 3, 14), GestationalWeeks: 9}, date(2026, 10, 17)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateDueDate(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("due date = %s, want %s", got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestCalculateDueDateRejectsInvalidDating(t *testing.T) {
+	for _, input := range []DatingInput{
+		{Method: "guess", Date: date(2026, 1, 1)},
+		{Method: models.DatingMethodIVF, Date: date(2026, 1, 1), EmbryoAgeDays: 4},
+		{Method: models.DatingMethodUltrasound, Date: date(2026, 1, 1), GestationalWeeks: 8, GestationalDays: 7},
+		{Method: models.DatingMethodUltrasound, Date: date(2026, 1, 1), GestationalWeeks: 40},
+	} {
+		if _, err := CalculateDueDate(input); !errors.Is(err, ErrInvalidDating) {
+			t.Errorf("%+v: err = %v, want ErrInvalidDating", input, err)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reminder scheduling rules
+const (
+	minReminderLead     = 5
+	maxReminderLead     = 7 * 24 * 60
+	maxRemindersPerType = 4
+	// Events are planned this far beyond the longest allowed lead
+	reminderPlanHorizon = maxReminderLead*time.Minute + time.Hour
+	reminderBatchSize   = 100
+)
+
+// DefaultReminderLeads are sent 24 hours and 1 hour before an event
+var DefaultReminderLeads = []int64{24 * 60, 60}
+
+// reminderTurnedOff marks jobs skipped because of the user's settings; they
+// are revived if the setting is turned back on before they are due
+const reminderTurnedOff = "turned off by user"
+
+var ErrInvalidReminderPreference = fmt.Errorf("reminder type must be appointment or checkup with 1-%d lead times between %d minutes and 7 days", maxRemindersPerType, minReminderLead)
+
+// reminderTypeFor maps a job source to the preference that controls it
+func reminderTypeFor(sourceType string) string {
+	if sourceType == models.ReminderSourceAppointment {
+		return models.ReminderTypeAppointment
+	}
+	return models.ReminderTypeCheckup
+}
+
+// GetReminderPreference returns a user's settings for a reminder type, or the defaults
+func GetReminderPreference(userID uuid.UUID, reminderType string) (models.ReminderPreference, error) {
+	pref := models.ReminderPreference{UserID: userID, ReminderType: reminderType, Enabled: true, LeadMinutes: pq.Int64Array(DefaultReminderLeads)}
+	err := config.DB.Where("user_id = ? AND reminder_type = ?", userID, reminderType).First(&pref).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return pref, err
+	}
+	return pref, nil
+}
+
+// SetReminderPreference saves a user's settings for a reminder type
+func SetReminderPreference(userID uuid.UUID, reminderType string, enabled bool, leads []int64) (*models.ReminderPreference, error) {
+	if reminderType != models.ReminderTypeAppointment && reminderType != models.ReminderTypeCheckup {
+		return nil, ErrInvalidReminderPreference
+	}
+	if len(leads) == 0 || len(leads) > maxRemindersPerType {
+		return nil, ErrInvalidReminderPreference
+	}
+	seen := map[int64]bool{}
+	for _, l := range leads {
+		if l < minReminderLead || l > maxReminderLead || seen[l] {
+			return nil, ErrInvalidReminderPreference
+		}
+		seen[l] = true
+	}
+	sorted := append([]int64(nil), leads...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	pref := models.ReminderPreference{
+		UserID:       userID,
+		ReminderType: reminderType,
+		Enabled:      enabled,
+		LeadMinutes:  pq.Int64Array(sorted),
+		UpdatedAt:    time.Now(),
+	}
+	if err := config.DB.Save(&pref).Error; err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+// reminderEvent is something upcoming that a user should be reminded about
+type reminderEvent struct {
+	userID     uuid.UUID
+	sourceType string
+	sourceID   uuid.UUID
+	at         time.Time
+}
+
+// upsertReminderJobs creates a job per lead time. When the event has moved,
+// existing jobs are reset to the new time; otherwise they are left alone so a
+// reminder that was already sent is not sent again.
+func upsertReminderJobs(events []reminderEvent, now time.Time) error {
+	prefs := map[string]models.ReminderPreference{}
+	planned := map[string]bool{}
+	var jobs []models.ReminderJob
+	for _, e := range events {
+		// One INSERT cannot touch the same conflict key twice
+		eventKey := e.userID.String() + "/" + e.sourceType + "/" + e.sourceID.String()
+		if planned[eventKey] {
+			continue
+		}
+		planned[eventKey] = true
+
+		key := e.userID.String() + "/" + reminderTypeFor(e.sourceType)
+		pref, ok := prefs[key]
+		if !ok {
+			var err error
+			if pref, err = GetReminderPreference(e.userID, reminderTypeFor(e.sourceType)); err != nil {
+				return err
+			}
+			prefs[key] = pref
+		}
+		if !pref.Enabled {
+			continue
+		}
+		for _, lead := range pref.LeadMinutes {
+			jobs = append(jobs, models.ReminderJob{
+				ID:          uuid.New(),
+				UserID:      e.userID,
+				SourceType:  e.sourceType,
+				SourceID:    e.sourceID,
+				LeadMinutes: int(lead),
+				EventAt:     e.at,
+				DueAt:       e.at.Add(-time.Duration(lead) * time.Minute),
+				Status:      models.ReminderPending,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			})
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	return config.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "source_type"}, {Name: "source_id"}, {Name: "lead_minutes"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"event_at":   gorm.Expr("excluded.event_at"),
+			"due_at":     gorm.Expr("excluded.due_at"),
+			"status":     models.ReminderPending,
+			"note":       "",
+			"sent_at":    nil,
+			"updated_at": now,
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{
+				SQL:  "reminder_jobs.event_at <> excluded.event_at OR (reminder_jobs.status = ? AND reminder_jobs.note = ? AND excluded.due_at > ?)",
+				Vars: []interface{}{models.ReminderSkipped, reminderTurnedOff, now},
+			},
+		}},
+	}).CreateInBatches(&jobs, reminderBatchSize).Error
+}
+
+// appointmentReminderEvents reminds both the patient and the doctor of a confirmed appointment
+func appointmentReminderEvents(appt *models.Appointment) []reminderEvent {
+	if appt.Status != models.AppointmentConfirmed {
+		return nil
+	}
+	return []reminderEvent{
+		{userID: appt.UserID, sourceType: models.ReminderSourceAppointment, sourceID: appt.ID, at: appt.ScheduledAt},
+		{userID: appt.DoctorID, sourceType: models.ReminderSourceAppointment, sourceID: appt.ID, at: appt.ScheduledAt},
+	}
+}
+
+// ScheduleAppointmentReminders plans reminders for an appointment straight
+// after it changes, rather than waiting for the next planner run
+func ScheduleAppointmentReminders(appt *models.Appointment, now time.Time) {
+	if err := upsertReminderJobs(appointmentReminderEvents(appt), now); err != nil {
+		log.Printf("❌ Failed to schedule reminders for appointment %s: %v", appt.ID, err)
+	}
+}
+
+// PlanReminders creates reminder jobs for confirmed appointments and next
+// checkups coming up within the planning horizon
+func PlanReminders(now time.Time) error {
+	horizon := now.Add(reminderPlanHorizon)
+	var events []reminderEvent
+
+	var appts []models.Appointment
+	if err := config.DB.Where("status = ? AND scheduled_at > ? AND scheduled_at <= ?", models.AppointmentConfirmed, now, horizon).
+		Find(&appts).Error; err != nil {
+		return err
+	}
+	for i := range appts {
+		events = append(events, appointmentReminderEvents(&appts[i])...)
+	}
+
+	// A confirmed follow-up appointment sends its own reminders for the checkup
+	covered := config.DB.Model(&models.Appointment{}).Select("id").Where("status = ?", models.AppointmentConfirmed)
+	uncovered := "follow_up_appointment_id IS NULL OR follow_up_appointment_id NOT IN (?)"
+
+	var prenatal []models.PregnancyCheckup
+	if err := config.DB.Where("next_checkup_at > ? AND next_checkup_at <= ?", now, horizon).
+		Where(uncovered, covered).Find(&prenatal).Error; err != nil {
+		return err
+	}
+	for _, c := range prenatal {
+		events = append(events, reminderEvent{c.UserID, models.ReminderSourcePregnancyCheckup, c.ID, c.NextCheckupAt})
+	}
+
+	var postnatal []models.PostpartumCheckup
+	if err := config.DB.Where("next_checkup_at > ? AND next_checkup_at <= ?", now, horizon).
+		Where(uncovered, covered).Find(&postnatal).Error; err != nil {
+		return err
+	}
+	for _, c := range postnatal {
+		events = append(events, reminderEvent{c.UserID, models.ReminderSourcePostpartumCheckup, c.ID, c.NextCheckupAt})
+	}
+
+	return upsertReminderJobs(events, now)
+}
+
+// reminderContent checks that a job's event still stands and builds its
+// message. A non-empty cancelled reason means the job should not be sent.
+func reminderContent(tx *gorm.DB, job models.ReminderJob, now time.Time) (title, message, link, cancelled string, err error) {
+	in := formatMinutes(int(job.EventAt.Sub(now).Round(time.Minute).Minutes()))
+	when := job.EventAt.UTC().Format("Mon 2 Jan 15:04 MST")
+
+	switch job.SourceType {
+	case models.ReminderSourceAppointment:
+		var appt models.Appointment
+		if err := tx.Where("id = ?", job.SourceID).First(&appt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", "", "", "appointment deleted", nil
+			}
+			return "", "", "", "", err
+		}
+		if appt.Status != models.AppointmentConfirmed {
+			return "", "", "", "appointment is " + appt.Status, nil
+		}
+		if !appt.ScheduledAt.Equal(job.EventAt) {
+			return "", "", "", "appointment moved", nil
+		}
+		label := appt.Title
+		if label == "" {
+			label = "Appointment"
+		}
+		message := fmt.Sprintf("%s starts in %s (%s).", label, in, when)
+		if appt.Mode == models.AppointmentModeVirtual {
+			message += " It is a video visit; you can join from the app up to 10 minutes early."
+		}
+		return "Upcoming appointment", message, "/appointments/" + appt.ID.String(), "", nil
+
+	case models.ReminderSourcePregnancyCheckup, models.ReminderSourcePostpartumCheckup:
+		var next time.Time
+		var followUp *uuid.UUID
+		var link string
+		if job.SourceType == models.ReminderSourcePregnancyCheckup {
+			var c models.PregnancyCheckup
+			err = tx.Where("id = ?", job.SourceID).First(&c).Error
+			next, followUp, link = c.NextCheckupAt, c.FollowUpAppointmentID, checkupPath(models.CheckupKindPregnancy, job.SourceID)
+		} else {
+			var c models.PostpartumCheckup
+			err = tx.Where("id = ?", job.SourceID).First(&c).Error
+			next, followUp, link = c.NextCheckupAt, c.FollowUpAppointmentID, checkupPath(models.CheckupKindPostpartum, job.SourceID)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", "", "checkup deleted", nil
+		}
+		if err != nil {
+			return "", "", "", "", err
+		}
+		if !next.Equal(job.EventAt) {
+			return "", "", "", "next checkup moved", nil
+		}
+		if followUp != nil {
+			var confirmed int64
+			if err := tx.Model(&models.Appointment{}).Where("id = ? AND status = ?", *followUp, models.AppointmentConfirmed).
+				Count(&confirmed).Error; err != nil {
+				return "", "", "", "", err
+			}
+			if confirmed > 0 {
+				return "", "", "", "covered by the follow-up appointment", nil
+			}
+		}
+		return "Checkup coming up", fmt.Sprintf("Your next checkup is in %s (%s).", in, when), link, "", nil
+	}
+	return "", "", "", "unknown source", nil
+}
+
+// finishReminderJob records the outcome of a due job
+func finishReminderJob(tx *gorm.DB, job models.ReminderJob, status, note string, now time.Time) error {
+	updates := map[string]interface{}{"status": status, "note": note, "updated_at": now}
+	if status == models.ReminderSent {
+		updates["sent_at"] = now
+	}
+	return tx.Model(&models.ReminderJob{}).Where("id = ?", job.ID).Updates(updates).Error
+}
+
+// DispatchDueReminders sends reminders that have come due. Jobs are claimed
+// with FOR UPDATE SKIP LOCKED and the notification is written in the same
+// transaction as the job's new status, so concurrent runs and restarts never
+// send a reminder twice or lose one.
+func DispatchDueReminders(now time.Time) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var jobs []models.ReminderJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND due_at <= ?", models.ReminderPending, now).
+			Order("due_at asc").Limit(reminderBatchSize).Find(&jobs).Error; err != nil {
+			return err
+		}
+
+		prefs := map[string]models.ReminderPreference{}
+		for _, job := range jobs {
+			if !job.EventAt.After(now) {
+				if err := finishReminderJob(tx, job, models.ReminderSkipped, "event already started", now); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Respect the user's current settings, which may have changed since planning
+			key := job.UserID.String() + "/" + reminderTypeFor(job.SourceType)
+			pref, ok := prefs[key]
+			if !ok {
+				var err error
+				if pref, err = GetReminderPreference(job.UserID, reminderTypeFor(job.SourceType)); err != nil {
+					return err
+				}
+				prefs[key] = pref
+			}
+			wanted := false
+			for _, l := range pref.LeadMinutes {
+				wanted = wanted || int(l) == job.LeadMinutes
+			}
+			if !pref.Enabled || !wanted {
+				if err := finishReminderJob(tx, job, models.ReminderSkipped, reminderTurnedOff, now); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// After downtime several leads may be due at once; only the closest one is sent
+			var shorter int64
+			if err := tx.Model(&models.ReminderJob{}).
+				Where("user_id = ? AND source_type = ? AND source_id = ? AND lead_minutes < ? AND due_at <= ? AND status IN ?",
+					job.UserID, job.SourceType, job.SourceID, job.LeadMinutes, now,
+					[]string{models.ReminderPending, models.ReminderSent}).
+				Count(&shorter).Error; err != nil {
+				return err
+			}
+			if shorter > 0 {
+				if err := finishReminderJob(tx, job, models.ReminderSkipped, "superseded by a closer reminder", now); err != nil {
+					return err
+				}
+				continue
+			}
+
+			title, message, link, cancelled, err := reminderContent(tx, job, now)
+			if err != nil {
+				return err
+			}
+			if cancelled != "" {
+				if err := finishReminderJob(tx, job, models.ReminderCancelled, cancelled, now); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := notifyTx(tx, job.UserID, models.NotificationTypeReminder, title, message, link); err != nil {
+				return err
+			}
+			if err := finishReminderJob(tx, job, models.ReminderSent, "", now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// ScheduledJob is a background task run periodically inside the server process
+type ScheduledJob struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// scheduledJobs lists the jobs started by StartScheduler
+var scheduledJobs = []ScheduledJob{
+	{Name: "missed-doses", Interval: 15 * time.Minute, Run: CheckMissedDoses},
+	{Name: "late-periods", Interval: time.Hour, Run: NotifyLatePeriods},
+	{Name: "vaccination-reminders", Interval: time.Hour, Run: SendVaccinationReminders},
+	{Name: "pregnancy-week-content", Interval: time.Hour, Run: DeliverWeeklyPregnancyContent},
+	{Name: "feeding-reminders", Interval: 5 * time.Minute, Run: SendFeedingReminders},
+	{Name: "lam-status", Interval: time.Hour, Run: NotifyLAMEnded},
+	{Name: "reminder-planner", Interval: 10 * time.Minute, Run: PlanReminders},
+	{Name: "reminder-dispatch", Interval: time.Minute, Run: DispatchDueReminders},
+	{Name: "import-recovery", Interval: 5 * time.Minute, Run: ResumeInterruptedImports},
+	{Name: "visit-rooms", Interval: 5 * time.Minute, Run: CloseExpiredVisitRooms},
+}
+
+// StartScheduler launches every scheduled job in its own goroutine.
+// Jobs must be idempotent: they may run again after a restart.
+func StartScheduler() {
+	for _, job := range scheduledJobs {
+		go runJob(job)
+	}
+	log.Printf("⏰ Scheduler started with %d job(s)", len(scheduledJobs))
+}
+
+func runJob(job ScheduledJob) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(time.Now()); err != nil {
+			log.Printf("❌ Scheduled job %s failed: %v", job.Name, err)
+		}
+		<-ticker.C
+	}
+}
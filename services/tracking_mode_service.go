@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+)
+
+// LatePeriodThresholdDays is how many days past the expected start a period
+// must be before we suggest a pregnancy test.
+const LatePeriodThresholdDays = 5
+
+const defaultCycleLength = 28
+
+const latePeriodLink = "/cycles/late-check"
+
+var (
+	ErrNoCycleData           = errors.New("no cycle data recorded")
+	ErrActivePregnancyExists = errors.New("an active pregnancy already exists")
+	ErrPregnancyNotFound     = errors.New("pregnancy not found")
+	ErrPregnancyNotActive    = errors.New("pregnancy is not active")
+)
+
+// LatePeriodStatus describes how far the user is past their expected period
+type LatePeriodStatus struct {
+	TrackingMode         string    `json:"tracking_mode"`
+	LastPeriodStart      time.Time `json:"last_period_start"`
+	ExpectedPeriodStart  time.Time `json:"expected_period_start"`
+	DaysLate             int       `json:"days_late"`
+	SuggestPregnancyTest bool      `json:"suggest_pregnancy_test"`
+}
+
+// GetTrackingMode returns the user's current tracking mode, defaulting to cycle tracking
+func GetTrackingMode(userID uuid.UUID) (string, error) {
+	var user models.User
+	if err := config.DB.Select("id", "tracking_mode").First(&user, "id = ?", userID).Error; err != nil {
+		return "", err
+	}
+	if user.TrackingMode == "" {
+		return models.TrackingModeCycle, nil
+	}
+	return user.TrackingMode, nil
+}
+
+// UserLocation returns the user's time zone, or UTC when none is set
+func UserLocation(userID uuid.UUID) (*time.Location, error) {
+	var user models.User
+	if err := config.DB.Select("id", "time_zone").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	if user.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// CyclePredictionsPaused reports whether cycle predictions should be withheld
+// because the user is pregnant or postpartum without a returned period.
+func CyclePredictionsPaused(userID uuid.UUID) (bool, string, error) {
+	mode, err := GetTrackingMode(userID)
+	if err != nil {
+		return false, "", err
+	}
+	return mode != models.TrackingModeCycle, mode, nil
+}
+
+func setTrackingMode(tx *gorm.DB, userID uuid.UUID, mode string) error {
+	return tx.Model(&models.User{}).Where("id = ?", userID).Update("tracking_mode", mode).Error
+}
+
+func averageCycleLength(cycles []models.Cycle) int {
+	total, count := 0, 0
+	for _, c := range cycles {
+		if c.Length > 0 {
+			total += c.Length
+			count++
+		}
+	}
+	if count == 0 {
+		return defaultCycleLength
+	}
+	return int(float64(total)/float64(count) + 0.5)
+}
+
+func daysBetween(from, to time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// CheckLatePeriod compares today against the expected start of the next period
+func CheckLatePeriod(userID uuid.UUID, now time.Time) (*LatePeriodStatus, error) {
+	mode, err := GetTrackingMode(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cycles, _, err := BaselineCycles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cycles) == 0 {
+		return nil, ErrNoCycleData
+	}
+
+	last := cycles[len(cycles)-1]
+	expected := last.StartDate.AddDate(0, 0, averageCycleLength(cycles))
+	daysLate := daysBetween(expected, now)
+	if daysLate < 0 {
+		daysLate = 0
+	}
+
+	return &LatePeriodStatus{
+		TrackingMode:         mode,
+		LastPeriodStart:      last.StartDate,
+		ExpectedPeriodStart:  expected,
+		DaysLate:             daysLate,
+		SuggestPregnancyTest: mode == models.TrackingModeCycle && daysLate >= LatePeriodThresholdDays,
+	}, nil
+}
+
+// NotifyLatePeriods sends a one-off notification suggesting a pregnancy test
+// to cycle-tracking users whose period is late enough
+func NotifyLatePeriods(now time.Time) error {
+	var userIDs []uuid.UUID
+	if err := config.DB.Model(&models.User{}).
+		Where("tracking_mode = ? OR tracking_mode = '' OR tracking_mode IS NULL", models.TrackingModeCycle).
+		Where("id IN (?)", config.DB.Model(&models.Cycle{}).Select("user_id")).
+		Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		status, err := CheckLatePeriod(userID, now)
+		if errors.Is(err, ErrNoCycleData) {
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Failed to check late period for user %s: %v", userID, err)
+			continue
+		}
+		if !status.SuggestPregnancyTest {
+			continue
+		}
+		sent, err := HasNotificationSince(userID, latePeriodLink, status.ExpectedPeriodStart)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+		if err := Notify(userID, models.NotificationTypeReminder,
+			"Your period is late",
+			"Your period is several days late. Consider taking a pregnancy test and let us know the result.",
+			latePeriodLink); err != nil {
+			log.Printf("❌ Failed to send late period notification to user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// ensureNoActivePregnancy serialises pregnancy creation per user and refuses
+// a second active pregnancy
+func ensureNoActivePregnancy(tx *gorm.DB, userID uuid.UUID) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "pregnancy:"+userID.String()).Error; err != nil {
+		return err
+	}
+	var active int64
+	if err := tx.Model(&models.Pregnancy{}).
+		Where("user_id = ? AND status = ?", userID, models.PregnancyStatusActive).
+		Count(&active).Error; err != nil {
+		return err
+	}
+	if active > 0 {
+		return ErrActivePregnancyExists
+	}
+	return nil
+}
+
+// ConfirmPregnancyFromCycles starts a pregnancy dated from the last menstrual
+// period and pauses cycle predictions.
+func ConfirmPregnancyFromCycles(userID uuid.UUID, notes string) (*models.Pregnancy, error) {
+	var lastCycle models.Cycle
+	if err := config.DB.Where("user_id = ?", userID).Order("start_date desc").First(&lastCycle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoCycleData
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	pregnancy := models.Pregnancy{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.PregnancyStatusActive,
+		Notes:     notes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyDating(&pregnancy, DatingInput{Method: models.DatingMethodLMP, Date: lastCycle.StartDate}); err != nil {
+		return nil, err
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureNoActivePregnancy(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Create(&pregnancy).Error; err != nil {
+			return err
+		}
+		if err := recordDueDate(tx, &pregnancy, nil, "dated from last logged period"); err != nil {
+			return err
+		}
+		return setTrackingMode(tx, userID, models.TrackingModePregnancy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pregnancy, nil
+}
+
+// ResumeCyclesIfPeriodReturned switches a postpartum user back to cycle tracking
+// once they log a period that starts after their last pregnancy ended.
+func ResumeCyclesIfPeriodReturned(userID uuid.UUID, cycle models.Cycle) error {
+	mode, err := GetTrackingMode(userID)
+	if err != nil || mode != models.TrackingModePostpartum {
+		return err
+	}
+
+	pregnancy, err := LatestEndedPregnancy(userID)
+	if err != nil {
+		return err
+	}
+	if pregnancy != nil && !cycle.StartDate.After(*pregnancy.EndDate) {
+		return nil
+	}
+	// Bleeding in the weeks after a birth is lochia, not a returned period
+	if pregnancy != nil && pregnancy.Status == models.PregnancyStatusDelivered &&
+		daysBetween(*pregnancy.EndDate, cycle.StartDate) < lochiaWindowDays {
+		return nil
+	}
+
+	return setTrackingMode(config.DB, userID, models.TrackingModeCycle)
+}
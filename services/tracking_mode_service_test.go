@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestDaysBetweenIgnoresTimeOfDay(t *testing.T) {
+	from := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 6, 0, 15, 0, 0, time.UTC)
+	if got := daysBetween(from, to); got != 5 {
+		t.Fatalf("daysBetween = %d, want 5", got)
+	}
+	if got := daysBetween(to, from); got != -5 {
+		t.Fatalf("daysBetween reversed = %d, want -5", got)
+	}
+}
+
+func TestAverageCycleLength(t *testing.T) {
+	if got := averageCycleLength(nil); got != defaultCycleLength {
+		t.Fatalf("no cycles: got %d, want %d", got, defaultCycleLength)
+	}
+	cycles := []models.Cycle{{Length: 27}, {Length: 0}, {Length: 30}}
+	if got := averageCycleLength(cycles); got != 29 {
+		t.Fatalf("got %d, want 29 (unknown lengths skipped, rounded)", got)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/utils"
+)
+
+var (
+	ErrVideoRoomNotFound     = errors.New("video room not found")
+	ErrVideoRoomClosed       = errors.New("video room is closed")
+	ErrVideoTokenInvalid     = errors.New("join token is not valid")
+	ErrVideoTokenNotYetValid = errors.New("join token is not valid yet")
+	ErrVideoTokenExpired     = errors.New("join token has expired")
+)
+
+// VideoParticipant is someone a join token is issued to
+type VideoParticipant struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"` // "patient" or "doctor"
+}
+
+// VideoProvider is the service that hosts video visits. Implementations must
+// refuse tokens outside the window they were issued for.
+type VideoProvider interface {
+	// Name identifies the provider on stored rooms
+	Name() string
+	// CreateRoom sets up a room open between opensAt and closesAt and returns
+	// the provider's reference for it. Calling it again for a roomID the
+	// provider has lost sets the room up anew.
+	CreateRoom(roomID uuid.UUID, opensAt, closesAt time.Time) (string, error)
+	// JoinToken issues a credential for one participant, usable from notBefore until expiresAt
+	JoinToken(providerRoomID string, participant VideoParticipant, notBefore, expiresAt time.Time) (string, error)
+	// CloseRoom ends the room and disconnects anyone still in it
+	CloseRoom(providerRoomID string) error
+}
+
+// videoProvider hosts new visit rooms; replace it with SetVideoProvider
+var videoProvider VideoProvider = NewLocalVideoProvider()
+
+// SetVideoProvider switches the provider used for new visit rooms
+func SetVideoProvider(p VideoProvider) {
+	videoProvider = p
+}
+
+// LocalVideoProvider is an in-memory VideoProvider for development and tests.
+// It does not carry any media; it only keeps rooms and checks tokens.
+type LocalVideoProvider struct {
+	mu     sync.Mutex
+	rooms  map[string]*localVideoRoom
+	tokens map[string]localVideoGrant
+}
+
+type localVideoRoom struct {
+	opensAt, closesAt time.Time
+	closed            bool
+}
+
+type localVideoGrant struct {
+	roomID               string
+	participant          VideoParticipant
+	notBefore, expiresAt time.Time
+}
+
+// NewLocalVideoProvider returns an empty LocalVideoProvider
+func NewLocalVideoProvider() *LocalVideoProvider {
+	return &LocalVideoProvider{
+		rooms:  map[string]*localVideoRoom{},
+		tokens: map[string]localVideoGrant{},
+	}
+}
+
+// Name identifies the local provider
+func (p *LocalVideoProvider) Name() string {
+	return "local"
+}
+
+// CreateRoom registers a room under "local-<roomID>", replacing any room of that ID
+func (p *LocalVideoProvider) CreateRoom(roomID uuid.UUID, opensAt, closesAt time.Time) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ref := "local-" + roomID.String()
+	p.rooms[ref] = &localVideoRoom{opensAt: opensAt, closesAt: closesAt}
+	return ref, nil
+}
+
+// JoinToken issues a random token for an open room
+func (p *LocalVideoProvider) JoinToken(providerRoomID string, participant VideoParticipant, notBefore, expiresAt time.Time) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room, ok := p.rooms[providerRoomID]
+	if !ok {
+		return "", ErrVideoRoomNotFound
+	}
+	if room.closed {
+		return "", ErrVideoRoomClosed
+	}
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	p.tokens[token] = localVideoGrant{roomID: providerRoomID, participant: participant, notBefore: notBefore, expiresAt: expiresAt}
+	return token, nil
+}
+
+// CloseRoom closes the room and revokes its tokens
+func (p *LocalVideoProvider) CloseRoom(providerRoomID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room, ok := p.rooms[providerRoomID]
+	if !ok {
+		return ErrVideoRoomNotFound
+	}
+	room.closed = true
+	for token, grant := range p.tokens {
+		if grant.roomID == providerRoomID {
+			delete(p.tokens, token)
+		}
+	}
+	return nil
+}
+
+// ValidateToken checks a token the way a media server would on connect and
+// returns who it admits to which room
+func (p *LocalVideoProvider) ValidateToken(token string, now time.Time) (string, VideoParticipant, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	grant, ok := p.tokens[token]
+	if !ok {
+		return "", VideoParticipant{}, ErrVideoTokenInvalid
+	}
+	room := p.rooms[grant.roomID]
+	switch {
+	case room == nil || room.closed:
+		return "", VideoParticipant{}, ErrVideoRoomClosed
+	case now.Before(grant.notBefore) || now.Before(room.opensAt):
+		return "", VideoParticipant{}, ErrVideoTokenNotYetValid
+	case !now.Before(grant.expiresAt) || !now.Before(room.closesAt):
+		return "", VideoParticipant{}, ErrVideoTokenExpired
+	}
+	return grant.roomID, grant.participant, nil
+}
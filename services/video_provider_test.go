@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLocalVideoProviderTokenWindow(t *testing.T) {
+	opensAt := time.Date(2026, 6, 1, 9, 50, 0, 0, time.UTC)
+	closesAt := time.Date(2026, 6, 1, 10, 45, 0, 0, time.UTC)
+	participant := VideoParticipant{UserID: uuid.New(), Role: appointmentActorPatient}
+
+	p := NewLocalVideoProvider()
+	ref, err := p.CreateRoom(uuid.New(), opensAt, closesAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := p.JoinToken(ref, participant, opensAt, closesAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		at   time.Time
+		want error
+	}{
+		{"before notBefore", opensAt.Add(-time.Second), ErrVideoTokenNotYetValid},
+		{"at notBefore", opensAt, nil},
+		{"during the visit", opensAt.Add(30 * time.Minute), nil},
+		{"at expiresAt", closesAt, ErrVideoTokenExpired},
+		{"after expiresAt", closesAt.Add(time.Minute), ErrVideoTokenExpired},
+	}
+	for _, tc := range cases {
+		room, who, err := p.ValidateToken(token, tc.at)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+			continue
+		}
+		if tc.want == nil && (room != ref || who != participant) {
+			t.Errorf("%s: token admitted %v to %s", tc.name, who, room)
+		}
+	}
+
+	if _, _, err := p.ValidateToken("not-a-token", opensAt.Add(time.Minute)); !errors.Is(err, ErrVideoTokenInvalid) {
+		t.Errorf("unknown token: got %v", err)
+	}
+}
+
+func TestLocalVideoProviderCloseRoom(t *testing.T) {
+	opensAt := time.Date(2026, 6, 1, 9, 50, 0, 0, time.UTC)
+	closesAt := opensAt.Add(time.Hour)
+	during := opensAt.Add(10 * time.Minute)
+	participant := VideoParticipant{UserID: uuid.New(), Role: appointmentActorDoctor}
+
+	p := NewLocalVideoProvider()
+	ref, _ := p.CreateRoom(uuid.New(), opensAt, closesAt)
+	token, err := p.JoinToken(ref, participant, opensAt, closesAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CloseRoom(ref); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := p.ValidateToken(token, during); err == nil {
+		t.Error("token still works after the room closed")
+	}
+	if len(p.tokens) != 0 {
+		t.Errorf("%d token(s) kept after the room closed", len(p.tokens))
+	}
+	if _, err := p.JoinToken(ref, participant, opensAt, closesAt); !errors.Is(err, ErrVideoRoomClosed) {
+		t.Errorf("join token for a closed room: got %v", err)
+	}
+	if _, err := p.JoinToken("local-unknown", participant, opensAt, closesAt); !errors.Is(err, ErrVideoRoomNotFound) {
+		t.Errorf("join token for an unknown room: got %v", err)
+	}
+}
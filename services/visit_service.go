@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shem958/cycle-backend/config"
+	"github.com/shem958/cycle-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// A video visit can be joined from VisitJoinEarly before the start until
+// VisitJoinGrace after the booked end
+const (
+	VisitJoinEarly = 10 * time.Minute
+	VisitJoinGrace = 15 * time.Minute
+)
+
+var (
+	ErrInvalidAppointmentMode = errors.New("mode must be \"in_person\" or \"virtual\"")
+	ErrNotVirtualVisit        = errors.New("this appointment is not a video visit")
+	ErrVisitNotConfirmed      = errors.New("only confirmed appointments can be joined")
+	ErrVisitNotOpenYet        = errors.New("the video visit is not open yet")
+	ErrVisitEnded             = errors.New("the video visit has ended")
+	ErrVisitNotJoined         = errors.New("you are not in this video visit")
+)
+
+// visitWindow is when the video room for an appointment admits participants
+func visitWindow(appt *models.Appointment) (opensAt, closesAt time.Time) {
+	return appt.ScheduledAt.Add(-VisitJoinEarly), appt.EndsAt.Add(VisitJoinGrace)
+}
+
+// VisitJoin is what a participant needs to connect to a video visit
+type VisitJoin struct {
+	Room      models.VisitRoom `json:"room"`
+	Token     string           `json:"token"`
+	NotBefore time.Time        `json:"not_before"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// checkVisitJoinable says whether the appointment's video visit can be joined at now
+func checkVisitJoinable(appt *models.Appointment, now time.Time) error {
+	if appt.Mode != models.AppointmentModeVirtual {
+		return ErrNotVirtualVisit
+	}
+	if appt.Status != models.AppointmentConfirmed {
+		return ErrVisitNotConfirmed
+	}
+	opensAt, closesAt := visitWindow(appt)
+	if now.Before(opensAt) {
+		return fmt.Errorf("%w: it opens at %s", ErrVisitNotOpenYet, opensAt.UTC().Format(time.RFC3339))
+	}
+	if !now.Before(closesAt) {
+		return ErrVisitEnded
+	}
+	return nil
+}
+
+// findOpenVisitRoom returns the appointment's open room, or nil if it has none
+func findOpenVisitRoom(db *gorm.DB, appointmentID uuid.UUID) (*models.VisitRoom, error) {
+	var room models.VisitRoom
+	err := db.Where("appointment_id = ? AND status = ?", appointmentID, models.VisitRoomOpen).First(&room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// ensureVisitRoom returns the appointment's open room, creating it with the
+// provider on first use. The provider is called outside any transaction; a
+// room created by a join that lost the race to store one is closed again.
+func ensureVisitRoom(appt *models.Appointment, now time.Time) (*models.VisitRoom, error) {
+	room, err := findOpenVisitRoom(config.DB, appt.ID)
+	if err != nil || room != nil {
+		return room, err
+	}
+
+	opensAt, closesAt := visitWindow(appt)
+	room = &models.VisitRoom{
+		ID:            uuid.New(),
+		AppointmentID: appt.ID,
+		Provider:      videoProvider.Name(),
+		OpensAt:       opensAt,
+		ClosesAt:      closesAt,
+		Status:        models.VisitRoomOpen,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if room.ProviderRoomID, err = videoProvider.CreateRoom(room.ID, opensAt, closesAt); err != nil {
+		return nil, fmt.Errorf("create video room: %w", err)
+	}
+
+	var existing *models.VisitRoom
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the appointment so two first joins do not both store a room, and
+		// make sure it was not cancelled while the room was being set up
+		var locked models.Appointment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", appt.ID).
+			First(&locked).Error; err != nil {
+			return err
+		}
+		if err := checkVisitJoinable(&locked, now); err != nil {
+			return err
+		}
+		var err error
+		if existing, err = findOpenVisitRoom(tx, appt.ID); err != nil || existing != nil {
+			return err
+		}
+		return tx.Create(room).Error
+	})
+	if err != nil || existing != nil {
+		if cerr := videoProvider.CloseRoom(room.ProviderRoomID); cerr != nil {
+			log.Printf("❌ Failed to close unused video room %s: %v", room.ProviderRoomID, cerr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+	return room, nil
+}
+
+// visitJoinToken issues a join token for the room. If the provider no longer
+// knows the room, as happens when an in-memory provider restarts, the room is
+// created again under the same ID.
+func visitJoinToken(room *models.VisitRoom, participant VideoParticipant, now time.Time) (string, error) {
+	token, err := videoProvider.JoinToken(room.ProviderRoomID, participant, room.OpensAt, room.ClosesAt)
+	if !errors.Is(err, ErrVideoRoomNotFound) {
+		return token, err
+	}
+
+	ref, err := videoProvider.CreateRoom(room.ID, room.OpensAt, room.ClosesAt)
+	if err != nil {
+		return "", fmt.Errorf("recreate video room: %w", err)
+	}
+	if err := config.DB.Model(&models.VisitRoom{}).Where("id = ?", room.ID).
+		Updates(map[string]interface{}{"provider_room_id": ref, "updated_at": now}).Error; err != nil {
+		return "", err
+	}
+	room.ProviderRoomID = ref
+	return videoProvider.JoinToken(ref, participant, room.OpensAt, room.ClosesAt)
+}
+
+// JoinVisit admits the patient or doctor to a confirmed virtual appointment
+// during its window: it opens the room if needed, issues a join token that
+// expires when the room closes and records the join
+func JoinVisit(appointmentID, userID uuid.UUID, now time.Time) (*VisitJoin, error) {
+	appt, err := GetAppointmentForParty(appointmentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := appointmentActorRole(appt, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVisitJoinable(appt, now); err != nil {
+		return nil, err
+	}
+
+	room, err := ensureVisitRoom(appt, now)
+	if err != nil {
+		return nil, err
+	}
+	token, err := visitJoinToken(room, VideoParticipant{UserID: userID, Role: role}, now)
+	if err != nil {
+		return nil, fmt.Errorf("issue join token: %w", err)
+	}
+	join := VisitJoin{Room: *room, Token: token, NotBefore: room.OpensAt, ExpiresAt: room.ClosesAt}
+
+	firstJoin := false
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialise joins to the room so the first one is only announced once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", room.ID).
+			First(&models.VisitRoom{}).Error; err != nil {
+			return err
+		}
+		var joins int64
+		if err := tx.Model(&models.VisitEvent{}).Where("room_id = ? AND user_id = ? AND action = ?", room.ID, userID, models.VisitEventJoined).
+			Count(&joins).Error; err != nil {
+			return err
+		}
+		firstJoin = joins == 0
+		return tx.Create(&models.VisitEvent{
+			ID:            uuid.New(),
+			RoomID:        room.ID,
+			AppointmentID: appt.ID,
+			UserID:        userID,
+			Role:          role,
+			Action:        models.VisitEventJoined,
+			CreatedAt:     now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if firstJoin {
+		recipient, who := appt.DoctorID, "Your patient"
+		if role == appointmentActorDoctor {
+			recipient, who = appt.UserID, "Your doctor"
+		}
+		if err := Notify(recipient, models.NotificationTypeAppointment, "Video visit started",
+			who+" has joined the video visit.", "/appointments/"+appt.ID.String()+"/visit"); err != nil {
+			log.Printf("❌ Failed to send visit notification for %s: %v", appt.ID, err)
+		}
+	}
+	return &join, nil
+}
+
+// LeaveVisit records that a participant left the appointment's current room
+func LeaveVisit(appointmentID, userID uuid.UUID, now time.Time) error {
+	appt, err := GetAppointmentForParty(appointmentID, userID)
+	if err != nil {
+		return err
+	}
+	role, err := appointmentActorRole(appt, userID)
+	if err != nil {
+		return err
+	}
+
+	var room models.VisitRoom
+	if err := config.DB.Where("appointment_id = ?", appt.ID).Order("created_at desc").First(&room).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVisitNotJoined
+		}
+		return err
+	}
+	var last models.VisitEvent
+	if err := config.DB.Where("room_id = ? AND user_id = ?", room.ID, userID).Order("created_at desc").First(&last).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVisitNotJoined
+		}
+		return err
+	}
+	if last.Action != models.VisitEventJoined {
+		return ErrVisitNotJoined
+	}
+
+	return config.DB.Create(&models.VisitEvent{
+		ID:            uuid.New(),
+		RoomID:        room.ID,
+		AppointmentID: appt.ID,
+		UserID:        userID,
+		Role:          role,
+		Action:        models.VisitEventLeft,
+		CreatedAt:     now,
+	}).Error
+}
+
+// GetVisitRooms lists an appointment's video rooms and their join and leave events
+func GetVisitRooms(appointmentID uuid.UUID) ([]models.VisitRoom, []models.VisitEvent, error) {
+	rooms := []models.VisitRoom{}
+	if err := config.DB.Where("appointment_id = ?", appointmentID).Order("created_at asc").Find(&rooms).Error; err != nil {
+		return nil, nil, err
+	}
+	events := []models.VisitEvent{}
+	if err := config.DB.Where("appointment_id = ?", appointmentID).Order("created_at asc").Find(&events).Error; err != nil {
+		return nil, nil, err
+	}
+	return rooms, events, nil
+}
+
+// closeVisitRoom ends a room with its provider and marks it closed
+func closeVisitRoom(room *models.VisitRoom, now time.Time) error {
+	if err := videoProvider.CloseRoom(room.ProviderRoomID); err != nil && !errors.Is(err, ErrVideoRoomNotFound) {
+		return err
+	}
+	return config.DB.Model(&models.VisitRoom{}).Where("id = ? AND status = ?", room.ID, models.VisitRoomOpen).
+		Updates(map[string]interface{}{"status": models.VisitRoomClosed, "closed_at": now, "updated_at": now}).Error
+}
+
+// CloseAppointmentVisit closes the open room of an appointment that was
+// moved, cancelled or finished. A moved visit gets a new room on the next join.
+func CloseAppointmentVisit(appointmentID uuid.UUID, now time.Time) {
+	var rooms []models.VisitRoom
+	if err := config.DB.Where("appointment_id = ? AND status = ?", appointmentID, models.VisitRoomOpen).Find(&rooms).Error; err != nil {
+		log.Printf("❌ Failed to load video rooms for appointment %s: %v", appointmentID, err)
+		return
+	}
+	for i := range rooms {
+		if err := closeVisitRoom(&rooms[i], now); err != nil {
+			log.Printf("❌ Failed to close video room %s: %v", rooms[i].ID, err)
+		}
+	}
+}
+
+// CloseExpiredVisitRooms closes rooms whose window has passed
+func CloseExpiredVisitRooms(now time.Time) error {
+	var rooms []models.VisitRoom
+	if err := config.DB.Where("status = ? AND closes_at <= ?", models.VisitRoomOpen, now).Find(&rooms).Error; err != nil {
+		return err
+	}
+	for i := range rooms {
+		if err := closeVisitRoom(&rooms[i], now); err != nil {
+			log.Printf("❌ Failed to close video room %s: %v", rooms[i].ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shem958/cycle-backend/models"
+)
+
+func TestCheckVisitJoinable(t *testing.T) {
+	start := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	appt := models.Appointment{
+		Mode:        models.AppointmentModeVirtual,
+		Status:      models.AppointmentConfirmed,
+		ScheduledAt: start,
+		EndsAt:      start.Add(30 * time.Minute),
+	}
+
+	cases := []struct {
+		name string
+		at   time.Time
+		want error
+	}{
+		{"too early", start.Add(-VisitJoinEarly - time.Minute), ErrVisitNotOpenYet},
+		{"just opened", start.Add(-VisitJoinEarly), nil},
+		{"during", start.Add(20 * time.Minute), nil},
+		{"in the grace period", start.Add(30*time.Minute + VisitJoinGrace - time.Second), nil},
+		{"after the grace period", start.Add(30*time.Minute + VisitJoinGrace), ErrVisitEnded},
+	}
+	for _, tc := range cases {
+		if err := checkVisitJoinable(&appt, tc.at); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	inPerson := appt
+	inPerson.Mode = models.AppointmentModeInPerson
+	if err := checkVisitJoinable(&inPerson, start); !errors.Is(err, ErrNotVirtualVisit) {
+		t.Errorf("in-person visit: got %v", err)
+	}
+	requested := appt
+	requested.Status = models.AppointmentRequested
+	if err := checkVisitJoinable(&requested, start); !errors.Is(err, ErrVisitNotConfirmed) {
+		t.Errorf("unconfirmed visit: got %v", err)
+	}
+}